@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
+)
+
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e apiError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("api responded %d: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("api responded %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// problem is the part of the problem+json error body shown to the admin.
+type problem struct {
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+	Errors []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (p problem) message() string {
+	var fields []string
+	for _, fieldError := range p.Errors {
+		fields = append(fields, fieldError.Field+": "+fieldError.Message)
+	}
+	if len(fields) == 0 {
+		return p.Detail
+	}
+	return p.Detail + " (" + strings.Join(fields, "; ") + ")"
+}
+
+type client struct {
+	baseURL    string
+	password   string
+	httpClient *http.Client
+}
+
+func newClient(cfg config) client {
+	return client{
+		baseURL:    strings.TrimRight(cfg.URL, "/"),
+		password:   cfg.Password,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c client) do(ctx context.Context, method string, path string, query url.Values, body any, result any) error {
+	var requestBody io.Reader
+	if body != nil {
+		bytesBody, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshaling request body: %w", err)
+		}
+		requestBody = bytes.NewReader(bytesBody)
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	request, err := http.NewRequestWithContext(ctx, method, target, requestBody)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	request.SetBasicAuth("admin", c.password)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		var body problem
+		if err := json.Unmarshal(responseBody, &body); err != nil || body.Code == "" {
+			return apiError{Status: response.StatusCode, Message: strings.TrimSpace(string(responseBody))}
+		}
+		return apiError{Status: response.StatusCode, Code: body.Code, Message: body.message()}
+	}
+
+	if err := json.Unmarshal(responseBody, result); err != nil {
+		return fmt.Errorf("parsing response body: %w", err)
+	}
+	return nil
+}
+
+type billingFilter struct {
+	Overdue    bool
+	AssigneeId string
+}
+
+func (c client) getBillings(ctx context.Context, filter billingFilter) ([]dto.Billing, error) {
+	query := url.Values{}
+	if filter.Overdue {
+		query.Set("overdue", "true")
+	}
+	if filter.AssigneeId != "" {
+		query.Set("assignee_id", filter.AssigneeId)
+	}
+	var billings []dto.Billing
+	err := c.do(ctx, http.MethodGet, "/admin/billings", query, nil, &billings)
+	return billings, err
+}
+
+func (c client) nextState(ctx context.Context, billingId string) (dto.Billing, error) {
+	var billing dto.Billing
+	err := c.do(ctx, http.MethodPatch, "/admin/billing/state/next/"+url.PathEscape(billingId), nil, nil, &billing)
+	return billing, err
+}
+
+func (c client) prevState(ctx context.Context, billingId string) (dto.Billing, error) {
+	var billing dto.Billing
+	err := c.do(ctx, http.MethodPatch, "/admin/billing/state/prev/"+url.PathEscape(billingId), nil, nil, &billing)
+	return billing, err
+}
+
+func (c client) getUsers(ctx context.Context) ([]dto.User, error) {
+	var users []dto.User
+	err := c.do(ctx, http.MethodGet, "/admin/users", nil, nil, &users)
+	return users, err
+}
+
+func (c client) createUser(ctx context.Context, telegramUN string) (dto.User, error) {
+	var user dto.User
+	err := c.do(ctx, http.MethodPost, "/user", nil, dto.CreateUserInfo{TelegramUN: telegramUN}, &user)
+	return user, err
+}
+
+func (c client) deleteUser(ctx context.Context, userId string) (dto.User, error) {
+	var user dto.User
+	err := c.do(ctx, http.MethodDelete, "/admin/user/"+url.PathEscape(userId), nil, nil, &user)
+	return user, err
+}
+
+func (c client) restoreUser(ctx context.Context, userId string) (dto.User, error) {
+	var user dto.User
+	err := c.do(ctx, http.MethodPatch, "/admin/user/restore/"+url.PathEscape(userId), nil, nil, &user)
+	return user, err
+}
+
+func (c client) eraseUser(ctx context.Context, userId string) (dto.User, error) {
+	var user dto.User
+	err := c.do(ctx, http.MethodPatch, "/admin/user/erase/"+url.PathEscape(userId), nil, nil, &user)
+	return user, err
+}
+
+func (c client) deleteBilling(ctx context.Context, billingId string) (dto.Billing, error) {
+	var billing dto.Billing
+	err := c.do(ctx, http.MethodDelete, "/admin/billing/"+url.PathEscape(billingId), nil, nil, &billing)
+	return billing, err
+}
+
+func (c client) restoreBilling(ctx context.Context, billingId string) (dto.Billing, error) {
+	var billing dto.Billing
+	err := c.do(ctx, http.MethodPatch, "/admin/billing/restore/"+url.PathEscape(billingId), nil, nil, &billing)
+	return billing, err
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	envConfig   = "BILLING_ADMIN_CONFIG"
+	envURL      = "BILLING_ADMIN_URL"
+	envPassword = "BILLING_ADMIN_PASSWORD"
+)
+
+type config struct {
+	URL      string `json:"url"`
+	Password string `json:"password"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "billing_admin.json"
+	}
+	return filepath.Join(dir, "billing_admin", "config.json")
+}
+
+// loadConfig reads the config file and lets env vars override it, a missing
+// file is fine as long as env vars provide everything.
+func loadConfig(path string) (config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(envConfig)
+		explicit = path != ""
+	}
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	cfg := config{}
+	bytes, err := os.ReadFile(path)
+	if err != nil && (explicit || !errors.Is(err, fs.ErrNotExist)) {
+		return config{}, fmt.Errorf("reading config file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(bytes, &cfg); err != nil {
+			return config{}, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	}
+
+	if url := os.Getenv(envURL); url != "" {
+		cfg.URL = url
+	}
+	if password := os.Getenv(envPassword); password != "" {
+		cfg.Password = password
+	}
+
+	if cfg.URL == "" {
+		return config{}, fmt.Errorf("api url is not set, put it into %s or %s", path, envURL)
+	}
+	if cfg.Password == "" {
+		return config{}, fmt.Errorf("admin password is not set, put it into %s or %s", path, envPassword)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
+)
+
+const usage = `Usage: billing_admin [-config path] [-output table|json] <command> [args]
+
+Commands:
+  billings list [-state state] [-user-id id] [-overdue] [-assignee id]
+  billings next <billing id>
+  billings prev <billing id>
+  billings delete <billing id>
+  billings restore <billing id>
+  users list
+  users create <telegram username>
+  users delete <user id>
+  users restore <user id>
+  users erase <user id>
+
+The API url and admin password are read from the config file
+(%s by default, or $%s) and can be overridden
+with $%s and $%s.
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	flags := flag.NewFlagSet("billing_admin", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), usage, defaultConfigPath(), envConfig, envURL, envPassword)
+	}
+	configPath := flags.String("config", "", "path to the config file")
+	output := flags.String("output", outputTable, "output format: table or json")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if *output != outputTable && *output != outputJSON {
+		fmt.Fprintln(os.Stderr, "output must be table or json")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, *configPath, printer{out: os.Stdout, format: *output}, flags.Args())
+	if errors.Is(err, errUsage) {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, configPath string, printer printer, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	client := newClient(cfg)
+
+	resource, command, args := args[0], args[1], args[2:]
+	switch resource + " " + command {
+	case "billings list":
+		return listBillings(ctx, client, printer, args)
+	case "billings next", "billings prev":
+		if len(args) != 1 {
+			return errUsage
+		}
+		change := client.nextState
+		if command == "prev" {
+			change = client.prevState
+		}
+		billing, err := change(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return printer.billings([]dto.Billing{billing})
+	case "billings delete", "billings restore":
+		if len(args) != 1 {
+			return errUsage
+		}
+		change := client.deleteBilling
+		if command == "restore" {
+			change = client.restoreBilling
+		}
+		billing, err := change(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return printer.billings([]dto.Billing{billing})
+	case "users list":
+		users, err := client.getUsers(ctx)
+		if err != nil {
+			return err
+		}
+		return printer.users(users)
+	case "users create":
+		if len(args) != 1 {
+			return errUsage
+		}
+		user, err := client.createUser(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return printer.users([]dto.User{user})
+	case "users delete", "users restore", "users erase":
+		if len(args) != 1 {
+			return errUsage
+		}
+		change := client.deleteUser
+		switch command {
+		case "restore":
+			change = client.restoreUser
+		case "erase":
+			change = client.eraseUser
+		}
+		user, err := change(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return printer.users([]dto.User{user})
+	}
+	return errUsage
+}
+
+// listBillings filters by overdue and assignee on the server,
+// state and user id are filtered here.
+func listBillings(ctx context.Context, client client, printer printer, args []string) error {
+	flags := flag.NewFlagSet("billings list", flag.ContinueOnError)
+	state := flags.String("state", "", "only billings in this state")
+	userId := flags.String("user-id", "", "only billings of this user")
+	overdue := flags.Bool("overdue", false, "only overdue billings")
+	assigneeId := flags.String("assignee", "", "only billings whose current stage is assigned to this staff member")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	billings, err := client.getBillings(ctx, billingFilter{
+		Overdue:    *overdue,
+		AssigneeId: *assigneeId,
+	})
+	if err != nil {
+		return err
+	}
+
+	filtered := []dto.Billing{}
+	for _, billing := range billings {
+		if *state != "" && billing.State != *state {
+			continue
+		}
+		if *userId != "" && billing.UserId != *userId {
+			continue
+		}
+		filtered = append(filtered, billing)
+	}
+	return printer.billings(filtered)
+}
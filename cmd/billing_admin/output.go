@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+type printer struct {
+	out    io.Writer
+	format string
+}
+
+func (p printer) json(value any) error {
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func (p printer) table(header []string, rows [][]string) error {
+	writer := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+func (p printer) billings(billings []dto.Billing) error {
+	if p.format == outputJSON {
+		return p.json(billings)
+	}
+	rows := [][]string{}
+	for _, billing := range billings {
+		deadline := "-"
+		if billing.Deadline != nil {
+			deadline = billing.Deadline.Format(time.RFC3339)
+		}
+		if billing.Overdue {
+			deadline += " (overdue)"
+		}
+		rows = append(rows, []string{
+			billing.Id,
+			billing.UserId,
+			billing.State,
+			billing.Priority,
+			deadline,
+			fmt.Sprintf("%.2f", float64(billing.Outstanding)/100),
+		})
+	}
+	return p.table([]string{"ID", "USER ID", "STATE", "PRIORITY", "DEADLINE", "OUTSTANDING"}, rows)
+}
+
+func (p printer) users(users []dto.User) error {
+	if p.format == outputJSON {
+		return p.json(users)
+	}
+	rows := [][]string{}
+	for _, user := range users {
+		rows = append(rows, []string{user.Id, user.TelegramUN})
+	}
+	return p.table([]string{"ID", "TELEGRAM USERNAME"}, rows)
+}
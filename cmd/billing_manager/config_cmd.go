@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ThePositree/billing_manager/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newConfigCommand(opts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Load the config from every source and report all problems",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := opts.loadConfig()
+			var invalidErr config.ErrInvalidConfig
+			if errors.As(err, &invalidErr) {
+				for _, problem := range invalidErr.Problems {
+					fmt.Fprintln(cmd.ErrOrStderr(), "-", problem)
+				}
+				return errors.New("config is invalid")
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := parseSettings(cfg); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "config is valid")
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Print the effective config with secrets redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(cfg.Redacted())
+		},
+	})
+	return cmd
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	repository_crypto "github.com/ThePositree/billing_manager/internal/adapter/repository/crypto"
+	"github.com/ThePositree/billing_manager/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newEncryptionCommand(opts *rootOptions) *cobra.Command {
+	rotate := &cobra.Command{
+		Use:   "rotate",
+		Short: "Re-encrypt personal data with the current encryption key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			logger, err := opts.newLogger(os.Stderr)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			if cfg.Storage != config.StorageMongo {
+				return fmt.Errorf("field encryption is only supported by the %s storage", config.StorageMongo)
+			}
+			if len(cfg.EncryptionKeys) == 0 {
+				return fmt.Errorf("encryption is off, set encryption_keys")
+			}
+
+			storage, err := openStorage(cmd.Context(), cfg)
+			if err != nil {
+				return err
+			}
+			defer storage.close(logger)
+
+			cipher, err := repository_crypto.New(cfg.Encryption())
+			if err != nil {
+				return err
+			}
+			db := storage.mongoClient.Database(cfg.Database)
+			for _, collection := range []struct {
+				name   string
+				fields []repository_crypto.Field
+			}{
+				{name: cfg.UserCollection, fields: []repository_crypto.Field{{Path: "telegram_username", Index: "telegram_username_index"}}},
+				{name: cfg.BillingCollection, fields: []repository_crypto.Field{{Path: "username"}}},
+				{name: cfg.EventCollection, fields: []repository_crypto.Field{{Path: "username"}}},
+				{name: cfg.SnapshotCollection, fields: []repository_crypto.Field{{Path: "billing.username"}}},
+			} {
+				rotated, err := cipher.Rotate(cmd.Context(), db.Collection(collection.name), collection.fields)
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: re-encrypted %d documents\n", collection.name, rotated)
+				if err != nil {
+					return fmt.Errorf("rotating %s: %w", collection.name, err)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "encryption",
+		Short: "Manage field encryption of personal data",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(rotate)
+	return cmd
+}
@@ -3,76 +3,177 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
-	mongo_billing_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/mongo"
-	mongo_user_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/user/mongo"
 	"github.com/ThePositree/billing_manager/internal/config"
-	http_controller "github.com/ThePositree/billing_manager/internal/controller/http"
-	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing/billing_managing_std"
-	"github.com/ThePositree/billing_manager/internal/usecase/user_managing/user_managing_std"
 	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func main() {
-	logger := zerolog.New(zerolog.ConsoleWriter{
-		Out:        os.Stdout,
-		TimeFormat: time.RFC3339,
-	}).With().Timestamp().Logger()
+// envConfig names the config file when --config is not given.
+const envConfig = config.EnvPrefix + "CONFIG"
 
-	ctx, stop := signal.NotifyContext(
-		context.Background(),
-		syscall.SIGKILL,
-		syscall.SIGABRT,
-		syscall.SIGQUIT,
-		syscall.SIGTERM,
-		os.Interrupt,
-	)
-	defer stop()
+const (
+	logFormatConsole = "console"
+	logFormatJSON    = "json"
+)
+
+// rootOptions are the persistent flags shared by every command.
+type rootOptions struct {
+	configPath     string
+	configRequired bool
+	overrides      []string
+	logLevel       string
+	logFormat      string
+}
 
-	cfg, err := config.New()
+// newLogger writes to out, commands that stream data through stdout log to stderr.
+// The level is global so that config reloads can change it.
+func (o *rootOptions) newLogger(out io.Writer) (zerolog.Logger, error) {
+	level := zerolog.InfoLevel.String()
+	if o.logLevel != "" {
+		level = o.logLevel
+	}
+	if err := setLogLevel(level); err != nil {
+		return zerolog.Logger{}, err
+	}
+
+	switch o.logFormat {
+	case logFormatConsole:
+		out = zerolog.ConsoleWriter{
+			Out:        out,
+			TimeFormat: time.RFC3339,
+		}
+	case logFormatJSON:
+	default:
+		return zerolog.Logger{}, fmt.Errorf("log format must be %s or %s", logFormatConsole, logFormatJSON)
+	}
+
+	return zerolog.New(out).With().Timestamp().Logger(), nil
+}
+
+func setLogLevel(value string) error {
+	level, err := zerolog.ParseLevel(value)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed create config")
+		return fmt.Errorf("parsing log level: %w", err)
 	}
+	zerolog.SetGlobalLevel(level)
+	return nil
+}
 
-	mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+// loadConfig reads the config and applies its log level.
+func (o *rootOptions) loadConfig() (config.Config, error) {
+	cfg, err := o.readConfig()
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed create mongo connect")
+		return config.Config{}, err
+	}
+	if err := o.applyLogLevel(cfg); err != nil {
+		return config.Config{}, err
+	}
+	return cfg, nil
+}
+
+// applyLogLevel leaves the level alone when --log-level pins it.
+func (o *rootOptions) applyLogLevel(cfg config.Config) error {
+	if o.logLevel != "" {
+		return nil
 	}
+	return setLogLevel(cfg.LogLevel)
+}
 
-	userRepo, err := mongo_user_repository.New(ctx, logger, mongoClient, mongo_user_repository.Config{
-		Database:   cfg.Database,
-		Collection: cfg.UserCollection,
+// readConfig layers the config file, BILLING_* env vars and --set overrides
+// and validates the result.
+func (o *rootOptions) readConfig() (config.Config, error) {
+	overrides := map[string]string{}
+	for _, override := range o.overrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return config.Config{}, fmt.Errorf("--set %s must look like key=value", override)
+		}
+		overrides[key] = value
+	}
+
+	cfg, err := config.Load(config.Options{
+		Path:      o.configPath,
+		Required:  o.configRequired,
+		Overrides: overrides,
 	})
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed create user repo")
+		return config.Config{}, fmt.Errorf("loading config %s: %w", o.configPath, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return config.Config{}, err
 	}
+	return cfg, nil
+}
 
-	billingRepo, err := mongo_billing_repository.New(ctx, logger, mongoClient, mongo_billing_repository.Config{
-		Database:   cfg.Database,
-		Collection: cfg.BillingCollection,
-	})
+func connectMongo(ctx context.Context, cfg config.Config) (*mongo.Client, error) {
+	mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed create billing repo")
+		return nil, fmt.Errorf("mongo connect: %w", err)
 	}
+	return mongoClient, nil
+}
 
-	billingManaging := billing_managing_std.New(userRepo, billingRepo)
-	userManaging := user_managing_std.New(userRepo)
+func newRootCommand() *cobra.Command {
+	opts := &rootOptions{}
+	serve := newServeCommand(opts)
 
-	ctrl := http_controller.New(logger, billingManaging, userManaging, cfg.HttpPort, cfg.AdminPassword)
+	root := &cobra.Command{
+		Use:           "billing_manager",
+		Short:         "Billing manager HTTP server and maintenance commands",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		// Running without a command keeps starting the server as before.
+		RunE: serve.RunE,
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			// Only the default config file may be missing.
+			opts.configRequired = cmd.Flag("config").Changed
+			if path, ok := os.LookupEnv(envConfig); ok && !opts.configRequired {
+				opts.configPath = path
+				opts.configRequired = true
+			}
+		},
+	}
+	root.PersistentFlags().StringVar(&opts.configPath, "config", "config.json", "path to the config file: .json, .yaml, .yml or .toml (env "+envConfig+")")
+	root.PersistentFlags().StringArrayVar(&opts.overrides, "set", nil, "override a config key, e.g. --set http_port=8080, may be repeated")
+	root.PersistentFlags().StringVar(&opts.logLevel, "log-level", "", "log level: trace, debug, info, warn, error, overrides log_level from the config")
+	root.PersistentFlags().StringVar(&opts.logFormat, "log-format", logFormatConsole, "log format: console or json")
 
-	go func() {
-		<-ctx.Done()
-		if err := mongoClient.Disconnect(context.Background()); err != nil {
-			logger.Error().Err(err).Msg("Mongo disconnect")
-		}
-	}()
+	root.AddCommand(
+		serve,
+		newMigrateCommand(opts),
+		newExportCommand(opts),
+		newImportCommand(opts),
+		newProjectionCommand(opts),
+		newEncryptionCommand(opts),
+		newVersionCommand(),
+		newConfigCommand(opts),
+	)
+	return root
+}
 
-	logger.Info().Msg(fmt.Sprintf("HTTP controller started on %d port", cfg.HttpPort))
-	ctrl.Start(ctx)
+func main() {
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		syscall.SIGKILL,
+		syscall.SIGABRT,
+		syscall.SIGQUIT,
+		syscall.SIGTERM,
+		os.Interrupt,
+	)
+	defer stop()
+
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		stop()
+		os.Exit(1)
+	}
 }
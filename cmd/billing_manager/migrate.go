@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/migration"
+	"github.com/spf13/cobra"
+)
+
+func withMigrator(ctx context.Context, opts *rootOptions, run func(migrator migration.Migrator) error) error {
+	logger, err := opts.newLogger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.close(logger)
+
+	migrator, err := storage.migrator(ctx, logger)
+	if err != nil {
+		return fmt.Errorf("creating migrator: %w", err)
+	}
+	return run(migrator)
+}
+
+func newMigrateCommand(opts *rootOptions) *cobra.Command {
+	var target int
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return withMigrator(cmd.Context(), opts, func(migrator migration.Migrator) error {
+				applied, err := migrator.Up(cmd.Context(), target)
+				fmt.Fprintf(cmd.OutOrStdout(), "applied %d migrations\n", len(applied))
+				return err
+			})
+		},
+	}
+	up.Flags().IntVar(&target, "to", 0, "stop after this version, 0 applies everything")
+
+	var steps int
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Revert the most recently applied migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return withMigrator(cmd.Context(), opts, func(migrator migration.Migrator) error {
+				reverted, err := migrator.Down(cmd.Context(), steps)
+				fmt.Fprintf(cmd.OutOrStdout(), "reverted %d migrations\n", len(reverted))
+				return err
+			})
+		},
+	}
+	down.Flags().IntVar(&steps, "steps", 1, "how many migrations to revert")
+
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "List migrations and whether they are applied",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return withMigrator(cmd.Context(), opts, func(migrator migration.Migrator) error {
+				statuses, err := migrator.Status(cmd.Context())
+				if err != nil {
+					return err
+				}
+				writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+				for _, status := range statuses {
+					appliedAt := "pending"
+					if status.Applied {
+						appliedAt = status.AppliedAt.Format(time.RFC3339)
+					}
+					fmt.Fprintf(writer, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+				}
+				return writer.Flush()
+			})
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage database schema migrations, without a subcommand applies pending ones",
+		Args:  cobra.NoArgs,
+		RunE:  up.RunE,
+	}
+	cmd.AddCommand(up, down, status)
+	return cmd
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	repository_cache "github.com/ThePositree/billing_manager/internal/adapter/repository/cache"
+	"github.com/spf13/cobra"
+)
+
+func newProjectionCommand(opts *rootOptions) *cobra.Command {
+	rebuild := &cobra.Command{
+		Use:   "rebuild",
+		Short: "Rewrite the billings read model from the event streams",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			logger, err := opts.newLogger(os.Stderr)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			if !cfg.EventSourcing {
+				return fmt.Errorf("billings are not event sourced, set event_sourcing to true")
+			}
+
+			storage, err := openStorage(cmd.Context(), cfg)
+			if err != nil {
+				return err
+			}
+			defer storage.close(logger)
+
+			repos, err := storage.repositories(cmd.Context(), logger, repository_cache.Config{Disabled: true})
+			if err != nil {
+				return err
+			}
+			rebuilt, err := repos.projection.RebuildProjection(cmd.Context())
+			fmt.Fprintf(cmd.OutOrStdout(), "rebuilt %d billings\n", rebuilt)
+			return err
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "projection",
+		Short: "Manage the read model of event sourced billings",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(rebuild)
+	return cmd
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	log_notifier "github.com/ThePositree/billing_manager/internal/adapter/notifier/log"
+	"github.com/ThePositree/billing_manager/internal/config"
+	http_controller "github.com/ThePositree/billing_manager/internal/controller/http"
+	"github.com/rs/zerolog"
+)
+
+// reloadableKeys can change while the server runs, changes to
+// other keys are reported and wait for a restart.
+var reloadableKeys = map[string]bool{
+	"admin_password":         true,
+	"admin_password_file":    true,
+	"log_level":              true,
+	"rate_limit_rps":         true,
+	"rate_limit_burst":       true,
+	"notification_templates": true,
+}
+
+type runtimeSettings interface {
+	SetAdminPassword(password string)
+	SetRateLimit(limit http_controller.RateLimit)
+}
+
+type templatesSetter interface {
+	SetTemplates(templates log_notifier.Templates)
+}
+
+type reloader struct {
+	mutex    sync.Mutex
+	opts     *rootOptions
+	logger   zerolog.Logger
+	current  config.Config
+	server   runtimeSettings
+	notifier templatesSetter
+}
+
+// reload loads and validates the whole config before applying anything,
+// so an invalid config leaves every setting untouched.
+func (r *reloader) reload(reason string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	logger := r.logger.With().Str("Reason", reason).Logger()
+
+	next, err := r.opts.readConfig()
+	if err != nil {
+		logger.Error().Err(err).Msg("Config reload rejected")
+		return
+	}
+	templates, err := log_notifier.ParseTemplates(next.NotificationTemplates)
+	if err != nil {
+		logger.Error().Err(err).Msg("Config reload rejected")
+		return
+	}
+
+	currentValues, err := configValues(r.current)
+	if err != nil {
+		logger.Error().Err(err).Msg("Config reload rejected")
+		return
+	}
+	nextValues, err := configValues(next)
+	if err != nil {
+		logger.Error().Err(err).Msg("Config reload rejected")
+		return
+	}
+	currentRedacted, _ := configValues(r.current.Redacted())
+	nextRedacted, _ := configValues(next.Redacted())
+
+	changed := 0
+	for _, key := range config.Keys() {
+		if bytes.Equal(currentValues[key], nextValues[key]) {
+			continue
+		}
+		if !reloadableKeys[key] {
+			logger.Warn().Str("Key", key).Msg("Config key changed, restart to apply")
+			continue
+		}
+		logger.Info().
+			Str("Key", key).
+			RawJSON("Old", currentRedacted[key]).
+			RawJSON("New", nextRedacted[key]).
+			Msg("Config key changed")
+		changed++
+	}
+	if changed == 0 {
+		logger.Info().Msg("Config reloaded, nothing to apply")
+		return
+	}
+
+	applied := r.current
+	applied.AdminPassword = next.AdminPassword
+	applied.AdminPasswordFile = next.AdminPasswordFile
+	applied.LogLevel = next.LogLevel
+	applied.RateLimitRPS = next.RateLimitRPS
+	applied.RateLimitBurst = next.RateLimitBurst
+	applied.NotificationTemplates = next.NotificationTemplates
+
+	if err := r.opts.applyLogLevel(applied); err != nil {
+		logger.Error().Err(err).Msg("Config reload rejected")
+		return
+	}
+	r.server.SetAdminPassword(applied.AdminPassword)
+	r.server.SetRateLimit(rateLimitFromConfig(applied))
+	r.notifier.SetTemplates(templates)
+	r.current = applied
+
+	logger.Info().Int("Changed", changed).Msg("Config reloaded")
+}
+
+func configValues(cfg config.Config) (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]json.RawMessage{}
+	if err := json.Unmarshal(encoded, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func rateLimitFromConfig(cfg config.Config) http_controller.RateLimit {
+	return http_controller.RateLimit{
+		RequestsPerSecond: cfg.RateLimitRPS,
+		Burst:             cfg.RateLimitBurst,
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	log_notifier "github.com/ThePositree/billing_manager/internal/adapter/notifier/log"
+	zip_transfer "github.com/ThePositree/billing_manager/internal/adapter/transfer/zip"
+	"github.com/ThePositree/billing_manager/internal/config"
+	http_controller "github.com/ThePositree/billing_manager/internal/controller/http"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing/auditing_std"
+	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing/billing_managing_std"
+	"github.com/ThePositree/billing_manager/internal/usecase/deadline_checking/deadline_checking_std"
+	"github.com/ThePositree/billing_manager/internal/usecase/personal_data/personal_data_std"
+	"github.com/ThePositree/billing_manager/internal/usecase/purging/purging_std"
+	"github.com/ThePositree/billing_manager/internal/usecase/reporting/reporting_std"
+	"github.com/ThePositree/billing_manager/internal/usecase/staff_managing/staff_managing_std"
+	"github.com/ThePositree/billing_manager/internal/usecase/user_managing/user_managing_std"
+	"github.com/spf13/cobra"
+)
+
+func newServeCommand(opts *rootOptions) *cobra.Command {
+	var migrate bool
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Start the HTTP server, the deadline checker and the purge of deleted records",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runServe(cmd.Context(), opts, migrate)
+		},
+	}
+	cmd.Flags().BoolVar(&migrate, "migrate", false, "apply pending migrations before serving")
+	return cmd
+}
+
+// runServe refuses to start on a database with pending migrations
+// unless migrate is set.
+func runServe(ctx context.Context, opts *rootOptions, migrate bool) error {
+	logger, err := opts.newLogger(os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	settings, err := parseSettings(cfg)
+	if err != nil {
+		return err
+	}
+	logger.Debug().Interface("Config", cfg.Redacted()).Msg("Config loaded")
+
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed open storage")
+	}
+
+	migrator, err := storage.migrator(ctx, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed create migrator")
+	}
+	if migrate {
+		if _, err := migrator.Up(ctx, 0); err != nil {
+			logger.Fatal().Err(err).Msg("Failed apply migrations")
+		}
+	}
+	if err := migrator.Check(ctx); err != nil {
+		logger.Fatal().Err(err).Msg("Database is not migrated")
+	}
+
+	repos, err := storage.repositories(ctx, logger, settings.cache)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed create repositories")
+	}
+	userRepo, billingRepo, staffRepo := repos.user, repos.billing, repos.staff
+
+	transactor, err := storage.transactor(ctx, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed create transactor")
+	}
+
+	auditing := auditing_std.New(repos.audit)
+
+	billingManaging, err := billing_managing_std.New(userRepo, billingRepo, staffRepo, transactor, auditing, billing_managing_std.Config{
+		DefaultRevisionLimit: settings.revisionLimit,
+		DefaultDeadline:      settings.defaultDeadline,
+		DefaultStageTargets:  settings.stageTargets,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed create billing managing")
+	}
+
+	templates, err := log_notifier.ParseTemplates(cfg.NotificationTemplates)
+	if err != nil {
+		return err
+	}
+	notifier := log_notifier.New(logger, templates)
+
+	deadlineChecking, err := deadline_checking_std.New(logger, billingRepo, notifier, deadline_checking_std.Config{
+		Interval: settings.deadlineCheckInterval,
+		Warning:  settings.deadlineWarning,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed create deadline checking")
+	}
+	purging, err := purging_std.New(logger, userRepo, billingRepo, purging_std.Config{
+		Interval:  settings.purgeInterval,
+		Retention: settings.deletedRetention,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed create purging")
+	}
+	userManaging := user_managing_std.New(userRepo, billingRepo, transactor, auditing)
+	staffManaging := staff_managing_std.New(staffRepo, billingRepo, transactor, auditing)
+	reporting := reporting_std.New(repos.report, userRepo, billingRepo)
+	personalData := personal_data_std.New(userRepo, billingRepo, transactor, zip_transfer.NewArchiver(), auditing)
+
+	ctrl := http_controller.New(logger, billingManaging, userManaging, staffManaging, reporting, personalData, auditing, cfg.HttpPort, cfg.AdminPassword, rateLimitFromConfig(cfg))
+
+	reloader := &reloader{
+		opts:     opts,
+		logger:   logger.With().Str("Component", "config reload").Logger(),
+		current:  cfg,
+		server:   ctrl,
+		notifier: notifier,
+	}
+	go func() {
+		if err := config.Watch(ctx, reloader.logger, opts.configPath, reloader.reload); err != nil {
+			logger.Error().Err(err).Msg("Config watch stopped, reload with SIGHUP is unavailable")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		storage.close(logger)
+	}()
+
+	go deadlineChecking.Run(ctx)
+	go purging.Run(ctx)
+
+	logger.Info().Msg(fmt.Sprintf("HTTP controller started on %d port", cfg.HttpPort))
+	ctrl.Start(ctx)
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	repository_cache "github.com/ThePositree/billing_manager/internal/adapter/repository/cache"
+	"github.com/ThePositree/billing_manager/internal/config"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+)
+
+// settings are config values parsed into the types usecases expect.
+type settings struct {
+	revisionLimit         model_billing.RevisionLimit
+	defaultDeadline       time.Duration
+	stageTargets          map[model_billing.State]time.Duration
+	deadlineWarning       time.Duration
+	deadlineCheckInterval time.Duration
+	cache                 repository_cache.Config
+	deletedRetention      time.Duration
+	purgeInterval         time.Duration
+}
+
+func parseSettings(cfg config.Config) (settings, error) {
+	revisionPolicy, err := model_billing.ParseRevisionPolicy(cfg.RevisionPolicy)
+	if err != nil {
+		return settings{}, fmt.Errorf("parsing revision policy: %w", err)
+	}
+
+	defaultDeadline, err := parseOptionalDuration(cfg.DefaultDeadline)
+	if err != nil {
+		return settings{}, fmt.Errorf("parsing default deadline: %w", err)
+	}
+
+	stageTargets := map[model_billing.State]time.Duration{}
+	for stageName, targetValue := range cfg.StageTargets {
+		stage, err := model_billing.ParseState(stageName)
+		if err != nil {
+			return settings{}, fmt.Errorf("parsing stage targets: %w", err)
+		}
+		target, err := time.ParseDuration(targetValue)
+		if err != nil {
+			return settings{}, fmt.Errorf("parsing stage targets: %w", err)
+		}
+		stageTargets[stage] = target
+	}
+
+	deadlineWarning, err := parseOptionalDuration(cfg.DeadlineWarning)
+	if err != nil {
+		return settings{}, fmt.Errorf("parsing deadline warning: %w", err)
+	}
+	deadlineCheckInterval, err := time.ParseDuration(cfg.DeadlineCheckInterval)
+	if err != nil {
+		return settings{}, fmt.Errorf("parsing deadline check interval: %w", err)
+	}
+
+	cache := repository_cache.Config{Disabled: cfg.CacheDisabled}
+	if !cache.Disabled {
+		cache.PollInterval, err = time.ParseDuration(cfg.CachePollInterval)
+		if err != nil {
+			return settings{}, fmt.Errorf("parsing cache poll interval: %w", err)
+		}
+	}
+
+	deletedRetention, err := time.ParseDuration(cfg.DeletedRetention)
+	if err != nil {
+		return settings{}, fmt.Errorf("parsing deleted retention: %w", err)
+	}
+	purgeInterval, err := time.ParseDuration(cfg.PurgeInterval)
+	if err != nil {
+		return settings{}, fmt.Errorf("parsing purge interval: %w", err)
+	}
+
+	return settings{
+		revisionLimit: model_billing.RevisionLimit{
+			Included:  cfg.IncludedRevisions,
+			Policy:    revisionPolicy,
+			Surcharge: cfg.RevisionSurcharge,
+		},
+		defaultDeadline:       defaultDeadline,
+		stageTargets:          stageTargets,
+		deadlineWarning:       deadlineWarning,
+		deadlineCheckInterval: deadlineCheckInterval,
+		cache:                 cache,
+		deletedRetention:      deletedRetention,
+		purgeInterval:         purgeInterval,
+	}, nil
+}
+
+func parseOptionalDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/migration"
+	mongo_migration "github.com/ThePositree/billing_manager/internal/adapter/migration/mongo"
+	postgres_migration "github.com/ThePositree/billing_manager/internal/adapter/migration/postgres"
+	sqlite_migration "github.com/ThePositree/billing_manager/internal/adapter/migration/sqlite"
+	mongo_audit_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/audit/mongo"
+	postgres_audit_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/audit/postgres"
+	sqlite_audit_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/audit/sqlite"
+	eventsourced_billing_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/eventsourced"
+	mongo_billing_event_store "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/eventsourced/mongo"
+	sql_billing_event_store "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/eventsourced/sql"
+	mongo_billing_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/mongo"
+	postgres_billing_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/postgres"
+	sqlite_billing_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/sqlite"
+	repository_cache "github.com/ThePositree/billing_manager/internal/adapter/repository/cache"
+	computed_report_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/report/computed"
+	mongo_report_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/report/mongo"
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	mongo_staff_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/staff/mongo"
+	postgres_staff_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/staff/postgres"
+	sqlite_staff_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/staff/sqlite"
+	mongo_user_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/user/mongo"
+	postgres_user_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/user/postgres"
+	sqlite_user_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/user/sqlite"
+	mongo_transaction "github.com/ThePositree/billing_manager/internal/adapter/transaction/mongo"
+	sql_transaction "github.com/ThePositree/billing_manager/internal/adapter/transaction/sql"
+	"github.com/ThePositree/billing_manager/internal/config"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/reporting"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/mongo"
+	_ "modernc.org/sqlite"
+)
+
+// migrationCollection records applied Mongo migrations next to the data.
+const migrationCollection = "schema_migrations"
+
+// storage is the database picked by the storage config key.
+type storage struct {
+	cfg         config.Config
+	mongoClient *mongo.Client
+	// sqlDB serves both the postgres and the sqlite storage.
+	sqlDB *sql.DB
+}
+
+type repositories struct {
+	user    usecase.UserRepository
+	billing usecase.BillingRepository
+	staff   usecase.StaffRepository
+	report  reporting.ReportRepository
+	audit   usecase.AuditRepository
+	// projection is nil unless billings are event sourced.
+	projection projection
+}
+
+type projection interface {
+	RebuildProjection(ctx context.Context) (int, error)
+}
+
+func openStorage(ctx context.Context, cfg config.Config) (storage, error) {
+	switch cfg.Storage {
+	case config.StorageMongo:
+		mongoClient, err := connectMongo(ctx, cfg)
+		if err != nil {
+			return storage{}, err
+		}
+		return storage{cfg: cfg, mongoClient: mongoClient}, nil
+	case config.StoragePostgres:
+		db, err := sql.Open("pgx", cfg.PostgresDSN)
+		if err != nil {
+			return storage{}, fmt.Errorf("postgres open: %w", err)
+		}
+		return storage{cfg: cfg, sqlDB: db}, nil
+	case config.StorageSQLite:
+		db, err := sql.Open("sqlite", sql_repository.SQLiteDSN(cfg.SQLitePath))
+		if err != nil {
+			return storage{}, fmt.Errorf("sqlite open: %w", err)
+		}
+		return storage{cfg: cfg, sqlDB: db}, nil
+	}
+	return storage{}, fmt.Errorf("unknown storage %q", cfg.Storage)
+}
+
+func (s storage) close(logger zerolog.Logger) {
+	if s.mongoClient != nil {
+		if err := s.mongoClient.Disconnect(context.Background()); err != nil {
+			logger.Error().Err(err).Msg("Mongo disconnect")
+		}
+	}
+	if s.sqlDB != nil {
+		if err := s.sqlDB.Close(); err != nil {
+			logger.Error().Err(err).Msg("SQL database close")
+		}
+	}
+}
+
+func (s storage) migrator(ctx context.Context, logger zerolog.Logger) (migration.Migrator, error) {
+	switch s.cfg.Storage {
+	case config.StoragePostgres:
+		return postgres_migration.New(logger, s.sqlDB)
+	case config.StorageSQLite:
+		return sqlite_migration.New(logger, s.sqlDB)
+	}
+	return mongo_migration.New(ctx, logger, s.mongoClient, mongo_migration.Config{
+		Database:            s.cfg.Database,
+		UserCollection:      s.cfg.UserCollection,
+		BillingCollection:   s.cfg.BillingCollection,
+		StaffCollection:     s.cfg.StaffCollection,
+		EventCollection:     s.cfg.EventCollection,
+		SnapshotCollection:  s.cfg.SnapshotCollection,
+		AuditCollection:     s.cfg.AuditCollection,
+		MigrationCollection: migrationCollection,
+	})
+}
+
+// transactor runs units of work in the storage, the SQL one is shared by
+// postgres and sqlite.
+func (s storage) transactor(ctx context.Context, logger zerolog.Logger) (usecase.Transactor, error) {
+	if s.sqlDB != nil {
+		return sql_transaction.New(s.sqlDB), nil
+	}
+	return mongo_transaction.New(ctx, logger, s.mongoClient)
+}
+
+// repositories only caches Mongo data, SQL storages are queried directly.
+// Event sourced billings use the billing repository of the storage as
+// their read model.
+func (s storage) repositories(ctx context.Context, logger zerolog.Logger, cache repository_cache.Config) (repositories, error) {
+	var (
+		repos repositories
+		err   error
+	)
+	switch s.cfg.Storage {
+	case config.StoragePostgres:
+		repos, err = s.postgresRepositories(ctx, logger)
+	case config.StorageSQLite:
+		repos, err = s.sqliteRepositories(ctx, logger)
+	default:
+		repos, err = s.mongoRepositories(ctx, logger, cache)
+	}
+	if err != nil || !s.cfg.EventSourcing {
+		return repos, err
+	}
+
+	events, err := s.eventStore(ctx)
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating event store: %w", err)
+	}
+	billingRepo, err := eventsourced_billing_repository.New(logger, events, repos.billing, eventsourced_billing_repository.Config{
+		SnapshotInterval: s.cfg.SnapshotInterval,
+	})
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating event sourced billing repo: %w", err)
+	}
+	repos.billing = billingRepo
+	repos.projection = billingRepo
+	return repos, nil
+}
+
+func (s storage) eventStore(ctx context.Context) (eventsourced_billing_repository.EventStore, error) {
+	switch s.cfg.Storage {
+	case config.StoragePostgres:
+		return sql_billing_event_store.New(ctx, s.sqlDB, sql_billing_event_store.DialectPostgres)
+	case config.StorageSQLite:
+		return sql_billing_event_store.New(ctx, s.sqlDB, sql_billing_event_store.DialectSQLite)
+	}
+	return mongo_billing_event_store.New(ctx, s.mongoClient, mongo_billing_event_store.Config{
+		Database:           s.cfg.Database,
+		EventCollection:    s.cfg.EventCollection,
+		SnapshotCollection: s.cfg.SnapshotCollection,
+		Encryption:         s.cfg.Encryption(),
+	})
+}
+
+func (s storage) mongoRepositories(ctx context.Context, logger zerolog.Logger, cache repository_cache.Config) (repositories, error) {
+	userRepo, err := mongo_user_repository.New(ctx, logger, s.mongoClient, mongo_user_repository.Config{
+		Database:   s.cfg.Database,
+		Collection: s.cfg.UserCollection,
+		Cache:      cache,
+		Encryption: s.cfg.Encryption(),
+	})
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating user repo: %w", err)
+	}
+	billingRepo, err := mongo_billing_repository.New(ctx, logger, s.mongoClient, mongo_billing_repository.Config{
+		Database:   s.cfg.Database,
+		Collection: s.cfg.BillingCollection,
+		Cache:      cache,
+		Encryption: s.cfg.Encryption(),
+	})
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating billing repo: %w", err)
+	}
+	staffRepo, err := mongo_staff_repository.New(ctx, logger, s.mongoClient, mongo_staff_repository.Config{
+		Database:   s.cfg.Database,
+		Collection: s.cfg.StaffCollection,
+		Cache:      cache,
+	})
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating staff repo: %w", err)
+	}
+	reportRepo, err := mongo_report_repository.New(ctx, logger, s.mongoClient, mongo_report_repository.Config{
+		Database:          s.cfg.Database,
+		BillingCollection: s.cfg.BillingCollection,
+		UserCollection:    s.cfg.UserCollection,
+		Encryption:        s.cfg.Encryption(),
+	})
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating report repo: %w", err)
+	}
+	auditRepo, err := mongo_audit_repository.New(ctx, logger, s.mongoClient, mongo_audit_repository.Config{
+		Database:   s.cfg.Database,
+		Collection: s.cfg.AuditCollection,
+	})
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating audit repo: %w", err)
+	}
+	return repositories{user: userRepo, billing: billingRepo, staff: staffRepo, report: reportRepo, audit: auditRepo}, nil
+}
+
+func (s storage) postgresRepositories(ctx context.Context, logger zerolog.Logger) (repositories, error) {
+	userRepo, err := postgres_user_repository.New(ctx, logger, s.sqlDB)
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating user repo: %w", err)
+	}
+	billingRepo, err := postgres_billing_repository.New(ctx, logger, s.sqlDB)
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating billing repo: %w", err)
+	}
+	staffRepo, err := postgres_staff_repository.New(ctx, logger, s.sqlDB)
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating staff repo: %w", err)
+	}
+	auditRepo, err := postgres_audit_repository.New(ctx, logger, s.sqlDB)
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating audit repo: %w", err)
+	}
+	return repositories{
+		user:    userRepo,
+		billing: billingRepo,
+		staff:   staffRepo,
+		report:  computed_report_repository.New(userRepo, billingRepo),
+		audit:   auditRepo,
+	}, nil
+}
+
+func (s storage) sqliteRepositories(ctx context.Context, logger zerolog.Logger) (repositories, error) {
+	userRepo, err := sqlite_user_repository.New(ctx, logger, s.sqlDB)
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating user repo: %w", err)
+	}
+	billingRepo, err := sqlite_billing_repository.New(ctx, logger, s.sqlDB)
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating billing repo: %w", err)
+	}
+	staffRepo, err := sqlite_staff_repository.New(ctx, logger, s.sqlDB)
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating staff repo: %w", err)
+	}
+	auditRepo, err := sqlite_audit_repository.New(ctx, logger, s.sqlDB)
+	if err != nil {
+		return repositories{}, fmt.Errorf("creating audit repo: %w", err)
+	}
+	return repositories{
+		user:    userRepo,
+		billing: billingRepo,
+		staff:   staffRepo,
+		report:  computed_report_repository.New(userRepo, billingRepo),
+		audit:   auditRepo,
+	}, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	repository_cache "github.com/ThePositree/billing_manager/internal/adapter/repository/cache"
+	json_transfer "github.com/ThePositree/billing_manager/internal/adapter/transfer/json"
+	"github.com/ThePositree/billing_manager/internal/usecase/data_transferring"
+	"github.com/ThePositree/billing_manager/internal/usecase/data_transferring/data_transferring_std"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// withDataTransferring logs to stderr since export and import may stream
+// data through stdout and stdin.
+func withDataTransferring(ctx context.Context, opts *rootOptions, run func(logger zerolog.Logger, dataTransferring data_transferring.DataTransferring) error) error {
+	logger, err := opts.newLogger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.close(logger)
+
+	// A one-off run reads everything once, following changes is pointless.
+	repos, err := storage.repositories(ctx, logger, repository_cache.Config{Disabled: true})
+	if err != nil {
+		return err
+	}
+
+	return run(logger, data_transferring_std.New(repos.user, repos.billing))
+}
+
+func newExportCommand(opts *rootOptions) *cobra.Command {
+	var formatValue, outPath string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write all users and billings to an NDJSON or JSON file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			format, err := json_transfer.ParseFormat(formatValue)
+			if err != nil {
+				return err
+			}
+
+			var out io.Writer = os.Stdout
+			if outPath != "-" {
+				file, err := os.Create(outPath)
+				if err != nil {
+					return fmt.Errorf("creating output file: %w", err)
+				}
+				defer file.Close()
+				out = file
+			}
+
+			writer, err := json_transfer.NewWriter(out, format)
+			if err != nil {
+				return err
+			}
+
+			return withDataTransferring(cmd.Context(), opts, func(logger zerolog.Logger, dataTransferring data_transferring.DataTransferring) error {
+				result, err := dataTransferring.Export(cmd.Context(), writer)
+				if err != nil {
+					return err
+				}
+				if err := writer.Close(); err != nil {
+					return err
+				}
+
+				logger.Info().Int("Users", result.Users).Int("Billings", result.Billings).Msg("Export finished")
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&formatValue, "format", string(json_transfer.FormatNDJSON), "output format: ndjson or json")
+	cmd.Flags().StringVar(&outPath, "out", "-", `output file, "-" for stdout`)
+	return cmd
+}
+
+func newImportCommand(opts *rootOptions) *cobra.Command {
+	var formatValue, inPath, conflictValue string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Read users and billings from an NDJSON or JSON file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			format, err := json_transfer.ParseFormat(formatValue)
+			if err != nil {
+				return err
+			}
+			conflict, err := data_transferring.ParseConflictPolicy(conflictValue)
+			if err != nil {
+				return err
+			}
+
+			var in io.Reader = os.Stdin
+			if inPath != "-" {
+				file, err := os.Open(inPath)
+				if err != nil {
+					return fmt.Errorf("opening input file: %w", err)
+				}
+				defer file.Close()
+				in = file
+			}
+
+			reader, err := json_transfer.NewReader(in, format)
+			if err != nil {
+				return err
+			}
+
+			return withDataTransferring(cmd.Context(), opts, func(logger zerolog.Logger, dataTransferring data_transferring.DataTransferring) error {
+				result, err := dataTransferring.Import(cmd.Context(), reader, data_transferring.ImportOptions{
+					DryRun:   dryRun,
+					Conflict: conflict,
+				})
+				event := logger.Info()
+				if err != nil {
+					event = logger.Error().Err(err)
+				}
+				event.
+					Bool("DryRun", dryRun).
+					Int("UsersCreated", result.Users.Created).
+					Int("UsersOverwritten", result.Users.Overwritten).
+					Int("UsersSkipped", result.Users.Skipped).
+					Int("BillingsCreated", result.Billings.Created).
+					Int("BillingsOverwritten", result.Billings.Overwritten).
+					Int("BillingsSkipped", result.Billings.Skipped).
+					Msg("Import finished")
+
+				var conflictErr data_transferring.ErrConflict
+				if errors.As(err, &conflictErr) {
+					return fmt.Errorf("%w, use --conflict skip or overwrite to continue", conflictErr)
+				}
+				return err
+			})
+		},
+	}
+	cmd.Flags().StringVar(&formatValue, "format", string(json_transfer.FormatNDJSON), "input format: ndjson or json")
+	cmd.Flags().StringVar(&inPath, "in", "-", `input file, "-" for stdin`)
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "validate the input and report what would change without writing")
+	cmd.Flags().StringVar(&conflictValue, "conflict", string(data_transferring.ConflictFail), "what to do with existing records: skip, overwrite or fail")
+	return cmd
+}
@@ -0,0 +1,42 @@
+// Package migration holds what the Mongo and SQL migrators share so that
+// commands can manage either storage the same way.
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrIrreversible   = errors.New("migration cannot be reverted")
+	ErrUnknownVersion = errors.New("database has a migration this binary does not know, upgrade the binary")
+)
+
+// ErrPendingMigrations is returned by Check when the database is behind the binary.
+type ErrPendingMigrations struct {
+	Pending []int
+}
+
+func (e ErrPendingMigrations) Error() string {
+	return fmt.Sprintf("database has %d pending migrations %v, run migrate up", len(e.Pending), e.Pending)
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator interface {
+	Status(ctx context.Context) ([]Status, error)
+	// Check returns ErrPendingMigrations unless every known migration is applied.
+	Check(ctx context.Context) error
+	// Up applies pending migrations in version order up to and including
+	// target, zero target means all of them.
+	Up(ctx context.Context, target int) ([]Status, error)
+	// Down reverts the given number of most recently applied migrations.
+	Down(ctx context.Context, steps int) ([]Status, error)
+}
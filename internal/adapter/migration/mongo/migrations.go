@@ -0,0 +1,343 @@
+package mongo_migration
+
+import (
+	"context"
+	"fmt"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrations are applied in version order, append new ones at the end.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_collections",
+		Up:      createCollections,
+	},
+	{
+		Version: 2,
+		Name:    "backfill_billing_defaults",
+		Up:      backfillBillingDefaults,
+	},
+	{
+		Version: 3,
+		Name:    "create_user_and_billing_indexes",
+		Up:      createUserAndBillingIndexes,
+		Down:    dropUserAndBillingIndexes,
+	},
+	{
+		Version: 4,
+		Name:    "create_billing_event_collections",
+		Up:      createBillingEventCollections,
+		Down:    dropBillingEventIndex,
+	},
+	{
+		Version: 5,
+		Name:    "create_telegram_username_blind_index",
+		Up:      createTelegramUsernameBlindIndex,
+		Down:    dropTelegramUsernameBlindIndex,
+	},
+	{
+		Version: 6,
+		Name:    "create_audit_collection",
+		Up:      createAuditCollection,
+		Down:    dropAuditIndexes,
+	},
+	{
+		Version: 7,
+		Name:    "scope_telegram_username_unique_to_live_users",
+		Up:      scopeTelegramUsernameIndexesToLiveUsers,
+		Down:    unscopeTelegramUsernameIndexes,
+	},
+}
+
+const (
+	telegramUsernameIndex          = "telegram_username_unique"
+	billingUserIdIndex             = "user_id"
+	billingEventIndex              = "billing_id_version_unique"
+	telegramUsernameBlindIndex     = "telegram_username_index_unique"
+	auditOccurredAtIndex           = "occurred_at"
+	auditTargetIndex               = "target_type_target_id_occurred_at"
+	liveTelegramUsernameIndex      = "telegram_username_deleted_at_unique"
+	liveTelegramUsernameBlindIndex = "telegram_username_index_deleted_at_unique"
+)
+
+func createCollections(ctx context.Context, db *mongo.Database, cfg Config) error {
+	existing, err := db.ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return fmt.Errorf("mongo list collection names: %w", err)
+	}
+	exists := map[string]bool{}
+	for _, name := range existing {
+		exists[name] = true
+	}
+
+	for _, name := range []string{cfg.UserCollection, cfg.BillingCollection, cfg.StaffCollection} {
+		if exists[name] {
+			continue
+		}
+		if err := db.CreateCollection(ctx, name); err != nil {
+			return fmt.Errorf("mongo create collection %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// backfillBillingDefaults stores the values ToModelFromDTO assumes for
+// billings created before revision policies, priorities and state history.
+func backfillBillingDefaults(ctx context.Context, db *mongo.Database, cfg Config) error {
+	coll := db.Collection(cfg.BillingCollection)
+
+	defaults := []struct {
+		field string
+		value any
+	}{
+		{field: "revision_policy", value: model_billing.RevisionPolicyUnlimited.String()},
+		{field: "priority", value: model_billing.PriorityNormal.String()},
+	}
+	for _, fieldDefault := range defaults {
+		_, err := coll.UpdateMany(ctx,
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: fieldDefault.field, Value: bson.D{{Key: "$exists", Value: false}}}},
+				bson.D{{Key: fieldDefault.field, Value: ""}},
+			}}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: fieldDefault.field, Value: fieldDefault.value}}}},
+		)
+		if err != nil {
+			return fmt.Errorf("mongo update many %s: %w", fieldDefault.field, err)
+		}
+	}
+
+	// Billings without history get a single entry for their current state.
+	_, err := coll.UpdateMany(ctx,
+		bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "state_changes", Value: bson.D{{Key: "$exists", Value: false}}}},
+			bson.D{{Key: "state_changes", Value: nil}},
+		}}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.D{
+				{Key: "state_changes", Value: bson.A{bson.D{
+					{Key: "state", Value: "$state"},
+					{Key: "entered_at", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$created_at", "$$NOW"}}}},
+				}}},
+			}}},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("mongo update many state_changes: %w", err)
+	}
+	return nil
+}
+
+// createUserAndBillingIndexes makes telegram usernames unique and speeds up
+// billing lookups by user. Existing duplicates are reported instead of
+// letting the index build fail with a generic error.
+func createUserAndBillingIndexes(ctx context.Context, db *mongo.Database, cfg Config) error {
+	users := db.Collection(cfg.UserCollection)
+
+	cursor, err := users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$telegram_username"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+	})
+	if err != nil {
+		return fmt.Errorf("mongo aggregate: %w", err)
+	}
+	var duplicates []struct {
+		TelegramUN string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return fmt.Errorf("cursor all: %w", err)
+	}
+	if len(duplicates) > 0 {
+		var names []string
+		for _, duplicate := range duplicates {
+			names = append(names, duplicate.TelegramUN)
+		}
+		return fmt.Errorf("users share telegram usernames %v, merge or delete them first", names)
+	}
+
+	_, err = users.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "telegram_username", Value: 1}},
+		Options: options.Index().SetName(telegramUsernameIndex).SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("mongo create index %s: %w", telegramUsernameIndex, err)
+	}
+
+	_, err = db.Collection(cfg.BillingCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().SetName(billingUserIdIndex),
+	})
+	if err != nil {
+		return fmt.Errorf("mongo create index %s: %w", billingUserIdIndex, err)
+	}
+	return nil
+}
+
+func dropUserAndBillingIndexes(ctx context.Context, db *mongo.Database, cfg Config) error {
+	if _, err := db.Collection(cfg.UserCollection).Indexes().DropOne(ctx, telegramUsernameIndex); err != nil {
+		return fmt.Errorf("mongo drop index %s: %w", telegramUsernameIndex, err)
+	}
+	if _, err := db.Collection(cfg.BillingCollection).Indexes().DropOne(ctx, billingUserIdIndex); err != nil {
+		return fmt.Errorf("mongo drop index %s: %w", billingUserIdIndex, err)
+	}
+	return nil
+}
+
+// createBillingEventCollections prepares the event store of event sourced
+// billings, the unique index rejects a second writer appending the same version.
+func createBillingEventCollections(ctx context.Context, db *mongo.Database, cfg Config) error {
+	existing, err := db.ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return fmt.Errorf("mongo list collection names: %w", err)
+	}
+	exists := map[string]bool{}
+	for _, name := range existing {
+		exists[name] = true
+	}
+	for _, name := range []string{cfg.EventCollection, cfg.SnapshotCollection} {
+		if exists[name] {
+			continue
+		}
+		if err := db.CreateCollection(ctx, name); err != nil {
+			return fmt.Errorf("mongo create collection %s: %w", name, err)
+		}
+	}
+
+	_, err = db.Collection(cfg.EventCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "billing_id", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetName(billingEventIndex).SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("mongo create index %s: %w", billingEventIndex, err)
+	}
+	return nil
+}
+
+func dropBillingEventIndex(ctx context.Context, db *mongo.Database, cfg Config) error {
+	if _, err := db.Collection(cfg.EventCollection).Indexes().DropOne(ctx, billingEventIndex); err != nil {
+		return fmt.Errorf("mongo drop index %s: %w", billingEventIndex, err)
+	}
+	return nil
+}
+
+// createTelegramUsernameBlindIndex keeps usernames unique once they are
+// encrypted, only users written with encryption on have the blind index.
+func createTelegramUsernameBlindIndex(ctx context.Context, db *mongo.Database, cfg Config) error {
+	_, err := db.Collection(cfg.UserCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "telegram_username_index", Value: 1}},
+		Options: options.Index().
+			SetName(telegramUsernameBlindIndex).
+			SetUnique(true).
+			SetPartialFilterExpression(bson.D{{Key: "telegram_username_index", Value: bson.D{{Key: "$exists", Value: true}}}}),
+	})
+	if err != nil {
+		return fmt.Errorf("mongo create index %s: %w", telegramUsernameBlindIndex, err)
+	}
+	return nil
+}
+
+func dropTelegramUsernameBlindIndex(ctx context.Context, db *mongo.Database, cfg Config) error {
+	if _, err := db.Collection(cfg.UserCollection).Indexes().DropOne(ctx, telegramUsernameBlindIndex); err != nil {
+		return fmt.Errorf("mongo drop index %s: %w", telegramUsernameBlindIndex, err)
+	}
+	return nil
+}
+
+func createAuditCollection(ctx context.Context, db *mongo.Database, cfg Config) error {
+	existing, err := db.ListCollectionNames(ctx, bson.D{{Key: "name", Value: cfg.AuditCollection}})
+	if err != nil {
+		return fmt.Errorf("mongo list collection names: %w", err)
+	}
+	if len(existing) == 0 {
+		if err := db.CreateCollection(ctx, cfg.AuditCollection); err != nil {
+			return fmt.Errorf("mongo create collection %s: %w", cfg.AuditCollection, err)
+		}
+	}
+
+	_, err = db.Collection(cfg.AuditCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "occurred_at", Value: -1}},
+			Options: options.Index().SetName(auditOccurredAtIndex),
+		},
+		{
+			Keys:    bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "occurred_at", Value: -1}},
+			Options: options.Index().SetName(auditTargetIndex),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("mongo create audit indexes: %w", err)
+	}
+	return nil
+}
+
+func dropAuditIndexes(ctx context.Context, db *mongo.Database, cfg Config) error {
+	for _, name := range []string{auditOccurredAtIndex, auditTargetIndex} {
+		if _, err := db.Collection(cfg.AuditCollection).Indexes().DropOne(ctx, name); err != nil {
+			return fmt.Errorf("mongo drop index %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// scopeTelegramUsernameIndexesToLiveUsers lets a soft deleted user keep its
+// telegram username until it is purged. Partial indexes cannot select
+// documents without deleted_at, so deleted_at joins the keys instead: live
+// users share its missing value and must differ in the username.
+func scopeTelegramUsernameIndexesToLiveUsers(ctx context.Context, db *mongo.Database, cfg Config) error {
+	users := db.Collection(cfg.UserCollection)
+
+	_, err := users.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "telegram_username", Value: 1}, {Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetName(liveTelegramUsernameIndex).SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "telegram_username_index", Value: 1}, {Key: "deleted_at", Value: 1}},
+			Options: options.Index().
+				SetName(liveTelegramUsernameBlindIndex).
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "telegram_username_index", Value: bson.D{{Key: "$exists", Value: true}}}}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("mongo create live telegram username indexes: %w", err)
+	}
+
+	for _, name := range []string{telegramUsernameIndex, telegramUsernameBlindIndex} {
+		if _, err := users.Indexes().DropOne(ctx, name); err != nil {
+			return fmt.Errorf("mongo drop index %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// unscopeTelegramUsernameIndexes fails while a deleted user shares its
+// username with another user, purge it first.
+func unscopeTelegramUsernameIndexes(ctx context.Context, db *mongo.Database, cfg Config) error {
+	users := db.Collection(cfg.UserCollection)
+
+	_, err := users.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "telegram_username", Value: 1}},
+		Options: options.Index().SetName(telegramUsernameIndex).SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("mongo create index %s: %w", telegramUsernameIndex, err)
+	}
+	if err := createTelegramUsernameBlindIndex(ctx, db, cfg); err != nil {
+		return err
+	}
+
+	for _, name := range []string{liveTelegramUsernameIndex, liveTelegramUsernameBlindIndex} {
+		if _, err := users.Indexes().DropOne(ctx, name); err != nil {
+			return fmt.Errorf("mongo drop index %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package mongo_migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/migration"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrIrreversible   = migration.ErrIrreversible
+	ErrUnknownVersion = migration.ErrUnknownVersion
+)
+
+type (
+	ErrPendingMigrations = migration.ErrPendingMigrations
+	Status               = migration.Status
+)
+
+type Config struct {
+	Database            string
+	UserCollection      string
+	BillingCollection   string
+	StaffCollection     string
+	EventCollection     string
+	SnapshotCollection  string
+	AuditCollection     string
+	MigrationCollection string
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Database == "" {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if cfg.UserCollection == "" {
+		return fmt.Errorf("user collection name cannot be empty")
+	}
+	if cfg.BillingCollection == "" {
+		return fmt.Errorf("billing collection name cannot be empty")
+	}
+	if cfg.StaffCollection == "" {
+		return fmt.Errorf("staff collection name cannot be empty")
+	}
+	if cfg.EventCollection == "" {
+		return fmt.Errorf("event collection name cannot be empty")
+	}
+	if cfg.SnapshotCollection == "" {
+		return fmt.Errorf("snapshot collection name cannot be empty")
+	}
+	if cfg.AuditCollection == "" {
+		return fmt.Errorf("audit collection name cannot be empty")
+	}
+	if cfg.MigrationCollection == "" {
+		return fmt.Errorf("migration collection name cannot be empty")
+	}
+	return nil
+}
+
+// Migration changes documents or indexes of the database. Versions are
+// never reused, Down is nil for migrations that cannot be reverted.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database, cfg Config) error
+	Down    func(ctx context.Context, db *mongo.Database, cfg Config) error
+}
+
+type appliedMigration struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+var _ migration.Migrator = &migrator{}
+
+type migrator struct {
+	logger     zerolog.Logger
+	db         *mongo.Database
+	coll       *mongo.Collection
+	cfg        Config
+	migrations []Migration
+}
+
+func (m *migrator) applied(ctx context.Context) (map[int]appliedMigration, error) {
+	cursor, err := m.coll.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("mongo find: %w", err)
+	}
+	var rows []appliedMigration
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("cursor all: %w", err)
+	}
+
+	known := map[int]bool{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+	}
+	result := map[int]appliedMigration{}
+	for _, row := range rows {
+		if !known[row.Version] {
+			return nil, fmt.Errorf("version %d: %w", row.Version, ErrUnknownVersion)
+		}
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+func (m *migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return []Status{}, err
+	}
+
+	result := []Status{}
+	for _, migration := range m.migrations {
+		row, ok := applied[migration.Version]
+		result = append(result, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: row.AppliedAt,
+		})
+	}
+	return result, nil
+}
+
+// Check returns ErrPendingMigrations unless every known migration is applied.
+func (m *migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []int
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Version)
+		}
+	}
+	if len(pending) > 0 {
+		return ErrPendingMigrations{Pending: pending}
+	}
+	return nil
+}
+
+// Up applies pending migrations in version order up to and including
+// target, zero target means all of them.
+func (m *migrator) Up(ctx context.Context, target int) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return []Status{}, err
+	}
+
+	result := []Status{}
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := migration.Up(ctx, m.db, m.cfg); err != nil {
+			return result, fmt.Errorf("migration %d %s up: %w", migration.Version, migration.Name, err)
+		}
+		row := appliedMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC()}
+		if _, err := m.coll.InsertOne(ctx, row); err != nil {
+			return result, fmt.Errorf("mongo insert one: %w", err)
+		}
+
+		m.logger.Info().Int("Version", migration.Version).Str("Name", migration.Name).Msg("Migration applied")
+		result = append(result, Status{Version: row.Version, Name: row.Name, Applied: true, AppliedAt: row.AppliedAt})
+	}
+	return result, nil
+}
+
+// Down reverts the given number of most recently applied migrations.
+func (m *migrator) Down(ctx context.Context, steps int) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return []Status{}, err
+	}
+
+	result := []Status{}
+	for i := len(m.migrations) - 1; i >= 0 && len(result) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == nil {
+			return result, fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, ErrIrreversible)
+		}
+
+		if err := migration.Down(ctx, m.db, m.cfg); err != nil {
+			return result, fmt.Errorf("migration %d %s down: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := m.coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: migration.Version}}); err != nil {
+			return result, fmt.Errorf("mongo delete one: %w", err)
+		}
+
+		m.logger.Info().Int("Version", migration.Version).Str("Name", migration.Name).Msg("Migration reverted")
+		result = append(result, Status{Version: migration.Version, Name: migration.Name})
+	}
+	return result, nil
+}
+
+func New(ctx context.Context, logger zerolog.Logger, client *mongo.Client, cfg Config) (*migrator, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return &migrator{}, fmt.Errorf("config validate: %w", err)
+	}
+
+	if err = client.Ping(ctx, nil); err != nil {
+		return &migrator{}, fmt.Errorf("mongo ping: %w", err)
+	}
+
+	migrations := append([]Migration(nil), Migrations...)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	db := client.Database(cfg.Database)
+	return &migrator{
+		logger:     logger.With().Str("Component", "migrator").Logger(),
+		db:         db,
+		coll:       db.Collection(cfg.MigrationCollection),
+		cfg:        cfg,
+		migrations: migrations,
+	}, nil
+}
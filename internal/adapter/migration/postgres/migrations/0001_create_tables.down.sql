@@ -0,0 +1,3 @@
+DROP TABLE billings;
+DROP TABLE staff;
+DROP TABLE users;
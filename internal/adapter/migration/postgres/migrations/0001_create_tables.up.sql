@@ -0,0 +1,37 @@
+CREATE TABLE users (
+	id TEXT PRIMARY KEY,
+	telegram_username TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX users_telegram_username_unique ON users (telegram_username);
+
+CREATE TABLE staff (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL
+);
+
+-- Nested lists and maps keep the shape of the Mongo documents as JSONB,
+-- top level fields used for filtering and reports are plain columns.
+CREATE TABLE billings (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+	state TEXT NOT NULL,
+	username TEXT NOT NULL DEFAULT '',
+	included_revisions INTEGER NOT NULL DEFAULT 0,
+	revision_policy TEXT NOT NULL DEFAULT 'unlimited',
+	revision_surcharge BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL,
+	deadline TIMESTAMPTZ,
+	priority TEXT NOT NULL DEFAULT '',
+	price BIGINT NOT NULL DEFAULT 0,
+	invoiced_at TIMESTAMPTZ,
+	revisions JSONB NOT NULL DEFAULT '[]',
+	line_items JSONB NOT NULL DEFAULT '[]',
+	state_changes JSONB NOT NULL DEFAULT '[]',
+	stage_targets JSONB NOT NULL DEFAULT '{}',
+	assignees JSONB NOT NULL DEFAULT '{}',
+	time_entries JSONB NOT NULL DEFAULT '[]',
+	payments JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX billings_user_id ON billings (user_id);
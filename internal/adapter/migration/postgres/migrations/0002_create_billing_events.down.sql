@@ -0,0 +1,2 @@
+DROP TABLE billing_snapshots;
+DROP TABLE billing_events;
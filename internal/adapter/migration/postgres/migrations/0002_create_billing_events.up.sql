@@ -0,0 +1,16 @@
+-- Event sourced billings keep their append-only streams here, the
+-- billings table stays as the read model. The primary key rejects a
+-- second writer appending the same version.
+CREATE TABLE billing_events (
+	billing_id TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	type TEXT NOT NULL,
+	data JSONB NOT NULL,
+	PRIMARY KEY (billing_id, version)
+);
+
+CREATE TABLE billing_snapshots (
+	billing_id TEXT PRIMARY KEY,
+	version INTEGER NOT NULL,
+	data JSONB NOT NULL
+);
@@ -0,0 +1,5 @@
+ALTER TABLE billings DROP COLUMN deleted_by;
+ALTER TABLE billings DROP COLUMN deleted_at;
+
+ALTER TABLE users DROP COLUMN deleted_by;
+ALTER TABLE users DROP COLUMN deleted_at;
@@ -0,0 +1,7 @@
+-- Soft deleted rows keep their data until the purge job removes them,
+-- a NULL deleted_at means the row is live.
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE billings ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE billings ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,297 @@
+package sql_migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/migration"
+	"github.com/rs/zerolog"
+)
+
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// placeholder returns the n-th query parameter, counting from one.
+func (d Dialect) placeholder(n int) string {
+	if d == DialectPostgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+type Config struct {
+	Dialect Dialect
+	// MigrationTable records applied migrations, it is created when missing.
+	MigrationTable string
+}
+
+func (cfg Config) Validate() error {
+	switch cfg.Dialect {
+	case DialectPostgres, DialectSQLite:
+	default:
+		return fmt.Errorf("unknown sql dialect %q", cfg.Dialect)
+	}
+	if cfg.MigrationTable == "" {
+		return fmt.Errorf("migration table name cannot be empty")
+	}
+	return nil
+}
+
+// Migration is a pair of files named like 0001_create_tables.up.sql and
+// 0001_create_tables.down.sql, a missing down file makes it irreversible.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// ReadMigrations loads migrations from the root of source in version order.
+func ReadMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return []Migration{}, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return []Migration{}, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(source, path.Clean(entry.Name()))
+		if err != nil {
+			return []Migration{}, fmt.Errorf("reading migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return []Migration{}, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return []Migration{}, fmt.Errorf("migration %d %s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+var _ migration.Migrator = &migrator{}
+
+type migrator struct {
+	logger     zerolog.Logger
+	db         *sql.DB
+	cfg        Config
+	migrations []Migration
+}
+
+func (m *migrator) applied(ctx context.Context) (map[int]migration.Status, error) {
+	createTable := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`, m.cfg.MigrationTable)
+	if _, err := m.db.ExecContext(ctx, createTable); err != nil {
+		return nil, fmt.Errorf("creating migration table: %w", err)
+	}
+
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf("SELECT version, name, applied_at FROM %s", m.cfg.MigrationTable))
+	if err != nil {
+		return nil, fmt.Errorf("sql query: %w", err)
+	}
+	defer rows.Close()
+
+	known := map[int]bool{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+	}
+	result := map[int]migration.Status{}
+	for rows.Next() {
+		row := migration.Status{Applied: true}
+		if err := rows.Scan(&row.Version, &row.Name, &row.AppliedAt); err != nil {
+			return nil, fmt.Errorf("rows scan: %w", err)
+		}
+		if !known[row.Version] {
+			return nil, fmt.Errorf("version %d: %w", row.Version, migration.ErrUnknownVersion)
+		}
+		result[row.Version] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return result, nil
+}
+
+func (m *migrator) Status(ctx context.Context) ([]migration.Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return []migration.Status{}, err
+	}
+
+	result := []migration.Status{}
+	for _, migration := range m.migrations {
+		row, ok := applied[migration.Version]
+		row.Version = migration.Version
+		row.Name = migration.Name
+		row.Applied = ok
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+// Check returns migration.ErrPendingMigrations unless every known migration is applied.
+func (m *migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []int
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Version)
+		}
+	}
+	if len(pending) > 0 {
+		return migration.ErrPendingMigrations{Pending: pending}
+	}
+	return nil
+}
+
+// inTx runs the migration script and updates the migration table in one
+// transaction so a failed migration leaves nothing behind.
+func (m *migrator) inTx(ctx context.Context, script string, record string, args ...any) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("sql exec: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("recording migration: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// Up applies pending migrations in version order up to and including
+// target, zero target means all of them.
+func (m *migrator) Up(ctx context.Context, target int) ([]migration.Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return []migration.Status{}, err
+	}
+
+	record := fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (%s, %s, %s)",
+		m.cfg.MigrationTable, m.cfg.Dialect.placeholder(1), m.cfg.Dialect.placeholder(2), m.cfg.Dialect.placeholder(3))
+
+	result := []migration.Status{}
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		appliedAt := time.Now().UTC()
+		if err := m.inTx(ctx, migration.Up, record, migration.Version, migration.Name, appliedAt); err != nil {
+			return result, fmt.Errorf("migration %d %s up: %w", migration.Version, migration.Name, err)
+		}
+
+		m.logger.Info().Int("Version", migration.Version).Str("Name", migration.Name).Msg("Migration applied")
+		result = append(result, statusOf(migration, true, appliedAt))
+	}
+	return result, nil
+}
+
+// Down reverts the given number of most recently applied migrations.
+func (m *migrator) Down(ctx context.Context, steps int) ([]migration.Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return []migration.Status{}, err
+	}
+
+	record := fmt.Sprintf("DELETE FROM %s WHERE version = %s", m.cfg.MigrationTable, m.cfg.Dialect.placeholder(1))
+
+	result := []migration.Status{}
+	for i := len(m.migrations) - 1; i >= 0 && len(result) < steps; i-- {
+		current := m.migrations[i]
+		if _, ok := applied[current.Version]; !ok {
+			continue
+		}
+		if current.Down == "" {
+			return result, fmt.Errorf("migration %d %s: %w", current.Version, current.Name, migration.ErrIrreversible)
+		}
+
+		if err := m.inTx(ctx, current.Down, record, current.Version); err != nil {
+			return result, fmt.Errorf("migration %d %s down: %w", current.Version, current.Name, err)
+		}
+
+		m.logger.Info().Int("Version", current.Version).Str("Name", current.Name).Msg("Migration reverted")
+		result = append(result, statusOf(current, false, time.Time{}))
+	}
+	return result, nil
+}
+
+func statusOf(m Migration, applied bool, appliedAt time.Time) migration.Status {
+	return migration.Status{Version: m.Version, Name: m.Name, Applied: applied, AppliedAt: appliedAt}
+}
+
+// New reads the migrations from the root of source, the database
+// is not touched until the first call.
+func New(logger zerolog.Logger, db *sql.DB, source fs.FS, cfg Config) (*migrator, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return &migrator{}, fmt.Errorf("config validate: %w", err)
+	}
+
+	migrations, err := ReadMigrations(source)
+	if err != nil {
+		return &migrator{}, err
+	}
+
+	return &migrator{
+		logger:     logger.With().Str("Component", "migrator").Logger(),
+		db:         db,
+		cfg:        cfg,
+		migrations: migrations,
+	}, nil
+}
@@ -0,0 +1,37 @@
+CREATE TABLE users (
+	id TEXT PRIMARY KEY,
+	telegram_username TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX users_telegram_username_unique ON users (telegram_username);
+
+CREATE TABLE staff (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL
+);
+
+-- Times are fixed width UTC text so they sort in order, an empty string
+-- means not set. Nested lists and maps are JSON text.
+CREATE TABLE billings (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+	state TEXT NOT NULL,
+	username TEXT NOT NULL DEFAULT '',
+	included_revisions INTEGER NOT NULL DEFAULT 0,
+	revision_policy TEXT NOT NULL DEFAULT 'unlimited',
+	revision_surcharge INTEGER NOT NULL DEFAULT 0,
+	created_at TEXT NOT NULL,
+	deadline TEXT NOT NULL DEFAULT '',
+	priority TEXT NOT NULL DEFAULT '',
+	price INTEGER NOT NULL DEFAULT 0,
+	invoiced_at TEXT NOT NULL DEFAULT '',
+	revisions TEXT NOT NULL DEFAULT '[]',
+	line_items TEXT NOT NULL DEFAULT '[]',
+	state_changes TEXT NOT NULL DEFAULT '[]',
+	stage_targets TEXT NOT NULL DEFAULT '{}',
+	assignees TEXT NOT NULL DEFAULT '{}',
+	time_entries TEXT NOT NULL DEFAULT '[]',
+	payments TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX billings_user_id ON billings (user_id);
@@ -0,0 +1,15 @@
+-- Administrative actions are appended here and never updated or deleted.
+CREATE TABLE audit_log (
+	id TEXT PRIMARY KEY,
+	actor TEXT NOT NULL,
+	request_id TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	action TEXT NOT NULL,
+	target_type TEXT NOT NULL,
+	target_id TEXT NOT NULL,
+	changes TEXT NOT NULL,
+	occurred_at TEXT NOT NULL
+);
+
+CREATE INDEX audit_log_occurred_at ON audit_log (occurred_at DESC);
+CREATE INDEX audit_log_target ON audit_log (target_type, target_id, occurred_at DESC);
@@ -0,0 +1,2 @@
+DROP INDEX users_telegram_username_live_unique;
+CREATE UNIQUE INDEX users_telegram_username_unique ON users (telegram_username);
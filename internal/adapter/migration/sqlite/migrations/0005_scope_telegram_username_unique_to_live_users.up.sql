@@ -0,0 +1,4 @@
+-- Soft deleted users keep their telegram username until the purge job
+-- removes them, only live users need distinct ones.
+DROP INDEX users_telegram_username_unique;
+CREATE UNIQUE INDEX users_telegram_username_live_unique ON users (telegram_username) WHERE deleted_at = '';
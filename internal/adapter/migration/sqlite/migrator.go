@@ -0,0 +1,29 @@
+package sqlite_migration
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/migration"
+	sql_migration "github.com/ThePositree/billing_manager/internal/adapter/migration/sql"
+	"github.com/rs/zerolog"
+)
+
+// MigrationTable records applied migrations next to the data.
+const MigrationTable = "schema_migrations"
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+func New(logger zerolog.Logger, db *sql.DB) (migration.Migrator, error) {
+	source, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("migrations dir: %w", err)
+	}
+	return sql_migration.New(logger, db, source, sql_migration.Config{
+		Dialect:        sql_migration.DialectSQLite,
+		MigrationTable: MigrationTable,
+	})
+}
@@ -0,0 +1,92 @@
+package log_notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"text/template"
+
+	"github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/usecase/deadline_checking"
+	"github.com/rs/zerolog"
+)
+
+var _ deadline_checking.Notifier = &notifier{}
+
+// DefaultTemplates render the log message per deadline status,
+// the deadline_checking.Event is the template data.
+var DefaultTemplates = map[string]string{
+	billing.DeadlineStatusApproaching.String(): `Billing {{.BillingId}} in {{.State}} is due at {{.DueAt.Format "2006-01-02 15:04"}}`,
+	billing.DeadlineStatusOverdue.String():     `Billing {{.BillingId}} in {{.State}} was due at {{.DueAt.Format "2006-01-02 15:04"}}`,
+}
+
+type Templates map[billing.DeadlineStatus]*template.Template
+
+// ParseTemplates parses templates keyed by deadline status on top of DefaultTemplates.
+func ParseTemplates(sources map[string]string) (Templates, error) {
+	merged := map[string]string{}
+	for status, source := range DefaultTemplates {
+		merged[status] = source
+	}
+	for status, source := range sources {
+		merged[status] = source
+	}
+
+	templates := Templates{}
+	for statusName, source := range merged {
+		status, err := billing.ParseDeadlineStatus(statusName)
+		if err != nil {
+			return Templates{}, fmt.Errorf("template %s: %w", statusName, err)
+		}
+		parsed, err := template.New(statusName).Option("missingkey=error").Parse(source)
+		if err != nil {
+			return Templates{}, fmt.Errorf("template %s: %w", statusName, err)
+		}
+		templates[status] = parsed
+	}
+	return templates, nil
+}
+
+type notifier struct {
+	logger    zerolog.Logger
+	templates atomic.Pointer[Templates]
+}
+
+// SetTemplates takes effect for the next notification.
+func (n *notifier) SetTemplates(templates Templates) {
+	n.templates.Store(&templates)
+}
+
+func (n *notifier) Notify(ctx context.Context, event deadline_checking.Event) error {
+	message := "Billing deadline"
+	if template, ok := (*n.templates.Load())[event.Status]; ok {
+		var rendered strings.Builder
+		if err := template.Execute(&rendered, event); err != nil {
+			n.logger.Error().Err(err).Str("Status", event.Status.String()).Msg("Render notification template")
+		} else {
+			message = rendered.String()
+		}
+	}
+
+	logEvent := n.logger.Warn()
+	if event.Status == billing.DeadlineStatusOverdue {
+		logEvent = n.logger.Error()
+	}
+	logEvent.
+		Str("BillingId", event.BillingId).
+		Str("UserId", event.UserId).
+		Str("State", event.State.String()).
+		Str("Status", event.Status.String()).
+		Time("DueAt", event.DueAt).
+		Msg(message)
+	return nil
+}
+
+func New(logger zerolog.Logger, templates Templates) *notifier {
+	notifier := &notifier{
+		logger: logger.With().Str("Notifier", "deadline").Logger(),
+	}
+	notifier.SetTemplates(templates)
+	return notifier
+}
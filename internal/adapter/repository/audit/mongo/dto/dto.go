@@ -0,0 +1,103 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+)
+
+type Change struct {
+	Field  string `bson:"field"`
+	Before string `bson:"before"`
+	After  string `bson:"after"`
+}
+
+func (c Change) GetField() string {
+	return c.Field
+}
+
+func (c Change) GetBefore() string {
+	return c.Before
+}
+
+func (c Change) GetAfter() string {
+	return c.After
+}
+
+type Entry struct {
+	Id         string    `bson:"_id"`
+	Actor      string    `bson:"actor"`
+	RequestId  string    `bson:"request_id"`
+	IP         string    `bson:"ip"`
+	Action     string    `bson:"action"`
+	TargetType string    `bson:"target_type"`
+	TargetId   string    `bson:"target_id"`
+	Changes    []Change  `bson:"changes"`
+	OccurredAt time.Time `bson:"occurred_at"`
+}
+
+func (e Entry) GetId() string {
+	return e.Id
+}
+
+func (e Entry) GetActor() string {
+	return e.Actor
+}
+
+func (e Entry) GetRequestId() string {
+	return e.RequestId
+}
+
+func (e Entry) GetIP() string {
+	return e.IP
+}
+
+func (e Entry) GetAction() string {
+	return e.Action
+}
+
+func (e Entry) GetTargetType() string {
+	return e.TargetType
+}
+
+func (e Entry) GetTargetId() string {
+	return e.TargetId
+}
+
+func (e Entry) GetChanges() []audit.ChangeDTO {
+	changes := make([]audit.ChangeDTO, 0, len(e.Changes))
+	for _, change := range e.Changes {
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func (e Entry) GetOccurredAt() time.Time {
+	return e.OccurredAt
+}
+
+func (e Entry) ToModel() (audit.Entry, error) {
+	return audit.ToModelFromDTO(e)
+}
+
+func NewEntryDTOFromModel(entry audit.Entry) Entry {
+	changes := make([]Change, 0, len(entry.Changes))
+	for _, change := range entry.Changes {
+		changes = append(changes, Change{
+			Field:  change.Field,
+			Before: change.Before,
+			After:  change.After,
+		})
+	}
+	return Entry{
+		Id:         entry.Id,
+		Actor:      entry.Actor.Name,
+		RequestId:  entry.Actor.RequestId,
+		IP:         entry.Actor.IP,
+		Action:     entry.Action,
+		TargetType: entry.Target.Type,
+		TargetId:   entry.Target.Id,
+		Changes:    changes,
+		OccurredAt: entry.OccurredAt,
+	}
+}
@@ -0,0 +1,117 @@
+package mongo_audit_repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/audit/mongo/dto"
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var _ usecase.AuditRepository = &auditRepository{}
+
+type Config struct {
+	Database   string
+	Collection string
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Database == "" {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if cfg.Collection == "" {
+		return fmt.Errorf("collection name cannot be empty")
+	}
+	return nil
+}
+
+type auditRepository struct {
+	coll   *mongo.Collection
+	client *mongo.Client
+}
+
+func (a *auditRepository) Append(ctx context.Context, entry audit.Entry) (audit.Entry, error) {
+	_, err := a.coll.InsertOne(ctx, dto.NewEntryDTOFromModel(entry))
+	if err != nil {
+		return audit.Entry{}, fmt.Errorf("mongo insert one: %w", err)
+	}
+	return entry, nil
+}
+
+func (a *auditRepository) Find(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	query := bson.D{}
+	for _, field := range []struct {
+		key   string
+		value string
+	}{
+		{key: "actor", value: filter.Actor},
+		{key: "action", value: filter.Action},
+		{key: "target_type", value: filter.TargetType},
+		{key: "target_id", value: filter.TargetId},
+	} {
+		if field.value == "" {
+			continue
+		}
+		query = append(query, bson.E{Key: field.key, Value: field.value})
+	}
+	occurredAt := bson.D{}
+	if !filter.From.IsZero() {
+		occurredAt = append(occurredAt, bson.E{Key: "$gte", Value: filter.From})
+	}
+	if !filter.To.IsZero() {
+		occurredAt = append(occurredAt, bson.E{Key: "$lt", Value: filter.To})
+	}
+	if len(occurredAt) > 0 {
+		query = append(query, bson.E{Key: "occurred_at", Value: occurredAt})
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "occurred_at", Value: -1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(filter.Limit))
+	cursor, err := a.coll.Find(ctx, query, opts)
+	if err != nil {
+		return []audit.Entry{}, fmt.Errorf("mongo find: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	entries := []audit.Entry{}
+	for cursor.Next(ctx) {
+		var entryDTO dto.Entry
+		if err := cursor.Decode(&entryDTO); err != nil {
+			return []audit.Entry{}, fmt.Errorf("result decode: %w", err)
+		}
+		entry, err := entryDTO.ToModel()
+		if err != nil {
+			return []audit.Entry{}, fmt.Errorf("dto to model: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := cursor.Err(); err != nil {
+		return []audit.Entry{}, fmt.Errorf("cursor error: %w", err)
+	}
+	return entries, nil
+}
+
+func New(ctx context.Context, logger zerolog.Logger, client *mongo.Client, cfg Config) (*auditRepository, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return &auditRepository{}, fmt.Errorf("config validate: %w", err)
+	}
+
+	auditRepo := &auditRepository{
+		client: client,
+	}
+
+	if err = auditRepo.client.Ping(ctx, nil); err != nil {
+		return &auditRepository{}, fmt.Errorf("mongo ping: %w", err)
+	}
+
+	auditRepo.coll = auditRepo.client.Database(cfg.Database).Collection(cfg.Collection)
+
+	return auditRepo, nil
+}
@@ -0,0 +1,102 @@
+package postgres_audit_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/audit/sql/dto"
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	sql_transaction "github.com/ThePositree/billing_manager/internal/adapter/transaction/sql"
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/rs/zerolog"
+)
+
+var _ usecase.AuditRepository = &auditRepository{}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func (a *auditRepository) Append(ctx context.Context, entry audit.Entry) (audit.Entry, error) {
+	args, err := dto.Args(dto.NewEntryDTOFromModel(entry), sql_repository.NullTime)
+	if err != nil {
+		return audit.Entry{}, err
+	}
+
+	_, err = sql_transaction.GetExecutor(ctx, a.db).ExecContext(ctx,
+		"INSERT INTO audit_log ("+dto.Columns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		args...,
+	)
+	if err != nil {
+		return audit.Entry{}, fmt.Errorf("sql insert: %w", err)
+	}
+	return entry, nil
+}
+
+func (a *auditRepository) Find(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	for _, field := range []struct {
+		column string
+		value  string
+	}{
+		{column: "actor", value: filter.Actor},
+		{column: "action", value: filter.Action},
+		{column: "target_type", value: filter.TargetType},
+		{column: "target_id", value: filter.TargetId},
+	} {
+		if field.value == "" {
+			continue
+		}
+		args = append(args, field.value)
+		conditions = append(conditions, field.column+" = $"+strconv.Itoa(len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, "occurred_at >= $"+strconv.Itoa(len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, "occurred_at < $"+strconv.Itoa(len(args)))
+	}
+
+	query := "SELECT " + dto.Columns + " FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += " ORDER BY occurred_at DESC, id LIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := sql_transaction.GetExecutor(ctx, a.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return []audit.Entry{}, fmt.Errorf("sql query: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []audit.Entry{}
+	for rows.Next() {
+		entry, err := dto.Scan(rows)
+		if err != nil {
+			return []audit.Entry{}, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return []audit.Entry{}, fmt.Errorf("rows error: %w", err)
+	}
+	return entries, nil
+}
+
+// New expects the schema to be migrated, see postgres_migration.
+func New(ctx context.Context, logger zerolog.Logger, db *sql.DB) (*auditRepository, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return &auditRepository{}, fmt.Errorf("sql ping: %w", err)
+	}
+	return &auditRepository{db: db}, nil
+}
@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+)
+
+// Columns are listed in the order Args and Scan use.
+const Columns = "id, actor, request_id, ip, action, target_type, target_id, changes, occurred_at"
+
+func Args(entryDTO Entry, timeValue sql_repository.TimeValue) ([]any, error) {
+	changes, err := json.Marshal(entryDTO.Changes)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal: %w", err)
+	}
+	return []any{
+		entryDTO.Id,
+		entryDTO.Actor,
+		entryDTO.RequestId,
+		entryDTO.IP,
+		entryDTO.Action,
+		entryDTO.TargetType,
+		entryDTO.TargetId,
+		string(changes),
+		timeValue(entryDTO.OccurredAt),
+	}, nil
+}
+
+func Scan(row sql_repository.Row) (audit.Entry, error) {
+	var (
+		entryDTO Entry
+		changes  []byte
+	)
+	err := row.Scan(
+		&entryDTO.Id,
+		&entryDTO.Actor,
+		&entryDTO.RequestId,
+		&entryDTO.IP,
+		&entryDTO.Action,
+		&entryDTO.TargetType,
+		&entryDTO.TargetId,
+		&changes,
+		sql_repository.TimeScanner{Time: &entryDTO.OccurredAt},
+	)
+	if err != nil {
+		return audit.Entry{}, fmt.Errorf("row scan: %w", err)
+	}
+	if err := json.Unmarshal(changes, &entryDTO.Changes); err != nil {
+		return audit.Entry{}, fmt.Errorf("json unmarshal: %w", err)
+	}
+
+	entry, err := entryDTO.ToModel()
+	if err != nil {
+		return audit.Entry{}, fmt.Errorf("dto to model: %w", err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,101 @@
+package sqlite_audit_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/audit/sql/dto"
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	sql_transaction "github.com/ThePositree/billing_manager/internal/adapter/transaction/sql"
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/rs/zerolog"
+)
+
+var _ usecase.AuditRepository = &auditRepository{}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func (a *auditRepository) Append(ctx context.Context, entry audit.Entry) (audit.Entry, error) {
+	args, err := dto.Args(dto.NewEntryDTOFromModel(entry), sql_repository.TextTime)
+	if err != nil {
+		return audit.Entry{}, err
+	}
+
+	_, err = sql_transaction.GetExecutor(ctx, a.db).ExecContext(ctx,
+		"INSERT INTO audit_log ("+dto.Columns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		args...,
+	)
+	if err != nil {
+		return audit.Entry{}, fmt.Errorf("sql insert: %w", err)
+	}
+	return entry, nil
+}
+
+func (a *auditRepository) Find(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	for _, field := range []struct {
+		column string
+		value  string
+	}{
+		{column: "actor", value: filter.Actor},
+		{column: "action", value: filter.Action},
+		{column: "target_type", value: filter.TargetType},
+		{column: "target_id", value: filter.TargetId},
+	} {
+		if field.value == "" {
+			continue
+		}
+		conditions = append(conditions, field.column+" = ?")
+		args = append(args, field.value)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "occurred_at >= ?")
+		args = append(args, sql_repository.TextTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "occurred_at < ?")
+		args = append(args, sql_repository.TextTime(filter.To))
+	}
+
+	query := "SELECT " + dto.Columns + " FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY occurred_at DESC, id LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := sql_transaction.GetExecutor(ctx, a.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return []audit.Entry{}, fmt.Errorf("sql query: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []audit.Entry{}
+	for rows.Next() {
+		entry, err := dto.Scan(rows)
+		if err != nil {
+			return []audit.Entry{}, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return []audit.Entry{}, fmt.Errorf("rows error: %w", err)
+	}
+	return entries, nil
+}
+
+// New expects the schema to be migrated, see sqlite_migration.
+func New(ctx context.Context, logger zerolog.Logger, db *sql.DB) (*auditRepository, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return &auditRepository{}, fmt.Errorf("sql ping: %w", err)
+	}
+	return &auditRepository{db: db}, nil
+}
@@ -0,0 +1,326 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/billing"
+)
+
+type Revision struct {
+	Stage       string    `bson:"stage" json:"stage"`
+	RequestedBy string    `bson:"requested_by" json:"requested_by"`
+	Note        string    `bson:"note" json:"note"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+func (r Revision) GetStage() string {
+	return r.Stage
+}
+
+func (r Revision) GetRequestedBy() string {
+	return r.RequestedBy
+}
+
+func (r Revision) GetNote() string {
+	return r.Note
+}
+
+func (r Revision) GetCreatedAt() time.Time {
+	return r.CreatedAt
+}
+
+type LineItem struct {
+	Description string `bson:"description" json:"description"`
+	Amount      int64  `bson:"amount" json:"amount"`
+}
+
+func (l LineItem) GetDescription() string {
+	return l.Description
+}
+
+func (l LineItem) GetAmount() int64 {
+	return l.Amount
+}
+
+type StateChange struct {
+	State     string    `bson:"state" json:"state"`
+	EnteredAt time.Time `bson:"entered_at" json:"entered_at"`
+}
+
+func (s StateChange) GetState() string {
+	return s.State
+}
+
+func (s StateChange) GetEnteredAt() time.Time {
+	return s.EnteredAt
+}
+
+type TimeEntry struct {
+	Id        string    `bson:"id" json:"id"`
+	StaffId   string    `bson:"staff_id" json:"staff_id"`
+	Stage     string    `bson:"stage" json:"stage"`
+	StartedAt time.Time `bson:"started_at" json:"started_at"`
+	EndedAt   time.Time `bson:"ended_at" json:"ended_at"`
+	Note      string    `bson:"note" json:"note"`
+}
+
+func (e TimeEntry) GetId() string {
+	return e.Id
+}
+
+func (e TimeEntry) GetStaffId() string {
+	return e.StaffId
+}
+
+func (e TimeEntry) GetStage() string {
+	return e.Stage
+}
+
+func (e TimeEntry) GetStartedAt() time.Time {
+	return e.StartedAt
+}
+
+func (e TimeEntry) GetEndedAt() time.Time {
+	return e.EndedAt
+}
+
+func (e TimeEntry) GetNote() string {
+	return e.Note
+}
+
+type Payment struct {
+	Id     string    `bson:"id" json:"id"`
+	Amount int64     `bson:"amount" json:"amount"`
+	PaidAt time.Time `bson:"paid_at" json:"paid_at"`
+	Note   string    `bson:"note" json:"note"`
+}
+
+func (p Payment) GetId() string {
+	return p.Id
+}
+
+func (p Payment) GetAmount() int64 {
+	return p.Amount
+}
+
+func (p Payment) GetPaidAt() time.Time {
+	return p.PaidAt
+}
+
+func (p Payment) GetNote() string {
+	return p.Note
+}
+
+type Billing struct {
+	Id                string                   `bson:"_id" json:"id"`
+	UserId            string                   `bson:"user_id" json:"user_id"`
+	State             string                   `bson:"state" json:"state"`
+	Username          string                   `bson:"username" json:"username"`
+	Revisions         []Revision               `bson:"revisions" json:"revisions"`
+	IncludedRevisions int                      `bson:"included_revisions" json:"included_revisions"`
+	RevisionPolicy    string                   `bson:"revision_policy" json:"revision_policy"`
+	RevisionSurcharge int64                    `bson:"revision_surcharge" json:"revision_surcharge"`
+	LineItems         []LineItem               `bson:"line_items" json:"line_items"`
+	CreatedAt         time.Time                `bson:"created_at" json:"created_at"`
+	StateChanges      []StateChange            `bson:"state_changes" json:"state_changes"`
+	Deadline          time.Time                `bson:"deadline" json:"deadline"`
+	StageTargets      map[string]time.Duration `bson:"stage_targets" json:"stage_targets"`
+	Priority          string                   `bson:"priority" json:"priority"`
+	Assignees         map[string]string        `bson:"assignees" json:"assignees"`
+	TimeEntries       []TimeEntry              `bson:"time_entries" json:"time_entries"`
+	Price             int64                    `bson:"price" json:"price"`
+	InvoicedAt        time.Time                `bson:"invoiced_at" json:"invoiced_at"`
+	Payments          []Payment                `bson:"payments" json:"payments"`
+	DeletedAt         time.Time                `bson:"deleted_at" json:"deleted_at"`
+	DeletedBy         string                   `bson:"deleted_by" json:"deleted_by"`
+}
+
+func (u Billing) GetUsername() string {
+	return u.Username
+}
+
+func (u Billing) ToModel() (billing.Billing, error) {
+	return billing.ToModelFromDTO(u)
+}
+
+func (u Billing) GetId() string {
+	return u.Id
+}
+
+func (u Billing) GetUserId() string {
+	return u.UserId
+}
+
+func (u Billing) GetState() string {
+	return u.State
+}
+
+func (u Billing) GetRevisions() []billing.RevisionDTO {
+	var result []billing.RevisionDTO
+	for _, revision := range u.Revisions {
+		result = append(result, revision)
+	}
+	return result
+}
+
+func (u Billing) GetIncludedRevisions() int {
+	return u.IncludedRevisions
+}
+
+func (u Billing) GetRevisionPolicy() string {
+	return u.RevisionPolicy
+}
+
+func (u Billing) GetRevisionSurcharge() int64 {
+	return u.RevisionSurcharge
+}
+
+func (u Billing) GetLineItems() []billing.LineItemDTO {
+	var result []billing.LineItemDTO
+	for _, lineItem := range u.LineItems {
+		result = append(result, lineItem)
+	}
+	return result
+}
+
+func (u Billing) GetCreatedAt() time.Time {
+	return u.CreatedAt
+}
+
+func (u Billing) GetStateChanges() []billing.StateChangeDTO {
+	var result []billing.StateChangeDTO
+	for _, stateChange := range u.StateChanges {
+		result = append(result, stateChange)
+	}
+	return result
+}
+
+func (u Billing) GetDeadline() time.Time {
+	return u.Deadline
+}
+
+func (u Billing) GetStageTargets() map[string]time.Duration {
+	return u.StageTargets
+}
+
+func (u Billing) GetPriority() string {
+	return u.Priority
+}
+
+func (u Billing) GetAssignees() map[string]string {
+	return u.Assignees
+}
+
+func (u Billing) GetTimeEntries() []billing.TimeEntryDTO {
+	var result []billing.TimeEntryDTO
+	for _, timeEntry := range u.TimeEntries {
+		result = append(result, timeEntry)
+	}
+	return result
+}
+
+func (u Billing) GetPrice() int64 {
+	return u.Price
+}
+
+func (u Billing) GetInvoicedAt() time.Time {
+	return u.InvoicedAt
+}
+
+func (u Billing) GetDeletedAt() time.Time {
+	return u.DeletedAt
+}
+
+func (u Billing) GetDeletedBy() string {
+	return u.DeletedBy
+}
+
+func (u Billing) GetPayments() []billing.PaymentDTO {
+	var result []billing.PaymentDTO
+	for _, payment := range u.Payments {
+		result = append(result, payment)
+	}
+	return result
+}
+
+func NewTimeEntryDTOFromModel(timeEntry billing.TimeEntry) TimeEntry {
+	return TimeEntry{
+		Id:        timeEntry.Id,
+		StaffId:   timeEntry.StaffId,
+		Stage:     timeEntry.Stage.String(),
+		StartedAt: timeEntry.StartedAt,
+		EndedAt:   timeEntry.EndedAt,
+		Note:      timeEntry.Note,
+	}
+}
+
+func NewBillingDTOFromModel(billing billing.Billing) Billing {
+	var revisions []Revision
+	for _, revision := range billing.GetRevisions() {
+		revisions = append(revisions, Revision{
+			Stage:       revision.Stage.String(),
+			RequestedBy: revision.RequestedBy,
+			Note:        revision.Note,
+			CreatedAt:   revision.CreatedAt,
+		})
+	}
+	var lineItems []LineItem
+	for _, lineItem := range billing.GetLineItems() {
+		lineItems = append(lineItems, LineItem{
+			Description: lineItem.Description,
+			Amount:      lineItem.Amount,
+		})
+	}
+	var stateChanges []StateChange
+	for _, stateChange := range billing.GetStateChanges() {
+		stateChanges = append(stateChanges, StateChange{
+			State:     stateChange.State.String(),
+			EnteredAt: stateChange.EnteredAt,
+		})
+	}
+	stageTargets := map[string]time.Duration{}
+	for stage, target := range billing.GetStageTargets() {
+		stageTargets[stage.String()] = target
+	}
+	assignees := map[string]string{}
+	for stage, staffId := range billing.GetAssignees() {
+		assignees[stage.String()] = staffId
+	}
+	var timeEntries []TimeEntry
+	for _, timeEntry := range billing.GetTimeEntries() {
+		timeEntries = append(timeEntries, NewTimeEntryDTOFromModel(timeEntry))
+	}
+	var payments []Payment
+	for _, payment := range billing.GetPayments() {
+		payments = append(payments, Payment{
+			Id:     payment.Id,
+			Amount: payment.Amount,
+			PaidAt: payment.PaidAt,
+			Note:   payment.Note,
+		})
+	}
+	revisionLimit := billing.GetRevisionLimit()
+	return Billing{
+		Id:                billing.Id,
+		UserId:            billing.UserId,
+		State:             billing.GetState().String(),
+		Username:          billing.GetBriefInfo().Username,
+		Revisions:         revisions,
+		IncludedRevisions: revisionLimit.Included,
+		RevisionPolicy:    revisionLimit.Policy.String(),
+		RevisionSurcharge: revisionLimit.Surcharge,
+		LineItems:         lineItems,
+		CreatedAt:         billing.GetCreatedAt(),
+		StateChanges:      stateChanges,
+		Deadline:          billing.GetDeadline(),
+		StageTargets:      stageTargets,
+		Priority:          billing.GetPriority().String(),
+		Assignees:         assignees,
+		TimeEntries:       timeEntries,
+		Price:             billing.GetPrice(),
+		InvoicedAt:        billing.GetInvoicedAt(),
+		Payments:          payments,
+		DeletedAt:         billing.GetDeletedAt(),
+		DeletedBy:         billing.GetDeletedBy(),
+	}
+}
@@ -0,0 +1,210 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/billing"
+)
+
+// Snapshot is a billing rebuilt from the first Version events of its stream.
+type Snapshot struct {
+	BillingId string  `bson:"_id" json:"billing_id"`
+	Version   int     `bson:"version" json:"version"`
+	Billing   Billing `bson:"billing" json:"billing"`
+}
+
+func (s Snapshot) ToModel() (billing.Billing, error) {
+	return billing.ToModelFromSnapshot(s.Billing, s.Version)
+}
+
+func NewSnapshotDTOFromModel(billing billing.Billing) Snapshot {
+	return Snapshot{
+		BillingId: billing.Id,
+		Version:   billing.GetVersion(),
+		Billing:   NewBillingDTOFromModel(billing),
+	}
+}
+
+// Event keeps only the payload fields of its type, the rest are omitted.
+type Event struct {
+	BillingId         string                   `bson:"billing_id" json:"billing_id"`
+	Version           int                      `bson:"version" json:"version"`
+	Type              string                   `bson:"type" json:"type"`
+	OccurredAt        time.Time                `bson:"occurred_at" json:"occurred_at"`
+	UserId            string                   `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	State             string                   `bson:"state,omitempty" json:"state,omitempty"`
+	Username          string                   `bson:"username,omitempty" json:"username,omitempty"`
+	Revision          *Revision                `bson:"revision,omitempty" json:"revision,omitempty"`
+	LineItem          *LineItem                `bson:"line_item,omitempty" json:"line_item,omitempty"`
+	IncludedRevisions int                      `bson:"included_revisions,omitempty" json:"included_revisions,omitempty"`
+	RevisionPolicy    string                   `bson:"revision_policy,omitempty" json:"revision_policy,omitempty"`
+	RevisionSurcharge int64                    `bson:"revision_surcharge,omitempty" json:"revision_surcharge,omitempty"`
+	Deadline          time.Time                `bson:"deadline,omitempty" json:"deadline,omitempty"`
+	StageTargets      map[string]time.Duration `bson:"stage_targets,omitempty" json:"stage_targets,omitempty"`
+	Priority          string                   `bson:"priority,omitempty" json:"priority,omitempty"`
+	Stage             string                   `bson:"stage,omitempty" json:"stage,omitempty"`
+	StaffId           string                   `bson:"staff_id,omitempty" json:"staff_id,omitempty"`
+	TimeEntry         *TimeEntry               `bson:"time_entry,omitempty" json:"time_entry,omitempty"`
+	Price             int64                    `bson:"price,omitempty" json:"price,omitempty"`
+	Payment           *Payment                 `bson:"payment,omitempty" json:"payment,omitempty"`
+	DeletedBy         string                   `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+}
+
+func (e Event) ToModel() (billing.Event, error) {
+	return billing.ToEventFromDTO(e)
+}
+
+func (e Event) GetType() string {
+	return e.Type
+}
+
+func (e Event) GetBillingId() string {
+	return e.BillingId
+}
+
+func (e Event) GetOccurredAt() time.Time {
+	return e.OccurredAt
+}
+
+func (e Event) GetUserId() string {
+	return e.UserId
+}
+
+func (e Event) GetState() string {
+	return e.State
+}
+
+func (e Event) GetUsername() string {
+	return e.Username
+}
+
+func (e Event) GetRevision() billing.RevisionDTO {
+	if e.Revision == nil {
+		return nil
+	}
+	return *e.Revision
+}
+
+func (e Event) GetLineItem() billing.LineItemDTO {
+	if e.LineItem == nil {
+		return nil
+	}
+	return *e.LineItem
+}
+
+func (e Event) GetIncludedRevisions() int {
+	return e.IncludedRevisions
+}
+
+func (e Event) GetRevisionPolicy() string {
+	return e.RevisionPolicy
+}
+
+func (e Event) GetRevisionSurcharge() int64 {
+	return e.RevisionSurcharge
+}
+
+func (e Event) GetDeadline() time.Time {
+	return e.Deadline
+}
+
+func (e Event) GetStageTargets() map[string]time.Duration {
+	return e.StageTargets
+}
+
+func (e Event) GetPriority() string {
+	return e.Priority
+}
+
+func (e Event) GetStage() string {
+	return e.Stage
+}
+
+func (e Event) GetStaffId() string {
+	return e.StaffId
+}
+
+func (e Event) GetTimeEntry() billing.TimeEntryDTO {
+	if e.TimeEntry == nil {
+		return nil
+	}
+	return *e.TimeEntry
+}
+
+func (e Event) GetPrice() int64 {
+	return e.Price
+}
+
+func (e Event) GetPayment() billing.PaymentDTO {
+	if e.Payment == nil {
+		return nil
+	}
+	return *e.Payment
+}
+
+func (e Event) GetDeletedBy() string {
+	return e.DeletedBy
+}
+
+// NewEventDTOFromModel stores the event as the given version of its stream.
+func NewEventDTOFromModel(event billing.Event, version int) Event {
+	eventDTO := Event{
+		BillingId:  event.BillingId,
+		Version:    version,
+		Type:       event.Type.String(),
+		OccurredAt: event.OccurredAt,
+	}
+
+	switch event.Type {
+	case billing.EventTypeCreated:
+		eventDTO.UserId = event.UserId
+	case billing.EventTypeStateAdvanced, billing.EventTypeStateReverted:
+		eventDTO.State = event.State.String()
+	case billing.EventTypeBriefSet:
+		eventDTO.Username = event.Username
+	case billing.EventTypeRevisionRequested:
+		eventDTO.Revision = &Revision{
+			Stage:       event.Revision.Stage.String(),
+			RequestedBy: event.Revision.RequestedBy,
+			Note:        event.Revision.Note,
+			CreatedAt:   event.Revision.CreatedAt,
+		}
+	case billing.EventTypeLineItemAdded:
+		eventDTO.LineItem = &LineItem{
+			Description: event.LineItem.Description,
+			Amount:      event.LineItem.Amount,
+		}
+	case billing.EventTypeRevisionLimitSet:
+		eventDTO.IncludedRevisions = event.RevisionLimit.Included
+		eventDTO.RevisionPolicy = event.RevisionLimit.Policy.String()
+		eventDTO.RevisionSurcharge = event.RevisionLimit.Surcharge
+	case billing.EventTypeDeadlineSet:
+		eventDTO.Deadline = event.Deadline
+	case billing.EventTypeStageTargetsSet:
+		eventDTO.StageTargets = map[string]time.Duration{}
+		for stage, target := range event.StageTargets {
+			eventDTO.StageTargets[stage.String()] = target
+		}
+	case billing.EventTypePrioritySet:
+		eventDTO.Priority = event.Priority.String()
+	case billing.EventTypeAssigned:
+		eventDTO.Stage = event.Stage.String()
+		eventDTO.StaffId = event.StaffId
+	case billing.EventTypeTimeEntryAdded, billing.EventTypeTimerStopped:
+		timeEntry := NewTimeEntryDTOFromModel(event.TimeEntry)
+		eventDTO.TimeEntry = &timeEntry
+	case billing.EventTypePriceSet:
+		eventDTO.Price = event.Price
+	case billing.EventTypePaymentAdded:
+		eventDTO.Payment = &Payment{
+			Id:     event.Payment.Id,
+			Amount: event.Payment.Amount,
+			PaidAt: event.Payment.PaidAt,
+			Note:   event.Payment.Note,
+		}
+	case billing.EventTypeDeleted:
+		eventDTO.DeletedBy = event.DeletedBy
+	}
+
+	return eventDTO
+}
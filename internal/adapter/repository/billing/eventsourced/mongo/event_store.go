@@ -0,0 +1,253 @@
+package mongo_billing_event_store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/billing/eventsourced"
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/billing/eventsourced/dto"
+	repository_crypto "github.com/ThePositree/billing_manager/internal/adapter/repository/crypto"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var _ eventsourced_billing_repository.EventStore = &eventStore{}
+
+// ErrVersionConflict is reported by the unique index on billing id and
+// version, see mongo_migration.
+var ErrVersionConflict = errors.New("version conflict")
+
+type Config struct {
+	Database           string
+	EventCollection    string
+	SnapshotCollection string
+	Encryption         repository_crypto.Config
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Database == "" {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if cfg.EventCollection == "" {
+		return fmt.Errorf("event collection name cannot be empty")
+	}
+	if cfg.SnapshotCollection == "" {
+		return fmt.Errorf("snapshot collection name cannot be empty")
+	}
+	if err := cfg.Encryption.Validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type eventStore struct {
+	events    *mongo.Collection
+	snapshots *mongo.Collection
+	// cipher encrypts the brief in brief_set events and in snapshots.
+	cipher *repository_crypto.Cipher
+}
+
+func (e *eventStore) GetVersionConflictError() error {
+	return ErrVersionConflict
+}
+
+func (e *eventStore) Append(ctx context.Context, billingId string, expectedVersion int, events []model_billing.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	documents := make([]any, 0, len(events))
+	for i, event := range events {
+		eventDTO := dto.NewEventDTOFromModel(event, expectedVersion+i+1)
+		username, err := e.cipher.Encrypt(eventDTO.Username)
+		if err != nil {
+			return fmt.Errorf("encrypt username: %w", err)
+		}
+		eventDTO.Username = username
+		documents = append(documents, eventDTO)
+	}
+
+	_, err := e.events.InsertMany(ctx, documents)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrVersionConflict
+	}
+	if err != nil {
+		return fmt.Errorf("mongo insert many: %w", err)
+	}
+	return nil
+}
+
+func (e *eventStore) Load(ctx context.Context, billingId string, afterVersion int) ([]model_billing.Event, error) {
+	cursor, err := e.events.Find(ctx,
+		bson.D{
+			{Key: "billing_id", Value: billingId},
+			{Key: "version", Value: bson.D{{Key: "$gt", Value: afterVersion}}},
+		},
+		options.Find().SetSort(bson.D{{Key: "version", Value: 1}}),
+	)
+	if err != nil {
+		return []model_billing.Event{}, fmt.Errorf("mongo find: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []model_billing.Event
+	for cursor.Next(ctx) {
+		var eventDTO dto.Event
+		if err := cursor.Decode(&eventDTO); err != nil {
+			return []model_billing.Event{}, fmt.Errorf("cursor decode: %w", err)
+		}
+		if eventDTO.Version != afterVersion+len(events)+1 {
+			return []model_billing.Event{}, fmt.Errorf("billing %s misses event %d", billingId, afterVersion+len(events)+1)
+		}
+		if eventDTO.Username, err = e.cipher.Decrypt(eventDTO.Username); err != nil {
+			return []model_billing.Event{}, fmt.Errorf("decrypt username: %w", err)
+		}
+		event, err := eventDTO.ToModel()
+		if err != nil {
+			return []model_billing.Event{}, fmt.Errorf("dto to model: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := cursor.Err(); err != nil {
+		return []model_billing.Event{}, fmt.Errorf("cursor error: %w", err)
+	}
+	return events, nil
+}
+
+func (e *eventStore) LoadSnapshot(ctx context.Context, billingId string) (model_billing.Billing, bool, error) {
+	var snapshotDTO dto.Snapshot
+	err := e.snapshots.FindOne(ctx, bson.D{{Key: "_id", Value: billingId}}).Decode(&snapshotDTO)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model_billing.Billing{}, false, nil
+	}
+	if err != nil {
+		return model_billing.Billing{}, false, fmt.Errorf("mongo find one: %w", err)
+	}
+	if snapshotDTO.Billing.Username, err = e.cipher.Decrypt(snapshotDTO.Billing.Username); err != nil {
+		return model_billing.Billing{}, false, fmt.Errorf("decrypt username: %w", err)
+	}
+	billing, err := snapshotDTO.ToModel()
+	if err != nil {
+		return model_billing.Billing{}, false, fmt.Errorf("dto to model: %w", err)
+	}
+	return billing, true, nil
+}
+
+func (e *eventStore) SaveSnapshot(ctx context.Context, billing model_billing.Billing) error {
+	snapshotDTO := dto.NewSnapshotDTOFromModel(billing)
+	username, err := e.cipher.Encrypt(snapshotDTO.Billing.Username)
+	if err != nil {
+		return fmt.Errorf("encrypt username: %w", err)
+	}
+	snapshotDTO.Billing.Username = username
+	_, err = e.snapshots.ReplaceOne(ctx,
+		bson.D{
+			{Key: "_id", Value: snapshotDTO.BillingId},
+			{Key: "version", Value: bson.D{{Key: "$lte", Value: snapshotDTO.Version}}},
+		},
+		snapshotDTO,
+		options.Replace().SetUpsert(true),
+	)
+	// The upsert collides with a newer snapshot of the same billing.
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("mongo replace one: %w", err)
+	}
+	return nil
+}
+
+func (e *eventStore) Erase(ctx context.Context, billingId string, throughVersion int) error {
+	_, err := e.events.UpdateMany(ctx,
+		bson.D{
+			{Key: "billing_id", Value: billingId},
+			{Key: "version", Value: bson.D{{Key: "$lte", Value: throughVersion}}},
+		},
+		mongo.Pipeline{{{Key: "$replaceWith", Value: bson.D{
+			{Key: "_id", Value: "$_id"},
+			{Key: "billing_id", Value: "$billing_id"},
+			{Key: "version", Value: "$version"},
+			{Key: "type", Value: "$type"},
+			{Key: "occurred_at", Value: "$occurred_at"},
+		}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("mongo update many: %w", err)
+	}
+	return nil
+}
+
+func (e *eventStore) GetHeadVersion(ctx context.Context, billingId string) (int, error) {
+	var head dto.Event
+	err := e.events.FindOne(ctx,
+		bson.D{{Key: "billing_id", Value: billingId}},
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.D{{Key: "version", Value: 1}}),
+	).Decode(&head)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("mongo find one: %w", err)
+	}
+	return head.Version, nil
+}
+
+func (e *eventStore) Delete(ctx context.Context, billingId string) error {
+	if _, err := e.events.DeleteMany(ctx, bson.D{{Key: "billing_id", Value: billingId}}); err != nil {
+		return fmt.Errorf("mongo delete many: %w", err)
+	}
+	if _, err := e.snapshots.DeleteOne(ctx, bson.D{{Key: "_id", Value: billingId}}); err != nil {
+		return fmt.Errorf("mongo delete one: %w", err)
+	}
+	return nil
+}
+
+func (e *eventStore) GetBillingIds(ctx context.Context) ([]string, error) {
+	ids := map[string]struct{}{}
+	var result []string
+	for _, field := range []struct {
+		coll *mongo.Collection
+		name string
+	}{
+		{coll: e.events, name: "billing_id"},
+		{coll: e.snapshots, name: "_id"},
+	} {
+		values, err := field.coll.Distinct(ctx, field.name, bson.D{})
+		if err != nil {
+			return []string{}, fmt.Errorf("mongo distinct: %w", err)
+		}
+		for _, value := range values {
+			id, ok := value.(string)
+			if _, seen := ids[id]; !ok || seen {
+				continue
+			}
+			ids[id] = struct{}{}
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+// New expects the collections and the unique index on billing id and
+// version to be created by mongo_migration.
+func New(ctx context.Context, client *mongo.Client, cfg Config) (*eventStore, error) {
+	if err := cfg.Validate(); err != nil {
+		return &eventStore{}, fmt.Errorf("config validate: %w", err)
+	}
+	cipher, err := repository_crypto.New(cfg.Encryption)
+	if err != nil {
+		return &eventStore{}, fmt.Errorf("create cipher: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		return &eventStore{}, fmt.Errorf("mongo ping: %w", err)
+	}
+	db := client.Database(cfg.Database)
+	return &eventStore{
+		events:    db.Collection(cfg.EventCollection),
+		snapshots: db.Collection(cfg.SnapshotCollection),
+		cipher:    cipher,
+	}, nil
+}
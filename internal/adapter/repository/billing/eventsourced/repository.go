@@ -0,0 +1,301 @@
+package eventsourced_billing_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/billing/eventsourced/dto"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/rs/zerolog"
+)
+
+var _ usecase.BillingRepository = &billingRepository{}
+
+var (
+	ErrNoData = errors.New("no data")
+	// ErrVersionConflict is returned by Update when the billing got new
+	// events after it was loaded.
+	ErrVersionConflict = errors.New("billing was changed concurrently")
+)
+
+// EventStore keeps the append-only event streams of billings together
+// with their snapshots.
+type EventStore interface {
+	// Append stores events as the versions following expectedVersion, it
+	// fails with the error of GetVersionConflictError when the stream is
+	// already past expectedVersion.
+	Append(ctx context.Context, billingId string, expectedVersion int, events []model_billing.Event) error
+	// Load returns the events stored after the version, oldest first.
+	Load(ctx context.Context, billingId string, afterVersion int) ([]model_billing.Event, error)
+	// LoadSnapshot returns the latest snapshot, false when there is none.
+	LoadSnapshot(ctx context.Context, billingId string) (model_billing.Billing, bool, error)
+	// SaveSnapshot keeps an older snapshot when it is newer than the billing.
+	SaveSnapshot(ctx context.Context, billing model_billing.Billing) error
+	// Erase drops the payload of the events up to the version. They stay in
+	// the stream as tombstones, so appends of writers that loaded an older
+	// version still conflict, but are never loaded again.
+	Erase(ctx context.Context, billingId string, throughVersion int) error
+	// GetHeadVersion returns the version of the last stored event, zero
+	// when the stream is empty.
+	GetHeadVersion(ctx context.Context, billingId string) (int, error)
+	// Delete removes the stream and the snapshot of the billing.
+	Delete(ctx context.Context, billingId string) error
+	GetBillingIds(ctx context.Context) ([]string, error)
+	GetVersionConflictError() error
+}
+
+type Config struct {
+	// SnapshotInterval is the number of events between two snapshots.
+	SnapshotInterval int
+}
+
+func (cfg Config) Validate() error {
+	if cfg.SnapshotInterval < 1 {
+		return fmt.Errorf("snapshot interval must be at least 1")
+	}
+	return nil
+}
+
+// billingRepository rebuilds billings from their events. Every stored
+// change is projected into the read model, which serves the queries over
+// many billings.
+type billingRepository struct {
+	logger    zerolog.Logger
+	events    EventStore
+	readModel usecase.BillingRepository
+	cfg       Config
+}
+
+func (b *billingRepository) GetNoDataError() error {
+	return ErrNoData
+}
+
+func (b *billingRepository) GetConflictError() error {
+	return ErrVersionConflict
+}
+
+func (b *billingRepository) GetAll(ctx context.Context) ([]model_billing.Billing, error) {
+	return b.readModel.GetAll(ctx)
+}
+
+func (b *billingRepository) GetByUserId(ctx context.Context, userId string) ([]model_billing.Billing, error) {
+	return b.readModel.GetByUserId(ctx, userId)
+}
+
+func (b *billingRepository) GetAllDeleted(ctx context.Context) ([]model_billing.Billing, error) {
+	return b.readModel.GetAllDeleted(ctx)
+}
+
+func (b *billingRepository) Get(ctx context.Context, id string) (model_billing.Billing, error) {
+	billing, err := b.load(ctx, id)
+	if err != nil {
+		return model_billing.Billing{}, err
+	}
+	if billing.IsDeleted() {
+		return model_billing.Billing{}, ErrNoData
+	}
+	return billing, nil
+}
+
+func (b *billingRepository) GetDeleted(ctx context.Context, id string) (model_billing.Billing, error) {
+	billing, err := b.load(ctx, id)
+	if err != nil {
+		return model_billing.Billing{}, err
+	}
+	if !billing.IsDeleted() {
+		return model_billing.Billing{}, ErrNoData
+	}
+	return billing, nil
+}
+
+func (b *billingRepository) load(ctx context.Context, id string) (model_billing.Billing, error) {
+	billing, found, err := b.events.LoadSnapshot(ctx, id)
+	if err != nil {
+		return model_billing.Billing{}, fmt.Errorf("load snapshot: %w", err)
+	}
+	events, err := b.events.Load(ctx, id, billing.GetVersion())
+	if err != nil {
+		return model_billing.Billing{}, fmt.Errorf("load events: %w", err)
+	}
+
+	if found {
+		if err = billing.Replay(events); err != nil {
+			return model_billing.Billing{}, fmt.Errorf("replay events: %w", err)
+		}
+		return billing, nil
+	}
+	if len(events) != 0 {
+		billing, err = model_billing.FromEvents(events)
+		if err != nil {
+			return model_billing.Billing{}, fmt.Errorf("billing from events: %w", err)
+		}
+		return billing, nil
+	}
+	return b.seed(ctx, id)
+}
+
+// seed starts the stream of a billing stored before event sourcing was
+// turned on with a snapshot of its read model.
+func (b *billingRepository) seed(ctx context.Context, id string) (model_billing.Billing, error) {
+	billing, err := b.readModel.Get(ctx, id)
+	if errors.Is(b.readModel.GetNoDataError(), err) {
+		billing, err = b.readModel.GetDeleted(ctx, id)
+	}
+	if errors.Is(b.readModel.GetNoDataError(), err) {
+		return model_billing.Billing{}, ErrNoData
+	}
+	if err != nil {
+		return model_billing.Billing{}, fmt.Errorf("get from read model: %w", err)
+	}
+	if err = b.events.SaveSnapshot(ctx, billing); err != nil {
+		return model_billing.Billing{}, fmt.Errorf("save snapshot: %w", err)
+	}
+	b.logger.Info().Str("BillingId", id).Msg("Billing stream seeded from read model")
+	return billing, nil
+}
+
+func (b *billingRepository) Create(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
+	billing, err := b.store(ctx, billing)
+	if err != nil {
+		return model_billing.Billing{}, err
+	}
+	if _, err = b.readModel.Create(ctx, billing); err != nil {
+		return model_billing.Billing{}, fmt.Errorf("project into read model: %w", err)
+	}
+	return billing, nil
+}
+
+func (b *billingRepository) Update(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
+	billing, err := b.store(ctx, billing)
+	if err != nil {
+		return model_billing.Billing{}, err
+	}
+	_, err = b.readModel.Update(ctx, billing)
+	if errors.Is(b.readModel.GetNoDataError(), err) {
+		return model_billing.Billing{}, ErrNoData
+	}
+	if err != nil {
+		return model_billing.Billing{}, fmt.Errorf("project into read model: %w", err)
+	}
+	return billing, nil
+}
+
+// store appends the changes recorded by the billing to its stream. A billing
+// without events, e.g. an imported one, replaces the stream with a snapshot
+// at the head of the stream.
+func (b *billingRepository) store(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
+	changes := billing.GetChanges()
+	if len(changes) == 0 && billing.GetVersion() == 0 {
+		head, err := b.events.GetHeadVersion(ctx, billing.Id)
+		if err != nil {
+			return model_billing.Billing{}, fmt.Errorf("get head version: %w", err)
+		}
+		snapshotDTO := dto.NewSnapshotDTOFromModel(billing)
+		snapshotDTO.Version = head
+		if billing, err = snapshotDTO.ToModel(); err != nil {
+			return model_billing.Billing{}, fmt.Errorf("dto to model: %w", err)
+		}
+		if err = b.reset(ctx, billing); err != nil {
+			return model_billing.Billing{}, err
+		}
+		return billing, nil
+	}
+
+	previousVersion := billing.GetVersion()
+	err := b.events.Append(ctx, billing.Id, previousVersion, changes)
+	if errors.Is(b.events.GetVersionConflictError(), err) {
+		return model_billing.Billing{}, ErrVersionConflict
+	}
+	if err != nil {
+		return model_billing.Billing{}, fmt.Errorf("append events: %w", err)
+	}
+	billing.CommitChanges()
+
+	// Older events still hold the personal data an anonymised billing
+	// dropped, the stream restarts from a snapshot taken after it.
+	if slices.ContainsFunc(changes, func(event model_billing.Event) bool {
+		return event.Type == model_billing.EventTypeAnonymized
+	}) {
+		if err = b.reset(ctx, billing); err != nil {
+			return model_billing.Billing{}, err
+		}
+		return billing, nil
+	}
+
+	if billing.GetVersion()/b.cfg.SnapshotInterval != previousVersion/b.cfg.SnapshotInterval {
+		if err = b.events.SaveSnapshot(ctx, billing); err != nil {
+			return model_billing.Billing{}, fmt.Errorf("save snapshot: %w", err)
+		}
+	}
+	return billing, nil
+}
+
+// Delete checks the deletion time against the read model, which is written
+// together with the stream.
+// reset makes the billing the start of its stream. Storages without
+// transactions run the steps one by one: the snapshot goes first, so the
+// billing can always be loaded, and the erased events keep their versions,
+// so a writer that loaded the billing before still gets a version conflict.
+func (b *billingRepository) reset(ctx context.Context, billing model_billing.Billing) error {
+	if err := b.events.SaveSnapshot(ctx, billing); err != nil {
+		return fmt.Errorf("save snapshot: %w", err)
+	}
+	if err := b.events.Erase(ctx, billing.Id, billing.GetVersion()); err != nil {
+		return fmt.Errorf("erase events: %w", err)
+	}
+	return nil
+}
+
+func (b *billingRepository) Delete(ctx context.Context, id string, deletedBefore time.Time) (model_billing.Billing, error) {
+	billing, err := b.readModel.Delete(ctx, id, deletedBefore)
+	if errors.Is(b.readModel.GetNoDataError(), err) {
+		return model_billing.Billing{}, ErrNoData
+	}
+	if err != nil {
+		return model_billing.Billing{}, fmt.Errorf("delete from read model: %w", err)
+	}
+	if err = b.events.Delete(ctx, id); err != nil {
+		return model_billing.Billing{}, fmt.Errorf("delete stream: %w", err)
+	}
+	return billing, nil
+}
+
+// RebuildProjection writes every billing rebuilt from its events into the
+// read model, e.g. after the read model was lost or a projection failed.
+func (b *billingRepository) RebuildProjection(ctx context.Context) (int, error) {
+	ids, err := b.events.GetBillingIds(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("get billing ids: %w", err)
+	}
+
+	for _, id := range ids {
+		billing, err := b.load(ctx, id)
+		if err != nil {
+			return 0, fmt.Errorf("get billing %s: %w", id, err)
+		}
+		_, err = b.readModel.Update(ctx, billing)
+		if errors.Is(b.readModel.GetNoDataError(), err) {
+			_, err = b.readModel.Create(ctx, billing)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("project billing %s: %w", id, err)
+		}
+	}
+	return len(ids), nil
+}
+
+func New(logger zerolog.Logger, events EventStore, readModel usecase.BillingRepository, cfg Config) (*billingRepository, error) {
+	if err := cfg.Validate(); err != nil {
+		return &billingRepository{}, fmt.Errorf("config validate: %w", err)
+	}
+	return &billingRepository{
+		logger:    logger.With().Str("Component", "eventsourced_billing_repository").Logger(),
+		events:    events,
+		readModel: readModel,
+		cfg:       cfg,
+	}, nil
+}
@@ -0,0 +1,148 @@
+package eventsourced_billing_repository_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	sqlite_migration "github.com/ThePositree/billing_manager/internal/adapter/migration/sqlite"
+	eventsourced_billing_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/eventsourced"
+	sql_billing_event_store "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/eventsourced/sql"
+	sqlite_billing_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/sqlite"
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	sqlite_user_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/user/sqlite"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+type fixture struct {
+	db        *sql.DB
+	repo      usecase.BillingRepository
+	readModel usecase.BillingRepository
+	userId    string
+}
+
+// newFixture stores billings in a migrated sqlite database, the calls run
+// without a transaction like on a standalone mongod.
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "billing_manager.db")
+	db, err := sql.Open("sqlite", sql_repository.SQLiteDSN(path))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := sqlite_migration.New(zerolog.Nop(), db)
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx, 0)
+	require.NoError(t, err)
+
+	userRepo, err := sqlite_user_repository.New(ctx, zerolog.Nop(), db)
+	require.NoError(t, err)
+	user, err := userRepo.Create(ctx, model_user.New("alice"))
+	require.NoError(t, err)
+
+	readModel, err := sqlite_billing_repository.New(ctx, zerolog.Nop(), db)
+	require.NoError(t, err)
+	events, err := sql_billing_event_store.New(ctx, db, sql_billing_event_store.DialectSQLite)
+	require.NoError(t, err)
+	repo, err := eventsourced_billing_repository.New(zerolog.Nop(), events, readModel, eventsourced_billing_repository.Config{SnapshotInterval: 50})
+	require.NoError(t, err)
+	return fixture{db: db, repo: repo, readModel: readModel, userId: user.Id}
+}
+
+func (f fixture) create(t *testing.T) model_billing.Billing {
+	t.Helper()
+	ctx := context.Background()
+	billing, err := model_billing.New(f.userId)
+	require.NoError(t, err)
+	_, err = billing.SetBriefInfo("alice_brief")
+	require.NoError(t, err)
+	billing, err = f.repo.Create(ctx, billing)
+	require.NoError(t, err)
+	return billing
+}
+
+func (f fixture) countEvents(t *testing.T, billingId string, query string) int {
+	t.Helper()
+	var count int
+	require.NoError(t, f.db.QueryRow("SELECT COUNT(*) FROM billing_events WHERE billing_id = ? AND "+query, billingId).Scan(&count))
+	return count
+}
+
+func TestAnonymizeKeepsVersions(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	created := f.create(t)
+
+	stale, err := f.repo.Get(ctx, created.Id)
+	require.NoError(t, err)
+
+	billing, err := f.repo.Get(ctx, created.Id)
+	require.NoError(t, err)
+	billing.Anonymize()
+	billing, err = f.repo.Update(ctx, billing)
+	require.NoError(t, err)
+	require.Zero(t, f.countEvents(t, billing.Id, "data LIKE '%alice_brief%'"))
+	require.Equal(t, billing.GetVersion(), f.countEvents(t, billing.Id, "data = '{}'"))
+
+	// A writer that loaded the billing before it was anonymised must not
+	// bring the erased brief back.
+	_, err = stale.SetBriefInfo("alice_brief")
+	require.NoError(t, err)
+	_, err = f.repo.Update(ctx, stale)
+	require.ErrorIs(t, err, eventsourced_billing_repository.ErrVersionConflict)
+
+	got, err := f.repo.Get(ctx, billing.Id)
+	require.NoError(t, err)
+	require.Empty(t, got.GetBriefInfo().Username)
+	require.Equal(t, billing.GetVersion(), got.GetVersion())
+
+	// Changes continue the stream after the snapshot.
+	require.NoError(t, got.NextState())
+	_, err = f.repo.Update(ctx, got)
+	require.NoError(t, err)
+	got, err = f.repo.Get(ctx, billing.Id)
+	require.NoError(t, err)
+	require.Equal(t, model_billing.StateDesign, got.GetState())
+	require.Equal(t, billing.GetVersion()+1, got.GetVersion())
+}
+
+func TestImportReplacesStream(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	created := f.create(t)
+
+	// The read model has no events, like a billing read from an export.
+	imported, err := f.readModel.Get(ctx, created.Id)
+	require.NoError(t, err)
+	require.Zero(t, imported.GetVersion())
+	require.Empty(t, imported.GetChanges())
+
+	stale, err := f.repo.Get(ctx, created.Id)
+	require.NoError(t, err)
+	billing, err := f.repo.Get(ctx, created.Id)
+	require.NoError(t, err)
+	require.NoError(t, billing.NextState())
+	billing, err = f.repo.Update(ctx, billing)
+	require.NoError(t, err)
+
+	_, err = f.repo.Update(ctx, imported)
+	require.NoError(t, err)
+	got, err := f.repo.Get(ctx, created.Id)
+	require.NoError(t, err)
+	require.Equal(t, model_billing.StatePending, got.GetState())
+	// The snapshot is taken at the head, the replaced events keep their versions.
+	require.Equal(t, billing.GetVersion(), got.GetVersion())
+	require.Equal(t, billing.GetVersion(), f.countEvents(t, created.Id, "data = '{}'"))
+
+	_, err = stale.SetBriefInfo("bob_brief")
+	require.NoError(t, err)
+	_, err = f.repo.Update(ctx, stale)
+	require.ErrorIs(t, err, eventsourced_billing_repository.ErrVersionConflict)
+}
@@ -0,0 +1,237 @@
+package sql_billing_event_store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/billing/eventsourced"
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/billing/eventsourced/dto"
+	sql_transaction "github.com/ThePositree/billing_manager/internal/adapter/transaction/sql"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var _ eventsourced_billing_repository.EventStore = &eventStore{}
+
+// ErrVersionConflict is reported by the primary key on billing id and
+// version of the billing_events table.
+var ErrVersionConflict = errors.New("version conflict")
+
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// placeholder returns the n-th query parameter, counting from one.
+func (d Dialect) placeholder(n int) string {
+	if d == DialectPostgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// isDuplicate reports whether err is a primary key violation.
+func (d Dialect) isDuplicate(err error) bool {
+	if d == DialectPostgres {
+		var pgErr *pgconn.PgError
+		return errors.As(err, &pgErr) && pgErr.Code == "23505"
+	}
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+type eventStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func (e *eventStore) GetVersionConflictError() error {
+	return ErrVersionConflict
+}
+
+func (e *eventStore) Append(ctx context.Context, billingId string, expectedVersion int, events []model_billing.Event) error {
+	query := fmt.Sprintf("INSERT INTO billing_events (billing_id, version, type, data) VALUES (%s, %s, %s, %s)",
+		e.dialect.placeholder(1), e.dialect.placeholder(2), e.dialect.placeholder(3), e.dialect.placeholder(4))
+	for i, event := range events {
+		eventDTO := dto.NewEventDTOFromModel(event, expectedVersion+i+1)
+		data, err := json.Marshal(eventDTO)
+		if err != nil {
+			return fmt.Errorf("json marshal: %w", err)
+		}
+		_, err = sql_transaction.GetExecutor(ctx, e.db).ExecContext(ctx, query,
+			billingId, eventDTO.Version, eventDTO.Type, data,
+		)
+		if e.dialect.isDuplicate(err) {
+			return ErrVersionConflict
+		}
+		if err != nil {
+			return fmt.Errorf("sql insert: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *eventStore) Load(ctx context.Context, billingId string, afterVersion int) ([]model_billing.Event, error) {
+	rows, err := sql_transaction.GetExecutor(ctx, e.db).QueryContext(ctx,
+		fmt.Sprintf("SELECT version, data FROM billing_events WHERE billing_id = %s AND version > %s ORDER BY version",
+			e.dialect.placeholder(1), e.dialect.placeholder(2)),
+		billingId, afterVersion,
+	)
+	if err != nil {
+		return []model_billing.Event{}, fmt.Errorf("sql query: %w", err)
+	}
+	defer rows.Close()
+
+	var events []model_billing.Event
+	for rows.Next() {
+		var (
+			version int
+			data    []byte
+		)
+		if err := rows.Scan(&version, &data); err != nil {
+			return []model_billing.Event{}, fmt.Errorf("row scan: %w", err)
+		}
+		if version != afterVersion+len(events)+1 {
+			return []model_billing.Event{}, fmt.Errorf("billing %s misses event %d", billingId, afterVersion+len(events)+1)
+		}
+		var eventDTO dto.Event
+		if err := json.Unmarshal(data, &eventDTO); err != nil {
+			return []model_billing.Event{}, fmt.Errorf("json unmarshal: %w", err)
+		}
+		event, err := eventDTO.ToModel()
+		if err != nil {
+			return []model_billing.Event{}, fmt.Errorf("dto to model: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return []model_billing.Event{}, fmt.Errorf("rows error: %w", err)
+	}
+	return events, nil
+}
+
+func (e *eventStore) LoadSnapshot(ctx context.Context, billingId string) (model_billing.Billing, bool, error) {
+	var data []byte
+	err := sql_transaction.GetExecutor(ctx, e.db).QueryRowContext(ctx,
+		"SELECT data FROM billing_snapshots WHERE billing_id = "+e.dialect.placeholder(1),
+		billingId,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model_billing.Billing{}, false, nil
+	}
+	if err != nil {
+		return model_billing.Billing{}, false, fmt.Errorf("row scan: %w", err)
+	}
+
+	var snapshotDTO dto.Snapshot
+	if err = json.Unmarshal(data, &snapshotDTO); err != nil {
+		return model_billing.Billing{}, false, fmt.Errorf("json unmarshal: %w", err)
+	}
+	billing, err := snapshotDTO.ToModel()
+	if err != nil {
+		return model_billing.Billing{}, false, fmt.Errorf("dto to model: %w", err)
+	}
+	return billing, true, nil
+}
+
+func (e *eventStore) SaveSnapshot(ctx context.Context, billing model_billing.Billing) error {
+	snapshotDTO := dto.NewSnapshotDTOFromModel(billing)
+	data, err := json.Marshal(snapshotDTO)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+
+	_, err = sql_transaction.GetExecutor(ctx, e.db).ExecContext(ctx,
+		fmt.Sprintf(`INSERT INTO billing_snapshots (billing_id, version, data) VALUES (%s, %s, %s)
+ON CONFLICT (billing_id) DO UPDATE SET version = excluded.version, data = excluded.data
+WHERE billing_snapshots.version <= excluded.version`,
+			e.dialect.placeholder(1), e.dialect.placeholder(2), e.dialect.placeholder(3)),
+		snapshotDTO.BillingId, snapshotDTO.Version, data,
+	)
+	if err != nil {
+		return fmt.Errorf("sql upsert: %w", err)
+	}
+	return nil
+}
+
+func (e *eventStore) Erase(ctx context.Context, billingId string, throughVersion int) error {
+	_, err := sql_transaction.GetExecutor(ctx, e.db).ExecContext(ctx,
+		fmt.Sprintf("UPDATE billing_events SET data = '{}' WHERE billing_id = %s AND version <= %s",
+			e.dialect.placeholder(1), e.dialect.placeholder(2)),
+		billingId, throughVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("sql update: %w", err)
+	}
+	return nil
+}
+
+func (e *eventStore) GetHeadVersion(ctx context.Context, billingId string) (int, error) {
+	var version int
+	err := sql_transaction.GetExecutor(ctx, e.db).QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(version), 0) FROM billing_events WHERE billing_id = "+e.dialect.placeholder(1),
+		billingId,
+	).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("row scan: %w", err)
+	}
+	return version, nil
+}
+
+func (e *eventStore) Delete(ctx context.Context, billingId string) error {
+	for _, table := range []string{"billing_events", "billing_snapshots"} {
+		_, err := sql_transaction.GetExecutor(ctx, e.db).ExecContext(ctx,
+			"DELETE FROM "+table+" WHERE billing_id = "+e.dialect.placeholder(1),
+			billingId,
+		)
+		if err != nil {
+			return fmt.Errorf("sql delete from %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func (e *eventStore) GetBillingIds(ctx context.Context) ([]string, error) {
+	rows, err := sql_transaction.GetExecutor(ctx, e.db).QueryContext(ctx,
+		"SELECT billing_id FROM billing_events UNION SELECT billing_id FROM billing_snapshots ORDER BY billing_id",
+	)
+	if err != nil {
+		return []string{}, fmt.Errorf("sql query: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return []string{}, fmt.Errorf("row scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return []string{}, fmt.Errorf("rows error: %w", err)
+	}
+	return ids, nil
+}
+
+// New serves both the postgres and the sqlite storage, the tables are
+// created by their migrations.
+func New(ctx context.Context, db *sql.DB, dialect Dialect) (*eventStore, error) {
+	switch dialect {
+	case DialectPostgres, DialectSQLite:
+	default:
+		return &eventStore{}, fmt.Errorf("unknown sql dialect %q", dialect)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return &eventStore{}, fmt.Errorf("sql ping: %w", err)
+	}
+	return &eventStore{db: db, dialect: dialect}, nil
+}
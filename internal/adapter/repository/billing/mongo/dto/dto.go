@@ -1,21 +1,153 @@
 package dto
 
 import (
+	"fmt"
+	"time"
+
+	repository_crypto "github.com/ThePositree/billing_manager/internal/adapter/repository/crypto"
 	"github.com/ThePositree/billing_manager/internal/model/billing"
 )
 
+type Revision struct {
+	Stage       string    `bson:"stage"`
+	RequestedBy string    `bson:"requested_by"`
+	Note        string    `bson:"note"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+func (r Revision) GetStage() string {
+	return r.Stage
+}
+
+func (r Revision) GetRequestedBy() string {
+	return r.RequestedBy
+}
+
+func (r Revision) GetNote() string {
+	return r.Note
+}
+
+func (r Revision) GetCreatedAt() time.Time {
+	return r.CreatedAt
+}
+
+type LineItem struct {
+	Description string `bson:"description"`
+	Amount      int64  `bson:"amount"`
+}
+
+func (l LineItem) GetDescription() string {
+	return l.Description
+}
+
+func (l LineItem) GetAmount() int64 {
+	return l.Amount
+}
+
+type StateChange struct {
+	State     string    `bson:"state"`
+	EnteredAt time.Time `bson:"entered_at"`
+}
+
+func (s StateChange) GetState() string {
+	return s.State
+}
+
+func (s StateChange) GetEnteredAt() time.Time {
+	return s.EnteredAt
+}
+
+type TimeEntry struct {
+	Id        string    `bson:"id"`
+	StaffId   string    `bson:"staff_id"`
+	Stage     string    `bson:"stage"`
+	StartedAt time.Time `bson:"started_at"`
+	EndedAt   time.Time `bson:"ended_at"`
+	Note      string    `bson:"note"`
+}
+
+func (e TimeEntry) GetId() string {
+	return e.Id
+}
+
+func (e TimeEntry) GetStaffId() string {
+	return e.StaffId
+}
+
+func (e TimeEntry) GetStage() string {
+	return e.Stage
+}
+
+func (e TimeEntry) GetStartedAt() time.Time {
+	return e.StartedAt
+}
+
+func (e TimeEntry) GetEndedAt() time.Time {
+	return e.EndedAt
+}
+
+func (e TimeEntry) GetNote() string {
+	return e.Note
+}
+
+type Payment struct {
+	Id     string    `bson:"id"`
+	Amount int64     `bson:"amount"`
+	PaidAt time.Time `bson:"paid_at"`
+	Note   string    `bson:"note"`
+}
+
+func (p Payment) GetId() string {
+	return p.Id
+}
+
+func (p Payment) GetAmount() int64 {
+	return p.Amount
+}
+
+func (p Payment) GetPaidAt() time.Time {
+	return p.PaidAt
+}
+
+func (p Payment) GetNote() string {
+	return p.Note
+}
+
 type Billing struct {
-	Id       string `bson:"_id"`
-	UserId   string `bson:"user_id"`
-	State    string `bson:"state"`
-	Username string `bson:"username"`
+	Id                string                   `bson:"_id"`
+	UserId            string                   `bson:"user_id"`
+	State             string                   `bson:"state"`
+	Username          string                   `bson:"username"`
+	Revisions         []Revision               `bson:"revisions"`
+	IncludedRevisions int                      `bson:"included_revisions"`
+	RevisionPolicy    string                   `bson:"revision_policy"`
+	RevisionSurcharge int64                    `bson:"revision_surcharge"`
+	LineItems         []LineItem               `bson:"line_items"`
+	CreatedAt         time.Time                `bson:"created_at"`
+	StateChanges      []StateChange            `bson:"state_changes"`
+	Deadline          time.Time                `bson:"deadline"`
+	StageTargets      map[string]time.Duration `bson:"stage_targets"`
+	Priority          string                   `bson:"priority"`
+	Assignees         map[string]string        `bson:"assignees"`
+	TimeEntries       []TimeEntry              `bson:"time_entries"`
+	Price             int64                    `bson:"price"`
+	InvoicedAt        time.Time                `bson:"invoiced_at"`
+	Payments          []Payment                `bson:"payments"`
+	DeletedAt         *time.Time               `bson:"deleted_at,omitempty"`
+	DeletedBy         string                   `bson:"deleted_by,omitempty"`
 }
 
 func (u Billing) GetUsername() string {
 	return u.Username
 }
 
-func (u Billing) ToModel() (billing.Billing, error) {
+// ToModel decrypts the brief, it is stored encrypted when encryption is on.
+func (u Billing) ToModel(cipher *repository_crypto.Cipher) (billing.Billing, error) {
+	username, err := cipher.Decrypt(u.Username)
+	if err != nil {
+		return billing.Billing{}, fmt.Errorf("username: %w", err)
+	}
+	u.Username = username
 	return billing.ToModelFromDTO(u)
 }
 
@@ -31,11 +163,183 @@ func (u Billing) GetState() string {
 	return u.State
 }
 
-func NewBillingDTOFromModel(billing billing.Billing) Billing {
-	return Billing{
-		Id:       billing.Id,
-		UserId:   billing.UserId,
-		State:    billing.GetState().String(),
-		Username: billing.GetBriefInfo().Username,
+func (u Billing) GetRevisions() []billing.RevisionDTO {
+	var result []billing.RevisionDTO
+	for _, revision := range u.Revisions {
+		result = append(result, revision)
 	}
+	return result
+}
+
+func (u Billing) GetIncludedRevisions() int {
+	return u.IncludedRevisions
+}
+
+func (u Billing) GetRevisionPolicy() string {
+	return u.RevisionPolicy
+}
+
+func (u Billing) GetRevisionSurcharge() int64 {
+	return u.RevisionSurcharge
+}
+
+func (u Billing) GetLineItems() []billing.LineItemDTO {
+	var result []billing.LineItemDTO
+	for _, lineItem := range u.LineItems {
+		result = append(result, lineItem)
+	}
+	return result
+}
+
+func (u Billing) GetCreatedAt() time.Time {
+	return u.CreatedAt
+}
+
+func (u Billing) GetStateChanges() []billing.StateChangeDTO {
+	var result []billing.StateChangeDTO
+	for _, stateChange := range u.StateChanges {
+		result = append(result, stateChange)
+	}
+	return result
+}
+
+func (u Billing) GetDeadline() time.Time {
+	return u.Deadline
+}
+
+func (u Billing) GetStageTargets() map[string]time.Duration {
+	return u.StageTargets
+}
+
+func (u Billing) GetPriority() string {
+	return u.Priority
+}
+
+func (u Billing) GetAssignees() map[string]string {
+	return u.Assignees
+}
+
+func (u Billing) GetTimeEntries() []billing.TimeEntryDTO {
+	var result []billing.TimeEntryDTO
+	for _, timeEntry := range u.TimeEntries {
+		result = append(result, timeEntry)
+	}
+	return result
+}
+
+func (u Billing) GetPrice() int64 {
+	return u.Price
+}
+
+func (u Billing) GetInvoicedAt() time.Time {
+	return u.InvoicedAt
+}
+
+func (u Billing) GetDeletedAt() time.Time {
+	if u.DeletedAt == nil {
+		return time.Time{}
+	}
+	return *u.DeletedAt
+}
+
+func (u Billing) GetDeletedBy() string {
+	return u.DeletedBy
+}
+
+func (u Billing) GetPayments() []billing.PaymentDTO {
+	var result []billing.PaymentDTO
+	for _, payment := range u.Payments {
+		result = append(result, payment)
+	}
+	return result
+}
+
+func NewTimeEntryDTOFromModel(timeEntry billing.TimeEntry) TimeEntry {
+	return TimeEntry{
+		Id:        timeEntry.Id,
+		StaffId:   timeEntry.StaffId,
+		Stage:     timeEntry.Stage.String(),
+		StartedAt: timeEntry.StartedAt,
+		EndedAt:   timeEntry.EndedAt,
+		Note:      timeEntry.Note,
+	}
+}
+
+func NewBillingDTOFromModel(billing billing.Billing, cipher *repository_crypto.Cipher) (Billing, error) {
+	username, err := cipher.Encrypt(billing.GetBriefInfo().Username)
+	if err != nil {
+		return Billing{}, fmt.Errorf("username: %w", err)
+	}
+	var revisions []Revision
+	for _, revision := range billing.GetRevisions() {
+		revisions = append(revisions, Revision{
+			Stage:       revision.Stage.String(),
+			RequestedBy: revision.RequestedBy,
+			Note:        revision.Note,
+			CreatedAt:   revision.CreatedAt,
+		})
+	}
+	var lineItems []LineItem
+	for _, lineItem := range billing.GetLineItems() {
+		lineItems = append(lineItems, LineItem{
+			Description: lineItem.Description,
+			Amount:      lineItem.Amount,
+		})
+	}
+	var stateChanges []StateChange
+	for _, stateChange := range billing.GetStateChanges() {
+		stateChanges = append(stateChanges, StateChange{
+			State:     stateChange.State.String(),
+			EnteredAt: stateChange.EnteredAt,
+		})
+	}
+	stageTargets := map[string]time.Duration{}
+	for stage, target := range billing.GetStageTargets() {
+		stageTargets[stage.String()] = target
+	}
+	assignees := map[string]string{}
+	for stage, staffId := range billing.GetAssignees() {
+		assignees[stage.String()] = staffId
+	}
+	var timeEntries []TimeEntry
+	for _, timeEntry := range billing.GetTimeEntries() {
+		timeEntries = append(timeEntries, NewTimeEntryDTOFromModel(timeEntry))
+	}
+	var payments []Payment
+	for _, payment := range billing.GetPayments() {
+		payments = append(payments, Payment{
+			Id:     payment.Id,
+			Amount: payment.Amount,
+			PaidAt: payment.PaidAt,
+			Note:   payment.Note,
+		})
+	}
+	revisionLimit := billing.GetRevisionLimit()
+	var deletedAt *time.Time
+	if billingDeletedAt := billing.GetDeletedAt(); !billingDeletedAt.IsZero() {
+		deletedAt = &billingDeletedAt
+	}
+	return Billing{
+		Id:                billing.Id,
+		UserId:            billing.UserId,
+		State:             billing.GetState().String(),
+		Username:          username,
+		Revisions:         revisions,
+		IncludedRevisions: revisionLimit.Included,
+		RevisionPolicy:    revisionLimit.Policy.String(),
+		RevisionSurcharge: revisionLimit.Surcharge,
+		LineItems:         lineItems,
+		CreatedAt:         billing.GetCreatedAt(),
+		StateChanges:      stateChanges,
+		Deadline:          billing.GetDeadline(),
+		StageTargets:      stageTargets,
+		Priority:          billing.GetPriority().String(),
+		Assignees:         assignees,
+		TimeEntries:       timeEntries,
+		Price:             billing.GetPrice(),
+		InvoicedAt:        billing.GetInvoicedAt(),
+		Payments:          payments,
+		DeletedAt:         deletedAt,
+		DeletedBy:         billing.GetDeletedBy(),
+	}, nil
 }
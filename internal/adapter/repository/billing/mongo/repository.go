@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sync"
+	"time"
 
 	"github.com/ThePositree/billing_manager/internal/adapter/repository/billing/mongo/dto"
+	repository_cache "github.com/ThePositree/billing_manager/internal/adapter/repository/cache"
+	repository_crypto "github.com/ThePositree/billing_manager/internal/adapter/repository/crypto"
 	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
 	"github.com/ThePositree/billing_manager/internal/usecase"
 	"github.com/rs/zerolog"
@@ -14,11 +16,23 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var ErrNoData = errors.New("no data")
+var (
+	ErrNoData = errors.New("no data")
+	// ErrConflict is never returned, Update overwrites the stored billing.
+	ErrConflict = errors.New("billing was changed concurrently")
+)
+
+var (
+	// live matches the billings that are not soft deleted.
+	live    = bson.E{Key: "deleted_at", Value: nil}
+	deleted = bson.E{Key: "deleted_at", Value: bson.D{{Key: "$ne", Value: nil}}}
+)
 
 type Config struct {
 	Database   string
 	Collection string
+	Cache      repository_cache.Config
+	Encryption repository_crypto.Config
 }
 
 func (cfg Config) Validate() error {
@@ -28,6 +42,12 @@ func (cfg Config) Validate() error {
 	if cfg.Collection == "" {
 		return fmt.Errorf("collection name cannot be empty")
 	}
+	if err := cfg.Cache.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.Encryption.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -36,59 +56,69 @@ var _ usecase.BillingRepository = &billingRepository{}
 type billingRepository struct {
 	coll   *mongo.Collection
 	client *mongo.Client
-	mutex  sync.RWMutex
-	cache  map[string]model_billing.Billing
+	cache  *repository_cache.Cache[model_billing.Billing]
+	cipher *repository_crypto.Cipher
 }
 
 func (u *billingRepository) GetNoDataError() error {
 	return ErrNoData
 }
 
+func (u *billingRepository) GetConflictError() error {
+	return ErrConflict
+}
+
 func (u *billingRepository) GetByUserId(ctx context.Context, userId string) ([]model_billing.Billing, error) {
-	var result []model_billing.Billing
-	u.mutex.RLock()
-	for _, billing := range u.cache {
-		if billing.UserId == userId {
-			result = append(result, billing)
-		}
+	if u.cache.Enabled(ctx) {
+		return u.cache.Filter(func(billing model_billing.Billing) bool {
+			return billing.UserId == userId && !billing.IsDeleted()
+		}), nil
 	}
-	u.mutex.RUnlock()
-	return result, nil
+	return u.find(ctx, bson.D{{Key: "user_id", Value: userId}, live})
 }
 
 func (u *billingRepository) Update(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
-	billingDto := dto.NewBillingDTOFromModel(billing)
+	billingDto, err := dto.NewBillingDTOFromModel(billing, u.cipher)
+	if err != nil {
+		return model_billing.Billing{}, fmt.Errorf("model to dto: %w", err)
+	}
 
 	result := u.coll.FindOneAndReplace(ctx, bson.D{{Key: "_id", Value: billing.Id}}, billingDto)
 	if err := result.Err(); err != nil {
 		return model_billing.Billing{}, fmt.Errorf("mongo find one and replace: %w", err)
 	}
 
-	u.mutex.Lock()
-	u.cache[billing.Id] = billing
-	u.mutex.Unlock()
+	u.cache.Set(ctx, billing.Id, billing)
 
 	return billing, nil
 }
 
 func (u *billingRepository) Create(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
-	billingDto := dto.NewBillingDTOFromModel(billing)
+	billingDto, err := dto.NewBillingDTOFromModel(billing, u.cipher)
+	if err != nil {
+		return model_billing.Billing{}, fmt.Errorf("model to dto: %w", err)
+	}
 
-	_, err := u.coll.InsertOne(ctx, billingDto)
+	_, err = u.coll.InsertOne(ctx, billingDto)
 	if err != nil {
 		return model_billing.Billing{}, fmt.Errorf("mongo insert one: %w", err)
 	}
 
-	u.mutex.Lock()
-	u.cache[billing.Id] = billing
-	u.mutex.Unlock()
+	u.cache.Set(ctx, billing.Id, billing)
 
 	return billing, nil
 }
 
-func (u *billingRepository) Delete(ctx context.Context, id string) (model_billing.Billing, error) {
-	result := u.coll.FindOneAndDelete(ctx, bson.D{{Key: "_id", Value: id}})
-	if err := result.Err(); err != nil {
+func (u *billingRepository) Delete(ctx context.Context, id string, deletedBefore time.Time) (model_billing.Billing, error) {
+	result := u.coll.FindOneAndDelete(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "deleted_at", Value: bson.D{{Key: "$lt", Value: deletedBefore}}},
+	})
+	err := result.Err()
+	if errors.Is(mongo.ErrNoDocuments, err) {
+		return model_billing.Billing{}, ErrNoData
+	}
+	if err != nil {
 		return model_billing.Billing{}, fmt.Errorf("mongo find one and delete: %w", err)
 	}
 
@@ -98,21 +128,39 @@ func (u *billingRepository) Delete(ctx context.Context, id string) (model_billin
 		return model_billing.Billing{}, fmt.Errorf("result decode: %w", err)
 	}
 
-	billing, err := billingDTO.ToModel()
+	billing, err := billingDTO.ToModel(u.cipher)
 	if err != nil {
 		return model_billing.Billing{}, fmt.Errorf("dto to model: %w", err)
 	}
-	u.mutex.Lock()
-	delete(u.cache, billing.Id)
-	u.mutex.Unlock()
+	u.cache.Delete(ctx, billing.Id)
 
 	return billing, nil
 }
 
 func (u *billingRepository) Get(ctx context.Context, id string) (model_billing.Billing, error) {
-	u.mutex.RLock()
-	billing, ok := u.cache[id]
-	u.mutex.RUnlock()
+	billing, err := u.get(ctx, id)
+	if err != nil {
+		return model_billing.Billing{}, err
+	}
+	if billing.IsDeleted() {
+		return model_billing.Billing{}, ErrNoData
+	}
+	return billing, nil
+}
+
+func (u *billingRepository) GetDeleted(ctx context.Context, id string) (model_billing.Billing, error) {
+	billing, err := u.get(ctx, id)
+	if err != nil {
+		return model_billing.Billing{}, err
+	}
+	if !billing.IsDeleted() {
+		return model_billing.Billing{}, ErrNoData
+	}
+	return billing, nil
+}
+
+func (u *billingRepository) get(ctx context.Context, id string) (model_billing.Billing, error) {
+	billing, ok := u.cache.Get(ctx, id)
 	if ok {
 		return billing, nil
 	}
@@ -132,26 +180,35 @@ func (u *billingRepository) Get(ctx context.Context, id string) (model_billing.B
 		return model_billing.Billing{}, fmt.Errorf("result decode: %w", err)
 	}
 
-	billing, err = billingDTO.ToModel()
+	billing, err = billingDTO.ToModel(u.cipher)
 	if err != nil {
 		return model_billing.Billing{}, fmt.Errorf("dto to model: %w", err)
 	}
-	u.mutex.Lock()
-	u.cache[billing.Id] = billing
-	u.mutex.Unlock()
+	u.cache.Set(ctx, billing.Id, billing)
 
 	return billing, nil
 }
 
 func (u *billingRepository) GetAll(ctx context.Context) ([]model_billing.Billing, error) {
-	if len(u.cache) != 0 {
-		var result []model_billing.Billing
-		for _, billing := range u.cache {
-			result = append(result, billing)
-		}
-		return result, nil
+	if u.cache.Enabled(ctx) {
+		return u.cache.Filter(func(billing model_billing.Billing) bool {
+			return !billing.IsDeleted()
+		}), nil
 	}
-	cursor, err := u.coll.Find(ctx, bson.D{})
+	return u.find(ctx, bson.D{live})
+}
+
+func (u *billingRepository) GetAllDeleted(ctx context.Context) ([]model_billing.Billing, error) {
+	if u.cache.Enabled(ctx) {
+		return u.cache.Filter(func(billing model_billing.Billing) bool {
+			return billing.IsDeleted()
+		}), nil
+	}
+	return u.find(ctx, bson.D{deleted})
+}
+
+func (u *billingRepository) find(ctx context.Context, filter bson.D) ([]model_billing.Billing, error) {
+	cursor, err := u.coll.Find(ctx, filter)
 	if err != nil {
 		return []model_billing.Billing{}, fmt.Errorf("mongo find: %w", err)
 	}
@@ -165,7 +222,7 @@ func (u *billingRepository) GetAll(ctx context.Context) ([]model_billing.Billing
 			if err := cursor.Decode(&result); err != nil {
 				return []model_billing.Billing{}, fmt.Errorf("result decode: %w", err)
 			}
-			billing, err := result.ToModel()
+			billing, err := result.ToModel(u.cipher)
 			if err != nil {
 				return []model_billing.Billing{}, fmt.Errorf("dto to model: %w", err)
 			}
@@ -189,8 +246,15 @@ func New(ctx context.Context, logger zerolog.Logger, client *mongo.Client, cfg C
 		return &billingRepository{}, fmt.Errorf("config validate: %w", err)
 	}
 
+	cipher, err := repository_crypto.New(cfg.Encryption)
+	if err != nil {
+		return &billingRepository{}, fmt.Errorf("create cipher: %w", err)
+	}
+
 	billingRepo := &billingRepository{
 		client: client,
+		cache:  repository_cache.New[model_billing.Billing]("billings", cfg.Cache),
+		cipher: cipher,
 	}
 
 	if err = billingRepo.client.Ping(ctx, nil); err != nil {
@@ -201,18 +265,17 @@ func New(ctx context.Context, logger zerolog.Logger, client *mongo.Client, cfg C
 
 	billingRepo.coll = coll
 
-	cache := map[string]model_billing.Billing{}
-
-	billings, err := billingRepo.GetAll(ctx)
+	err = billingRepo.cache.Start(ctx, logger, coll, func(raw bson.Raw) (string, model_billing.Billing, error) {
+		var billingDTO dto.Billing
+		if err := bson.Unmarshal(raw, &billingDTO); err != nil {
+			return "", model_billing.Billing{}, err
+		}
+		billing, err := billingDTO.ToModel(cipher)
+		return billing.Id, billing, err
+	}, cfg.Cache.PollInterval)
 	if err != nil {
-		return &billingRepository{}, fmt.Errorf("get all users: %w", err)
+		return &billingRepository{}, fmt.Errorf("start cache: %w", err)
 	}
 
-	for _, billing := range billings {
-		cache[billing.Id] = billing
-	}
-
-	billingRepo.cache = cache
-
 	return billingRepo, nil
 }
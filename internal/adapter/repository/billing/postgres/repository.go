@@ -0,0 +1,159 @@
+package postgres_billing_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	sql_transaction "github.com/ThePositree/billing_manager/internal/adapter/transaction/sql"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/billing/sql/dto"
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog"
+)
+
+var _ usecase.BillingRepository = &billingRepository{}
+
+var (
+	ErrNoData = errors.New("no data")
+	// ErrUnknownUser is returned by Create and Update when the billing
+	// refers to a user that does not exist.
+	ErrUnknownUser = errors.New("billing user does not exist")
+	// ErrConflict is never returned, Update overwrites the stored billing.
+	ErrConflict = errors.New("billing was changed concurrently")
+)
+
+const foreignKeyViolation = "23503"
+
+type billingRepository struct {
+	db *sql.DB
+}
+
+func (u *billingRepository) GetNoDataError() error {
+	return ErrNoData
+}
+
+func (u *billingRepository) GetConflictError() error {
+	return ErrConflict
+}
+
+func (u *billingRepository) GetByUserId(ctx context.Context, userId string) ([]model_billing.Billing, error) {
+	return u.query(ctx, "SELECT "+dto.Columns+" FROM billings WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at, id", userId)
+}
+
+func (u *billingRepository) Update(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
+	args, err := dto.Args(dto.NewBillingDTOFromModel(billing), sql_repository.NullTime)
+	if err != nil {
+		return model_billing.Billing{}, err
+	}
+
+	result, err := sql_transaction.GetExecutor(ctx, u.db).ExecContext(ctx, `UPDATE billings SET
+		user_id = $2, state = $3, username = $4, included_revisions = $5, revision_policy = $6,
+		revision_surcharge = $7, created_at = $8, deadline = $9, priority = $10, price = $11, invoiced_at = $12,
+		revisions = $13, line_items = $14, state_changes = $15, stage_targets = $16, assignees = $17,
+		time_entries = $18, payments = $19, deleted_at = $20, deleted_by = $21
+		WHERE id = $1`, args...)
+	if err := mapError(err); err != nil {
+		return model_billing.Billing{}, fmt.Errorf("sql update: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return model_billing.Billing{}, fmt.Errorf("rows affected: %w", err)
+	}
+	if updated == 0 {
+		return model_billing.Billing{}, ErrNoData
+	}
+	return billing, nil
+}
+
+func (u *billingRepository) Create(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
+	args, err := dto.Args(dto.NewBillingDTOFromModel(billing), sql_repository.NullTime)
+	if err != nil {
+		return model_billing.Billing{}, err
+	}
+
+	_, err = sql_transaction.GetExecutor(ctx, u.db).ExecContext(ctx, "INSERT INTO billings ("+dto.Columns+`) VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)`, args...)
+	if err := mapError(err); err != nil {
+		return model_billing.Billing{}, fmt.Errorf("sql insert: %w", err)
+	}
+	return billing, nil
+}
+
+func (u *billingRepository) Delete(ctx context.Context, id string, deletedBefore time.Time) (model_billing.Billing, error) {
+	row := sql_transaction.GetExecutor(ctx, u.db).QueryRowContext(ctx, "DELETE FROM billings WHERE id = $1 AND deleted_at < $2 RETURNING "+dto.Columns, id, deletedBefore)
+	return scanBilling(row)
+}
+
+func (u *billingRepository) Get(ctx context.Context, id string) (model_billing.Billing, error) {
+	query := "SELECT " + dto.Columns + " FROM billings WHERE id = $1 AND deleted_at IS NULL"
+	// Inside a unit of work the billing is read to be updated, locking the
+	// row keeps concurrent read-modify-write cycles from losing changes.
+	if sql_transaction.InTransaction(ctx) {
+		query += " FOR UPDATE"
+	}
+	row := sql_transaction.GetExecutor(ctx, u.db).QueryRowContext(ctx, query, id)
+	return scanBilling(row)
+}
+
+func (u *billingRepository) GetDeleted(ctx context.Context, id string) (model_billing.Billing, error) {
+	row := sql_transaction.GetExecutor(ctx, u.db).QueryRowContext(ctx, "SELECT "+dto.Columns+" FROM billings WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	return scanBilling(row)
+}
+
+func (u *billingRepository) GetAll(ctx context.Context) ([]model_billing.Billing, error) {
+	return u.query(ctx, "SELECT "+dto.Columns+" FROM billings WHERE deleted_at IS NULL ORDER BY created_at, id")
+}
+
+func (u *billingRepository) GetAllDeleted(ctx context.Context) ([]model_billing.Billing, error) {
+	return u.query(ctx, "SELECT "+dto.Columns+" FROM billings WHERE deleted_at IS NOT NULL ORDER BY deleted_at, id")
+}
+
+func (u *billingRepository) query(ctx context.Context, query string, args ...any) ([]model_billing.Billing, error) {
+	rows, err := sql_transaction.GetExecutor(ctx, u.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return []model_billing.Billing{}, fmt.Errorf("sql query: %w", err)
+	}
+	defer rows.Close()
+
+	var billings []model_billing.Billing
+	for rows.Next() {
+		billing, err := scanBilling(rows)
+		if err != nil {
+			return []model_billing.Billing{}, err
+		}
+		billings = append(billings, billing)
+	}
+	if err := rows.Err(); err != nil {
+		return []model_billing.Billing{}, fmt.Errorf("rows error: %w", err)
+	}
+	return billings, nil
+}
+
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrUnknownUser
+	}
+	return err
+}
+
+func scanBilling(row sql_repository.Row) (model_billing.Billing, error) {
+	billing, err := dto.Scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model_billing.Billing{}, ErrNoData
+	}
+	return billing, err
+}
+
+// New expects the schema to be migrated, see postgres_migration.
+func New(ctx context.Context, logger zerolog.Logger, db *sql.DB) (*billingRepository, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return &billingRepository{}, fmt.Errorf("sql ping: %w", err)
+	}
+	return &billingRepository{db: db}, nil
+}
@@ -0,0 +1,126 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	"github.com/ThePositree/billing_manager/internal/model/billing"
+)
+
+// Columns are listed in the order Args and Scan use.
+const Columns = `id, user_id, state, username, included_revisions, revision_policy,
+	revision_surcharge, created_at, deadline, priority, price, invoiced_at,
+	revisions, line_items, state_changes, stage_targets, assignees, time_entries, payments,
+	deleted_at, deleted_by`
+
+// encodeJSON writes nil lists and maps as empty ones.
+func encodeJSON(value any, empty string) ([]byte, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if string(encoded) == "null" {
+		return []byte(empty), nil
+	}
+	return encoded, nil
+}
+
+func Args(billingDTO Billing, timeValue sql_repository.TimeValue) ([]any, error) {
+	nested := []struct {
+		value any
+		empty string
+	}{
+		{value: billingDTO.Revisions, empty: "[]"},
+		{value: billingDTO.LineItems, empty: "[]"},
+		{value: billingDTO.StateChanges, empty: "[]"},
+		{value: billingDTO.StageTargets, empty: "{}"},
+		{value: billingDTO.Assignees, empty: "{}"},
+		{value: billingDTO.TimeEntries, empty: "[]"},
+		{value: billingDTO.Payments, empty: "[]"},
+	}
+
+	args := []any{
+		billingDTO.Id,
+		billingDTO.UserId,
+		billingDTO.State,
+		billingDTO.Username,
+		billingDTO.IncludedRevisions,
+		billingDTO.RevisionPolicy,
+		billingDTO.RevisionSurcharge,
+		timeValue(billingDTO.CreatedAt),
+		timeValue(billingDTO.Deadline),
+		billingDTO.Priority,
+		billingDTO.Price,
+		timeValue(billingDTO.InvoicedAt),
+	}
+	for _, field := range nested {
+		encoded, err := encodeJSON(field.value, field.empty)
+		if err != nil {
+			return nil, fmt.Errorf("json marshal: %w", err)
+		}
+		args = append(args, string(encoded))
+	}
+	return append(args, timeValue(billingDTO.DeletedAt), billingDTO.DeletedBy), nil
+}
+
+// Scan wraps sql.ErrNoRows when there is no row.
+func Scan(row sql_repository.Row) (billing.Billing, error) {
+	var (
+		billingDTO             Billing
+		revisions, lineItems   []byte
+		stateChanges           []byte
+		stageTargets           []byte
+		assignees, timeEntries []byte
+		payments               []byte
+	)
+	err := row.Scan(
+		&billingDTO.Id,
+		&billingDTO.UserId,
+		&billingDTO.State,
+		&billingDTO.Username,
+		&billingDTO.IncludedRevisions,
+		&billingDTO.RevisionPolicy,
+		&billingDTO.RevisionSurcharge,
+		sql_repository.TimeScanner{Time: &billingDTO.CreatedAt},
+		sql_repository.TimeScanner{Time: &billingDTO.Deadline},
+		&billingDTO.Priority,
+		&billingDTO.Price,
+		sql_repository.TimeScanner{Time: &billingDTO.InvoicedAt},
+		&revisions,
+		&lineItems,
+		&stateChanges,
+		&stageTargets,
+		&assignees,
+		&timeEntries,
+		&payments,
+		sql_repository.TimeScanner{Time: &billingDTO.DeletedAt},
+		&billingDTO.DeletedBy,
+	)
+	if err != nil {
+		return billing.Billing{}, fmt.Errorf("row scan: %w", err)
+	}
+
+	for _, field := range []struct {
+		encoded []byte
+		target  any
+	}{
+		{encoded: revisions, target: &billingDTO.Revisions},
+		{encoded: lineItems, target: &billingDTO.LineItems},
+		{encoded: stateChanges, target: &billingDTO.StateChanges},
+		{encoded: stageTargets, target: &billingDTO.StageTargets},
+		{encoded: assignees, target: &billingDTO.Assignees},
+		{encoded: timeEntries, target: &billingDTO.TimeEntries},
+		{encoded: payments, target: &billingDTO.Payments},
+	} {
+		if err := json.Unmarshal(field.encoded, field.target); err != nil {
+			return billing.Billing{}, fmt.Errorf("json unmarshal: %w", err)
+		}
+	}
+
+	result, err := billingDTO.ToModel()
+	if err != nil {
+		return billing.Billing{}, fmt.Errorf("dto to model: %w", err)
+	}
+	return result, nil
+}
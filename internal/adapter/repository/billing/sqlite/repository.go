@@ -0,0 +1,156 @@
+package sqlite_billing_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	sql_transaction "github.com/ThePositree/billing_manager/internal/adapter/transaction/sql"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/billing/sql/dto"
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/rs/zerolog"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var _ usecase.BillingRepository = &billingRepository{}
+
+var (
+	ErrNoData = errors.New("no data")
+	// ErrUnknownUser is returned by Create and Update when the billing
+	// refers to a user that does not exist.
+	ErrUnknownUser = errors.New("billing user does not exist")
+	// ErrConflict is never returned, Update overwrites the stored billing.
+	ErrConflict = errors.New("billing was changed concurrently")
+)
+
+type billingRepository struct {
+	db *sql.DB
+}
+
+func (u *billingRepository) GetNoDataError() error {
+	return ErrNoData
+}
+
+func (u *billingRepository) GetConflictError() error {
+	return ErrConflict
+}
+
+func (u *billingRepository) GetByUserId(ctx context.Context, userId string) ([]model_billing.Billing, error) {
+	return u.query(ctx, "SELECT "+dto.Columns+" FROM billings WHERE user_id = ? AND deleted_at = '' ORDER BY created_at, id", userId)
+}
+
+func (u *billingRepository) Update(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
+	args, err := dto.Args(dto.NewBillingDTOFromModel(billing), sql_repository.TextTime)
+	if err != nil {
+		return model_billing.Billing{}, err
+	}
+
+	// The id goes last to match the WHERE clause.
+	result, err := sql_transaction.GetExecutor(ctx, u.db).ExecContext(ctx, `UPDATE billings SET
+		user_id = ?, state = ?, username = ?, included_revisions = ?, revision_policy = ?,
+		revision_surcharge = ?, created_at = ?, deadline = ?, priority = ?, price = ?, invoiced_at = ?,
+		revisions = ?, line_items = ?, state_changes = ?, stage_targets = ?, assignees = ?,
+		time_entries = ?, payments = ?, deleted_at = ?, deleted_by = ?
+		WHERE id = ?`, append(args[1:], args[0])...)
+	if err := mapError(err); err != nil {
+		return model_billing.Billing{}, fmt.Errorf("sql update: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return model_billing.Billing{}, fmt.Errorf("rows affected: %w", err)
+	}
+	if updated == 0 {
+		return model_billing.Billing{}, ErrNoData
+	}
+	return billing, nil
+}
+
+func (u *billingRepository) Create(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
+	args, err := dto.Args(dto.NewBillingDTOFromModel(billing), sql_repository.TextTime)
+	if err != nil {
+		return model_billing.Billing{}, err
+	}
+
+	_, err = sql_transaction.GetExecutor(ctx, u.db).ExecContext(ctx, "INSERT INTO billings ("+dto.Columns+`) VALUES
+		(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	if err := mapError(err); err != nil {
+		return model_billing.Billing{}, fmt.Errorf("sql insert: %w", err)
+	}
+	return billing, nil
+}
+
+func (u *billingRepository) Delete(ctx context.Context, id string, deletedBefore time.Time) (model_billing.Billing, error) {
+	row := sql_transaction.GetExecutor(ctx, u.db).QueryRowContext(ctx,
+		"DELETE FROM billings WHERE id = ? AND deleted_at != '' AND deleted_at < ? RETURNING "+dto.Columns,
+		id, sql_repository.TextTime(deletedBefore),
+	)
+	return scanBilling(row)
+}
+
+func (u *billingRepository) Get(ctx context.Context, id string) (model_billing.Billing, error) {
+	row := sql_transaction.GetExecutor(ctx, u.db).QueryRowContext(ctx, "SELECT "+dto.Columns+" FROM billings WHERE id = ? AND deleted_at = ''", id)
+	return scanBilling(row)
+}
+
+func (u *billingRepository) GetDeleted(ctx context.Context, id string) (model_billing.Billing, error) {
+	row := sql_transaction.GetExecutor(ctx, u.db).QueryRowContext(ctx, "SELECT "+dto.Columns+" FROM billings WHERE id = ? AND deleted_at != ''", id)
+	return scanBilling(row)
+}
+
+func (u *billingRepository) GetAll(ctx context.Context) ([]model_billing.Billing, error) {
+	return u.query(ctx, "SELECT "+dto.Columns+" FROM billings WHERE deleted_at = '' ORDER BY created_at, id")
+}
+
+func (u *billingRepository) GetAllDeleted(ctx context.Context) ([]model_billing.Billing, error) {
+	return u.query(ctx, "SELECT "+dto.Columns+" FROM billings WHERE deleted_at != '' ORDER BY deleted_at, id")
+}
+
+func (u *billingRepository) query(ctx context.Context, query string, args ...any) ([]model_billing.Billing, error) {
+	rows, err := sql_transaction.GetExecutor(ctx, u.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return []model_billing.Billing{}, fmt.Errorf("sql query: %w", err)
+	}
+	defer rows.Close()
+
+	var billings []model_billing.Billing
+	for rows.Next() {
+		billing, err := scanBilling(rows)
+		if err != nil {
+			return []model_billing.Billing{}, err
+		}
+		billings = append(billings, billing)
+	}
+	if err := rows.Err(); err != nil {
+		return []model_billing.Billing{}, fmt.Errorf("rows error: %w", err)
+	}
+	return billings, nil
+}
+
+func mapError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+		return ErrUnknownUser
+	}
+	return err
+}
+
+func scanBilling(row sql_repository.Row) (model_billing.Billing, error) {
+	billing, err := dto.Scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model_billing.Billing{}, ErrNoData
+	}
+	return billing, err
+}
+
+// New expects the schema to be migrated, see sqlite_migration.
+func New(ctx context.Context, logger zerolog.Logger, db *sql.DB) (*billingRepository, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return &billingRepository{}, fmt.Errorf("sql ping: %w", err)
+	}
+	return &billingRepository{db: db}, nil
+}
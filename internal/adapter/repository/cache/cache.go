@@ -0,0 +1,309 @@
+package repository_cache
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// retryDelay is the pause before reopening a broken change stream.
+const retryDelay = 5 * time.Second
+
+// stats holds <name>_hits and <name>_misses counters of every cache,
+// served by the expvar handler.
+var stats = expvar.NewMap("repository_cache")
+
+type Config struct {
+	// Disabled sends every read to Mongo.
+	Disabled bool
+	// PollInterval is how often the whole collection is reloaded when
+	// the server does not support change streams, e.g. a standalone mongod.
+	PollInterval time.Duration
+}
+
+func (cfg Config) Validate() error {
+	if !cfg.Disabled && cfg.PollInterval <= 0 {
+		return fmt.Errorf("cache poll interval must be positive")
+	}
+	return nil
+}
+
+type pendingKey struct{}
+
+// pending holds cache changes made inside a transaction.
+type pending struct {
+	mutex   sync.Mutex
+	changes []func()
+}
+
+// WithTransaction marks ctx as running inside a transaction. Caches are
+// bypassed for reads under it and their changes wait for commit to be
+// called, so an aborted transaction leaves them untouched.
+func WithTransaction(ctx context.Context) (context.Context, func()) {
+	changes := &pending{}
+	commit := func() {
+		changes.mutex.Lock()
+		defer changes.mutex.Unlock()
+		for _, change := range changes.changes {
+			change()
+		}
+		changes.changes = nil
+	}
+	return context.WithValue(ctx, pendingKey{}, changes), commit
+}
+
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(pendingKey{}).(*pending)
+	return ok
+}
+
+// Decoder turns a raw collection document into the cached item and its id.
+type Decoder[T any] func(raw bson.Raw) (string, T, error)
+
+// Cache mirrors a whole collection in memory and follows its changes so that
+// several service instances sharing the database see each other's writes.
+type Cache[T any] struct {
+	name    string
+	enabled bool
+	mutex   sync.RWMutex
+	items   map[string]T
+}
+
+// Enabled reports whether reads with ctx may be served from the cache.
+func (c *Cache[T]) Enabled(ctx context.Context) bool {
+	return c.enabled && !InTransaction(ctx)
+}
+
+func (c *Cache[T]) Get(ctx context.Context, id string) (T, bool) {
+	if !c.Enabled(ctx) {
+		var empty T
+		return empty, false
+	}
+	c.mutex.RLock()
+	item, ok := c.items[id]
+	c.mutex.RUnlock()
+	if ok {
+		c.Hit()
+	} else {
+		c.Miss()
+	}
+	return item, ok
+}
+
+// Filter returns the items matching the predicate, an empty result
+// is authoritative since the cache mirrors the whole collection.
+func (c *Cache[T]) Filter(match func(item T) bool) []T {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	var result []T
+	for _, item := range c.items {
+		if match(item) {
+			result = append(result, item)
+		}
+	}
+	c.Hit()
+	return result
+}
+
+func (c *Cache[T]) Values() []T {
+	return c.Filter(func(T) bool { return true })
+}
+
+func (c *Cache[T]) Set(ctx context.Context, id string, item T) {
+	c.change(ctx, func() {
+		c.items[id] = item
+	})
+}
+
+func (c *Cache[T]) Delete(ctx context.Context, id string) {
+	c.change(ctx, func() {
+		delete(c.items, id)
+	})
+}
+
+func (c *Cache[T]) change(ctx context.Context, change func()) {
+	if !c.enabled {
+		return
+	}
+	apply := func() {
+		c.mutex.Lock()
+		change()
+		c.mutex.Unlock()
+	}
+	if changes, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		changes.mutex.Lock()
+		changes.changes = append(changes.changes, apply)
+		changes.mutex.Unlock()
+		return
+	}
+	apply()
+}
+
+func (c *Cache[T]) Hit() {
+	stats.Add(c.name+"_hits", 1)
+}
+
+func (c *Cache[T]) Miss() {
+	stats.Add(c.name+"_misses", 1)
+}
+
+// Start loads the collection and keeps the cache in sync until ctx is done.
+// The change stream is opened before loading so no change is lost in between.
+func (c *Cache[T]) Start(ctx context.Context, logger zerolog.Logger, coll *mongo.Collection, decode Decoder[T], pollInterval time.Duration) error {
+	if !c.enabled {
+		return nil
+	}
+	logger = logger.With().Str("Cache", c.name).Logger()
+
+	stream, err := c.watch(ctx, coll)
+	if err != nil {
+		logger.Warn().Err(err).Dur("PollInterval", pollInterval).Msg("Change streams unavailable, polling instead")
+	}
+	if err := c.reload(ctx, coll, decode); err != nil {
+		if stream != nil {
+			stream.Close(context.Background())
+		}
+		return err
+	}
+
+	if stream == nil {
+		go c.poll(ctx, logger, coll, decode, pollInterval)
+		return nil
+	}
+	go c.follow(ctx, logger, coll, decode, stream)
+	return nil
+}
+
+func (c *Cache[T]) watch(ctx context.Context, coll *mongo.Collection) (*mongo.ChangeStream, error) {
+	stream, err := coll.Watch(ctx, mongo.Pipeline{}, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	if err != nil {
+		return nil, fmt.Errorf("mongo watch: %w", err)
+	}
+	return stream, nil
+}
+
+func (c *Cache[T]) reload(ctx context.Context, coll *mongo.Collection, decode Decoder[T]) error {
+	cursor, err := coll.Find(ctx, bson.D{})
+	if err != nil {
+		return fmt.Errorf("mongo find: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	items := map[string]T{}
+	for cursor.Next(ctx) {
+		id, item, err := decode(cursor.Current)
+		if err != nil {
+			return fmt.Errorf("decode: %w", err)
+		}
+		items[id] = item
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("cursor error: %w", err)
+	}
+
+	c.mutex.Lock()
+	c.items = items
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *Cache[T]) poll(ctx context.Context, logger zerolog.Logger, coll *mongo.Collection, decode Decoder[T], pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.reload(ctx, coll, decode); err != nil && ctx.Err() == nil {
+				logger.Error().Err(err).Msg("Cache reload")
+			}
+		}
+	}
+}
+
+// follow applies change events, a broken or invalidated stream is reopened
+// and the collection reloaded since events may have been missed.
+func (c *Cache[T]) follow(ctx context.Context, logger zerolog.Logger, coll *mongo.Collection, decode Decoder[T], stream *mongo.ChangeStream) {
+	for {
+		err := c.apply(ctx, stream, decode)
+		stream.Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.Error().Err(err).Msg("Change stream broken")
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			stream, err = c.watch(ctx, coll)
+			if err == nil {
+				err = c.reload(ctx, coll, decode)
+			}
+			if err == nil {
+				break
+			}
+			if stream != nil {
+				stream.Close(context.Background())
+			}
+			logger.Error().Err(err).Msg("Change stream reopen")
+		}
+	}
+}
+
+var errStreamInvalidated = errors.New("change stream invalidated")
+
+func (c *Cache[T]) apply(ctx context.Context, stream *mongo.ChangeStream, decode Decoder[T]) error {
+	for stream.Next(ctx) {
+		var event struct {
+			OperationType string `bson:"operationType"`
+			DocumentKey   struct {
+				Id string `bson:"_id"`
+			} `bson:"documentKey"`
+			FullDocument bson.Raw `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			return fmt.Errorf("stream decode: %w", err)
+		}
+
+		switch event.OperationType {
+		case "insert", "update", "replace":
+			// The document may be gone by the time the update is looked up.
+			if event.FullDocument == nil {
+				c.Delete(ctx, event.DocumentKey.Id)
+				continue
+			}
+			id, item, err := decode(event.FullDocument)
+			if err != nil {
+				return fmt.Errorf("decode: %w", err)
+			}
+			c.Set(ctx, id, item)
+		case "delete":
+			c.Delete(ctx, event.DocumentKey.Id)
+		case "drop", "rename", "dropDatabase", "invalidate":
+			return errStreamInvalidated
+		}
+	}
+	return stream.Err()
+}
+
+func New[T any](name string, cfg Config) *Cache[T] {
+	return &Cache[T]{
+		name:    name,
+		enabled: !cfg.Disabled,
+		items:   map[string]T{},
+	}
+}
@@ -0,0 +1,411 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	postgres_migration "github.com/ThePositree/billing_manager/internal/adapter/migration/postgres"
+	sqlite_migration "github.com/ThePositree/billing_manager/internal/adapter/migration/sqlite"
+	postgres_billing_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/postgres"
+	sqlite_billing_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/billing/sqlite"
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	postgres_user_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/user/postgres"
+	sqlite_user_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/user/sqlite"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// storage is one adapter set the contract runs against. The port has no
+// getters for foreign key violations, so their errors are given here.
+type storage struct {
+	userRepo       usecase.UserRepository
+	billingRepo    usecase.BillingRepository
+	errUnknownUser error
+	errHasBillings error
+}
+
+func TestRepositoryContract(t *testing.T) {
+	storages := map[string]func(t *testing.T) storage{
+		"sqlite":   openSQLite,
+		"postgres": openPostgres,
+	}
+	for name, open := range storages {
+		t.Run(name, func(t *testing.T) {
+			t.Run("user", func(t *testing.T) { testUserRepository(t, open(t)) })
+			t.Run("billing", func(t *testing.T) { testBillingRepository(t, open(t)) })
+		})
+	}
+}
+
+// openSQLite migrates a database in the test directory, opened with the DSN
+// the application uses.
+func openSQLite(t *testing.T) storage {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "billing_manager.db")
+	db, err := sql.Open("sqlite", sql_repository.SQLiteDSN(path))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := sqlite_migration.New(zerolog.Nop(), db)
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx, 0)
+	require.NoError(t, err)
+
+	userRepo, err := sqlite_user_repository.New(ctx, zerolog.Nop(), db)
+	require.NoError(t, err)
+	billingRepo, err := sqlite_billing_repository.New(ctx, zerolog.Nop(), db)
+	require.NoError(t, err)
+	return storage{
+		userRepo:       userRepo,
+		billingRepo:    billingRepo,
+		errUnknownUser: sqlite_billing_repository.ErrUnknownUser,
+		errHasBillings: sqlite_user_repository.ErrHasBillings,
+	}
+}
+
+// openPostgres migrates a throwaway schema of the database at
+// BILLING_TEST_POSTGRES_DSN.
+func openPostgres(t *testing.T) storage {
+	dsn := os.Getenv("BILLING_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("BILLING_TEST_POSTGRES_DSN is not set")
+	}
+	ctx := context.Background()
+
+	admin, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+	schema := "billing_manager_test_" + strings.ReplaceAll(uuid.NewString()[:8], "-", "")
+	_, err = admin.ExecContext(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE") })
+
+	db, err := sql.Open("pgx", withSearchPath(t, dsn, schema))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := postgres_migration.New(zerolog.Nop(), db)
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx, 0)
+	require.NoError(t, err)
+
+	userRepo, err := postgres_user_repository.New(ctx, zerolog.Nop(), db)
+	require.NoError(t, err)
+	billingRepo, err := postgres_billing_repository.New(ctx, zerolog.Nop(), db)
+	require.NoError(t, err)
+	return storage{
+		userRepo:       userRepo,
+		billingRepo:    billingRepo,
+		errUnknownUser: postgres_billing_repository.ErrUnknownUser,
+		errHasBillings: postgres_user_repository.ErrHasBillings,
+	}
+}
+
+// withSearchPath points every connection of the pool at schema, for both
+// URL and keyword/value DSNs.
+func withSearchPath(t *testing.T, dsn string, schema string) string {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsn + " search_path=" + schema
+	}
+	parsed, err := url.Parse(dsn)
+	require.NoError(t, err)
+	query := parsed.Query()
+	query.Set("search_path", schema)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// requireSameTime compares at microseconds, the precision of postgres
+// timestamptz, and expects times to come back in UTC.
+func requireSameTime(t *testing.T, want time.Time, got time.Time, field string) {
+	t.Helper()
+	require.True(t, want.Truncate(time.Microsecond).Equal(got.Truncate(time.Microsecond)), "%s: want %s, got %s", field, want, got)
+	if !got.IsZero() {
+		require.Equal(t, time.UTC, got.Location(), field)
+	}
+}
+
+func testUserRepository(t *testing.T, s storage) {
+	ctx := context.Background()
+	repo := s.userRepo
+	isNoData := func(err error) bool { return errors.Is(repo.GetNoDataError(), err) }
+	isAlreadyExists := func(err error) bool { return errors.Is(repo.GetAlreadyExistsError(), err) }
+
+	alice, err := repo.Create(ctx, model_user.New("alice"))
+	require.NoError(t, err)
+	bob, err := repo.Create(ctx, model_user.New("bob"))
+	require.NoError(t, err)
+
+	got, err := repo.Get(ctx, alice.Id)
+	require.NoError(t, err)
+	require.Equal(t, alice, got)
+	got, err = repo.GetByTelegramUN(ctx, "bob")
+	require.NoError(t, err)
+	require.Equal(t, bob, got)
+
+	_, err = repo.Get(ctx, uuid.NewString())
+	require.True(t, isNoData(err), "get unknown: %v", err)
+	_, err = repo.GetByTelegramUN(ctx, "carol")
+	require.True(t, isNoData(err), "get unknown username: %v", err)
+
+	t.Run("duplicates", func(t *testing.T) {
+		_, err := repo.Create(ctx, model_user.New("alice"))
+		require.True(t, isAlreadyExists(err), "duplicate username: %v", err)
+
+		_, err = repo.Create(ctx, model_user.User{Id: alice.Id, TelegramUN: "alice-copy"})
+		require.True(t, isAlreadyExists(err), "duplicate id: %v", err)
+
+		renamed := bob
+		renamed.TelegramUN = "alice"
+		_, err = repo.Update(ctx, renamed)
+		require.True(t, isAlreadyExists(err), "update to taken username: %v", err)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		renamed := bob
+		renamed.TelegramUN = "robert"
+		_, err := repo.Update(ctx, renamed)
+		require.NoError(t, err)
+
+		got, err := repo.Get(ctx, bob.Id)
+		require.NoError(t, err)
+		require.Equal(t, "robert", got.TelegramUN)
+		_, err = repo.GetByTelegramUN(ctx, "bob")
+		require.True(t, isNoData(err), "old username: %v", err)
+
+		_, err = repo.Update(ctx, model_user.New("nobody"))
+		require.True(t, isNoData(err), "update unknown: %v", err)
+	})
+
+	t.Run("soft delete", func(t *testing.T) {
+		deleted, err := repo.Create(ctx, model_user.New("dave"))
+		require.NoError(t, err)
+		deleted.MarkDeleted("admin")
+		_, err = repo.Update(ctx, deleted)
+		require.NoError(t, err)
+
+		_, err = repo.Get(ctx, deleted.Id)
+		require.True(t, isNoData(err), "get deleted: %v", err)
+		_, err = repo.GetByTelegramUN(ctx, "dave")
+		require.True(t, isNoData(err), "get deleted by username: %v", err)
+		all, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		require.NotContains(t, userIds(all), deleted.Id)
+
+		got, err := repo.GetDeleted(ctx, deleted.Id)
+		require.NoError(t, err)
+		require.Equal(t, "admin", got.GetDeletedBy())
+		requireSameTime(t, deleted.GetDeletedAt(), got.GetDeletedAt(), "deleted at")
+		allDeleted, err := repo.GetAllDeleted(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{deleted.Id}, userIds(allDeleted))
+		_, err = repo.GetDeleted(ctx, alice.Id)
+		require.True(t, isNoData(err), "get deleted of live user: %v", err)
+
+		// The username of a deleted user is free, restoring the deleted
+		// one then collides with the new owner.
+		_, err = repo.Create(ctx, model_user.New("dave"))
+		require.NoError(t, err)
+		deleted.Restore()
+		_, err = repo.Update(ctx, deleted)
+		require.True(t, isAlreadyExists(err), "restore taken username: %v", err)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		// Only users deleted before the moment are removed, e.g. not one
+		// restored while it was purged.
+		_, err := repo.Delete(ctx, alice.Id, time.Now().Add(time.Hour))
+		require.True(t, isNoData(err), "delete live: %v", err)
+
+		alice.MarkDeleted("admin")
+		_, err = repo.Update(ctx, alice)
+		require.NoError(t, err)
+		_, err = repo.Delete(ctx, alice.Id, alice.GetDeletedAt())
+		require.True(t, isNoData(err), "delete deleted later: %v", err)
+
+		removed, err := repo.Delete(ctx, alice.Id, alice.GetDeletedAt().Add(time.Second))
+		require.NoError(t, err)
+		require.Equal(t, alice.Id, removed.Id)
+		requireSameTime(t, alice.GetDeletedAt(), removed.GetDeletedAt(), "deleted at")
+
+		_, err = repo.GetDeleted(ctx, alice.Id)
+		require.True(t, isNoData(err), "get removed: %v", err)
+		_, err = repo.Delete(ctx, alice.Id, time.Now().Add(time.Hour))
+		require.True(t, isNoData(err), "delete removed: %v", err)
+	})
+}
+
+func testBillingRepository(t *testing.T, s storage) {
+	ctx := context.Background()
+	repo := s.billingRepo
+	isNoData := func(err error) bool { return errors.Is(repo.GetNoDataError(), err) }
+
+	owner, err := s.userRepo.Create(ctx, model_user.New("owner"))
+	require.NoError(t, err)
+
+	newBilling := func() model_billing.Billing {
+		t.Helper()
+		billing, err := model_billing.New(owner.Id)
+		require.NoError(t, err)
+		return billing
+	}
+
+	billing := newBilling()
+	_, err = repo.Create(ctx, billing)
+	require.NoError(t, err)
+
+	got, err := repo.Get(ctx, billing.Id)
+	require.NoError(t, err)
+	requireSameBilling(t, billing, got)
+	_, err = repo.Get(ctx, uuid.NewString())
+	require.True(t, isNoData(err), "get unknown: %v", err)
+
+	t.Run("unknown user", func(t *testing.T) {
+		orphan, err := model_billing.New(uuid.NewString())
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, orphan)
+		require.ErrorIs(t, err, s.errUnknownUser)
+
+		moved := billing
+		moved.UserId = uuid.NewString()
+		_, err = repo.Update(ctx, moved)
+		require.ErrorIs(t, err, s.errUnknownUser)
+	})
+
+	t.Run("user with billings", func(t *testing.T) {
+		deleted := owner
+		deleted.MarkDeleted("admin")
+		_, err := s.userRepo.Update(ctx, deleted)
+		require.NoError(t, err)
+		_, err = s.userRepo.Delete(ctx, owner.Id, time.Now().Add(time.Hour))
+		require.ErrorIs(t, err, s.errHasBillings)
+		_, err = s.userRepo.Update(ctx, owner)
+		require.NoError(t, err)
+	})
+
+	t.Run("update and times", func(t *testing.T) {
+		moscow := time.FixedZone("MSK", 3*60*60)
+		require.NoError(t, billing.NextState())
+		billing.SetDeadline(time.Date(2030, 1, 2, 3, 4, 5, 123456789, moscow))
+		require.NoError(t, billing.SetPrice(10000))
+		require.NoError(t, billing.Invoice())
+		_, err := billing.AddPayment(2500, time.Date(2030, 1, 3, 0, 0, 0, 987654321, moscow), "advance")
+		require.NoError(t, err)
+		_, err = repo.Update(ctx, billing)
+		require.NoError(t, err)
+
+		got, err := repo.Get(ctx, billing.Id)
+		require.NoError(t, err)
+		requireSameBilling(t, billing, got)
+
+		_, err = repo.Update(ctx, newBilling())
+		require.True(t, isNoData(err), "update unknown: %v", err)
+	})
+
+	t.Run("soft delete", func(t *testing.T) {
+		deleted := newBilling()
+		_, err := repo.Create(ctx, deleted)
+		require.NoError(t, err)
+		deleted.MarkDeleted("admin")
+		_, err = repo.Update(ctx, deleted)
+		require.NoError(t, err)
+
+		_, err = repo.Get(ctx, deleted.Id)
+		require.True(t, isNoData(err), "get deleted: %v", err)
+		all, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{billing.Id}, billingIds(all))
+		byUser, err := repo.GetByUserId(ctx, owner.Id)
+		require.NoError(t, err)
+		require.Equal(t, []string{billing.Id}, billingIds(byUser))
+
+		got, err := repo.GetDeleted(ctx, deleted.Id)
+		require.NoError(t, err)
+		requireSameBilling(t, deleted, got)
+		allDeleted, err := repo.GetAllDeleted(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{deleted.Id}, billingIds(allDeleted))
+		_, err = repo.GetDeleted(ctx, billing.Id)
+		require.True(t, isNoData(err), "get deleted of live billing: %v", err)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, err := repo.Delete(ctx, billing.Id, time.Now().Add(time.Hour))
+		require.True(t, isNoData(err), "delete live: %v", err)
+
+		billing.MarkDeleted("admin")
+		_, err = repo.Update(ctx, billing)
+		require.NoError(t, err)
+		_, err = repo.Delete(ctx, billing.Id, billing.GetDeletedAt())
+		require.True(t, isNoData(err), "delete deleted later: %v", err)
+
+		removed, err := repo.Delete(ctx, billing.Id, billing.GetDeletedAt().Add(time.Second))
+		require.NoError(t, err)
+		requireSameBilling(t, billing, removed)
+
+		_, err = repo.GetDeleted(ctx, billing.Id)
+		require.True(t, isNoData(err), "get removed: %v", err)
+		_, err = repo.Delete(ctx, billing.Id, time.Now().Add(time.Hour))
+		require.True(t, isNoData(err), "delete removed: %v", err)
+	})
+}
+
+// requireSameBilling compares what the repositories store, the pending
+// events of want are not stored.
+func requireSameBilling(t *testing.T, want model_billing.Billing, got model_billing.Billing) {
+	t.Helper()
+	require.Equal(t, want.Id, got.Id)
+	require.Equal(t, want.UserId, got.UserId)
+	require.Equal(t, want.GetState(), got.GetState())
+	require.Equal(t, want.GetPrice(), got.GetPrice())
+	require.Equal(t, want.GetPaid(), got.GetPaid())
+	require.Equal(t, want.GetDeletedBy(), got.GetDeletedBy())
+	requireSameTime(t, want.GetCreatedAt(), got.GetCreatedAt(), "created at")
+	requireSameTime(t, want.GetDeadline(), got.GetDeadline(), "deadline")
+	requireSameTime(t, want.GetInvoicedAt(), got.GetInvoicedAt(), "invoiced at")
+	requireSameTime(t, want.GetDeletedAt(), got.GetDeletedAt(), "deleted at")
+
+	wantChanges, gotChanges := want.GetStateChanges(), got.GetStateChanges()
+	require.Len(t, gotChanges, len(wantChanges))
+	for i := range wantChanges {
+		require.Equal(t, wantChanges[i].State, gotChanges[i].State)
+		requireSameTime(t, wantChanges[i].EnteredAt, gotChanges[i].EnteredAt, "state entered at")
+	}
+	wantPayments, gotPayments := want.GetPayments(), got.GetPayments()
+	require.Len(t, gotPayments, len(wantPayments))
+	for i := range wantPayments {
+		require.Equal(t, wantPayments[i].Id, gotPayments[i].Id)
+		require.Equal(t, wantPayments[i].Amount, gotPayments[i].Amount)
+		require.Equal(t, wantPayments[i].Note, gotPayments[i].Note)
+		requireSameTime(t, wantPayments[i].PaidAt, gotPayments[i].PaidAt, "paid at")
+	}
+}
+
+func userIds(users []model_user.User) []string {
+	ids := []string{}
+	for _, user := range users {
+		ids = append(ids, user.Id)
+	}
+	return ids
+}
+
+func billingIds(billings []model_billing.Billing) []string {
+	ids := []string{}
+	for _, billing := range billings {
+		ids = append(ids, billing.Id)
+	}
+	return ids
+}
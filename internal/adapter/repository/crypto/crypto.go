@@ -0,0 +1,183 @@
+package repository_crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// prefix starts every encrypted value: enc:<key id>:<base64 nonce and ciphertext>.
+// Values without it were written before encryption was turned on.
+const prefix = "enc:"
+
+const keySize = 32
+
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+type Config struct {
+	// Keys are base64 encoded AES-256 keys by key id. An empty map turns
+	// encryption off and values are stored as they are.
+	Keys map[string]string
+	// KeyId picks the key new values are encrypted with, the other keys
+	// only decrypt values written before a rotation.
+	KeyId string
+	// IndexKey is the base64 encoded HMAC key of blind indexes. Changing it
+	// invalidates every stored index, so it is not rotated with the keys.
+	IndexKey string
+}
+
+func (cfg Config) Enabled() bool {
+	return len(cfg.Keys) > 0
+}
+
+func (cfg Config) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	ids := make([]string, 0, len(cfg.Keys))
+	for id := range cfg.Keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if id == "" || strings.Contains(id, ":") {
+			return fmt.Errorf("encryption key id %q cannot be empty or contain a colon", id)
+		}
+		if _, err := decodeKey(cfg.Keys[id]); err != nil {
+			return fmt.Errorf("encryption key %s: %w", id, err)
+		}
+	}
+	if _, ok := cfg.Keys[cfg.KeyId]; !ok {
+		return fmt.Errorf("encryption key id %q is not among the keys", cfg.KeyId)
+	}
+	if _, err := decodeKey(cfg.IndexKey); err != nil {
+		return fmt.Errorf("blind index key: %w", err)
+	}
+	return nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("must be base64 encoded")
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("must be %d bytes long, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+// Cipher encrypts personal data fields of documents with AES-GCM. A cipher
+// made from a disabled config passes values through.
+type Cipher struct {
+	keyId    string
+	aeads    map[string]cipher.AEAD
+	indexKey []byte
+}
+
+func (c *Cipher) Enabled() bool {
+	return len(c.aeads) > 0
+}
+
+// Encrypt leaves empty values empty, they mean the field is not set.
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if !c.Enabled() || plaintext == "" {
+		return plaintext, nil
+	}
+	aead := c.aeads[c.keyId]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generating nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(c.keyId))
+	return prefix + c.keyId + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns values without the encryption prefix as they are.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	keyId, encoded, ok := parse(value)
+	if !ok {
+		return value, nil
+	}
+	aead, ok := c.aeads[keyId]
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrUnknownKey, keyId)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed value encrypted with key %s", keyId)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(keyId))
+	if err != nil {
+		return "", fmt.Errorf("decrypting value with key %s: %w", keyId, err)
+	}
+	return string(plaintext), nil
+}
+
+// Stale reports whether the value is not encrypted with the current key
+// and should be written again.
+func (c *Cipher) Stale(value string) bool {
+	if !c.Enabled() || value == "" {
+		return false
+	}
+	keyId, _, ok := parse(value)
+	return !ok || keyId != c.keyId
+}
+
+// BlindIndex is a keyed hash of the plaintext, equal values get equal
+// indexes so encrypted fields can still be looked up and kept unique.
+// It is empty when encryption is off.
+func (c *Cipher) BlindIndex(plaintext string) string {
+	if !c.Enabled() {
+		return ""
+	}
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(plaintext))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func parse(value string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return "", "", false
+	}
+	return strings.Cut(rest, ":")
+}
+
+func New(cfg Config) (*Cipher, error) {
+	if err := cfg.Validate(); err != nil {
+		return &Cipher{}, fmt.Errorf("config validate: %w", err)
+	}
+	if !cfg.Enabled() {
+		return &Cipher{}, nil
+	}
+
+	aeads := map[string]cipher.AEAD{}
+	for id, encoded := range cfg.Keys {
+		key, _ := decodeKey(encoded)
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return &Cipher{}, fmt.Errorf("creating cipher %s: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return &Cipher{}, fmt.Errorf("creating gcm %s: %w", id, err)
+		}
+		aeads[id] = aead
+	}
+	indexKey, _ := decodeKey(cfg.IndexKey)
+	return &Cipher{
+		keyId:    cfg.KeyId,
+		aeads:    aeads,
+		indexKey: indexKey,
+	}, nil
+}
@@ -0,0 +1,212 @@
+package repository_crypto
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(fill), keySize)))
+}
+
+func newTestCipher(t *testing.T, keyId string, keys ...string) *Cipher {
+	t.Helper()
+	cfg := Config{Keys: map[string]string{}, KeyId: keyId, IndexKey: testKey('i')}
+	for _, id := range keys {
+		cfg.Keys[id] = testKey(id[len(id)-1])
+	}
+	cipher, err := New(cfg)
+	require.NoError(t, err)
+	return cipher
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{
+			name: "disabled",
+			cfg:  Config{},
+		},
+		{
+			name: "valid",
+			cfg:  Config{Keys: map[string]string{"v1": testKey('1')}, KeyId: "v1", IndexKey: testKey('i')},
+		},
+		{
+			name:    "empty key id",
+			cfg:     Config{Keys: map[string]string{"": testKey('1')}, KeyId: "", IndexKey: testKey('i')},
+			wantErr: `encryption key id "" cannot be empty or contain a colon`,
+		},
+		{
+			name:    "colon in key id",
+			cfg:     Config{Keys: map[string]string{"v:1": testKey('1')}, KeyId: "v:1", IndexKey: testKey('i')},
+			wantErr: `encryption key id "v:1" cannot be empty or contain a colon`,
+		},
+		{
+			name:    "key not base64",
+			cfg:     Config{Keys: map[string]string{"v1": "not base64!"}, KeyId: "v1", IndexKey: testKey('i')},
+			wantErr: "encryption key v1: must be base64 encoded",
+		},
+		{
+			name:    "short key",
+			cfg:     Config{Keys: map[string]string{"v1": base64.StdEncoding.EncodeToString([]byte("short"))}, KeyId: "v1", IndexKey: testKey('i')},
+			wantErr: "encryption key v1: must be 32 bytes long, got 5",
+		},
+		{
+			name:    "current key missing",
+			cfg:     Config{Keys: map[string]string{"v1": testKey('1')}, KeyId: "v2", IndexKey: testKey('i')},
+			wantErr: `encryption key id "v2" is not among the keys`,
+		},
+		{
+			name:    "index key missing",
+			cfg:     Config{Keys: map[string]string{"v1": testKey('1')}, KeyId: "v1"},
+			wantErr: "blind index key: must be 32 bytes long, got 0",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.cfg.Validate()
+			if test.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, test.wantErr)
+
+			_, err = New(test.cfg)
+			require.ErrorContains(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	cipher := newTestCipher(t, "v1", "v1")
+
+	for _, plaintext := range []string{"alice", "Иван Петров", strings.Repeat("x", 4096)} {
+		encrypted, err := cipher.Encrypt(plaintext)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(encrypted, "enc:v1:"), encrypted)
+		require.NotContains(t, encrypted, plaintext)
+		require.False(t, cipher.Stale(encrypted))
+
+		decrypted, err := cipher.Decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, decrypted)
+	}
+
+	first, err := cipher.Encrypt("alice")
+	require.NoError(t, err)
+	second, err := cipher.Encrypt("alice")
+	require.NoError(t, err)
+	require.NotEqual(t, first, second, "nonces must differ")
+
+	empty, err := cipher.Encrypt("")
+	require.NoError(t, err)
+	require.Empty(t, empty)
+}
+
+func TestDecryptAfterRotation(t *testing.T) {
+	old := newTestCipher(t, "v1", "v1")
+	encrypted, err := old.Encrypt("alice")
+	require.NoError(t, err)
+
+	rotated := newTestCipher(t, "v2", "v1", "v2")
+	require.True(t, rotated.Stale(encrypted))
+	decrypted, err := rotated.Decrypt(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "alice", decrypted)
+
+	reencrypted, err := rotated.Encrypt(decrypted)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(reencrypted, "enc:v2:"), reencrypted)
+	require.False(t, rotated.Stale(reencrypted))
+
+	// A cipher that dropped the old key cannot read values left behind.
+	_, err = newTestCipher(t, "v2", "v2").Decrypt(encrypted)
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestDecryptRejects(t *testing.T) {
+	cipher := newTestCipher(t, "v1", "v1")
+	encrypted, err := cipher.Encrypt("alice")
+	require.NoError(t, err)
+	encoded := strings.TrimPrefix(encrypted, "enc:v1:")
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+
+	flipped := append([]byte{}, sealed...)
+	flipped[len(flipped)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{name: "tampered ciphertext", value: "enc:v1:" + base64.RawStdEncoding.EncodeToString(flipped), wantErr: "decrypting value with key v1"},
+		{name: "truncated", value: "enc:v1:" + base64.RawStdEncoding.EncodeToString(sealed[:4]), wantErr: "malformed value encrypted with key v1"},
+		{name: "not base64", value: "enc:v1:!!!", wantErr: "malformed value encrypted with key v1"},
+		// The key id is authenticated, moving a value to another id fails.
+		{name: "other key id", value: "enc:v2:" + encoded, wantErr: "unknown encryption key v2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := cipher.Decrypt(test.value)
+			require.ErrorContains(t, err, test.wantErr)
+		})
+	}
+
+	both := newTestCipher(t, "v1", "v1", "v2")
+	_, err = both.Decrypt("enc:v2:" + encoded)
+	require.ErrorContains(t, err, "decrypting value with key v2")
+}
+
+func TestPlaintextPassthrough(t *testing.T) {
+	disabled, err := New(Config{})
+	require.NoError(t, err)
+	enabled := newTestCipher(t, "v1", "v1")
+
+	tests := []struct {
+		name      string
+		cipher    *Cipher
+		wantStale bool
+	}{
+		{name: "disabled", cipher: disabled, wantStale: false},
+		// Values written before encryption was turned on are read as they
+		// are and rewritten by the next rotation.
+		{name: "enabled", cipher: enabled, wantStale: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decrypted, err := test.cipher.Decrypt("alice")
+			require.NoError(t, err)
+			require.Equal(t, "alice", decrypted)
+			require.Equal(t, test.wantStale, test.cipher.Stale("alice"))
+			require.False(t, test.cipher.Stale(""))
+		})
+	}
+
+	encrypted, err := disabled.Encrypt("alice")
+	require.NoError(t, err)
+	require.Equal(t, "alice", encrypted)
+	require.Empty(t, disabled.BlindIndex("alice"))
+}
+
+func TestBlindIndex(t *testing.T) {
+	cipher := newTestCipher(t, "v1", "v1")
+	index := cipher.BlindIndex("alice")
+	require.Len(t, index, 64)
+	require.Equal(t, index, cipher.BlindIndex("alice"))
+	require.NotEqual(t, index, cipher.BlindIndex("bob"))
+
+	// Rotating the encryption keys keeps indexes, the index key changes them.
+	rotated := newTestCipher(t, "v2", "v1", "v2")
+	require.Equal(t, index, rotated.BlindIndex("alice"))
+
+	otherIndexKey, err := New(Config{Keys: map[string]string{"v1": testKey('1')}, KeyId: "v1", IndexKey: testKey('j')})
+	require.NoError(t, err)
+	require.NotEqual(t, index, otherIndexKey.BlindIndex("alice"))
+}
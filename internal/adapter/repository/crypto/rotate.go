@@ -0,0 +1,71 @@
+package repository_crypto
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Field is an encrypted field of the documents of a collection, nested
+// fields are separated by dots. Index names the field of its blind index,
+// empty when the field has none.
+type Field struct {
+	Path  string
+	Index string
+}
+
+// Rotate encrypts every field value that is plaintext or encrypted with an
+// older key with the current key and returns the number of documents written.
+// A value changed since it was read is left for the next run.
+func (c *Cipher) Rotate(ctx context.Context, coll *mongo.Collection, fields []Field) (int, error) {
+	if !c.Enabled() {
+		return 0, fmt.Errorf("encryption is off")
+	}
+
+	cursor, err := coll.Find(ctx, bson.D{})
+	if err != nil {
+		return 0, fmt.Errorf("mongo find: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	rotated := 0
+	for cursor.Next(ctx) {
+		filter := bson.D{{Key: "_id", Value: cursor.Current.Lookup("_id")}}
+		var set bson.D
+		for _, field := range fields {
+			value, ok := cursor.Current.Lookup(strings.Split(field.Path, ".")...).StringValueOK()
+			if !ok || !c.Stale(value) {
+				continue
+			}
+			plaintext, err := c.Decrypt(value)
+			if err != nil {
+				return rotated, fmt.Errorf("%s of %s: %w", field.Path, cursor.Current.Lookup("_id"), err)
+			}
+			encrypted, err := c.Encrypt(plaintext)
+			if err != nil {
+				return rotated, fmt.Errorf("%s of %s: %w", field.Path, cursor.Current.Lookup("_id"), err)
+			}
+			filter = append(filter, bson.E{Key: field.Path, Value: value})
+			set = append(set, bson.E{Key: field.Path, Value: encrypted})
+			if field.Index != "" {
+				set = append(set, bson.E{Key: field.Index, Value: c.BlindIndex(plaintext)})
+			}
+		}
+		if len(set) == 0 {
+			continue
+		}
+
+		result, err := coll.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: set}})
+		if err != nil {
+			return rotated, fmt.Errorf("mongo update one: %w", err)
+		}
+		rotated += int(result.ModifiedCount)
+	}
+	if err := cursor.Err(); err != nil {
+		return rotated, fmt.Errorf("cursor error: %w", err)
+	}
+	return rotated, nil
+}
@@ -0,0 +1,93 @@
+package repository_crypto
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestRotate runs against the mongod at BILLING_TEST_MONGO_URI in a
+// throwaway database.
+func TestRotate(t *testing.T) {
+	uri := os.Getenv("BILLING_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("BILLING_TEST_MONGO_URI is not set")
+	}
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(ctx) })
+
+	database := "billing_manager_test_" + uuid.NewString()[:8]
+	t.Cleanup(func() { client.Database(database).Drop(ctx) })
+	coll := client.Database(database).Collection("users")
+
+	old := newTestCipher(t, "v1", "v1")
+	cipher := newTestCipher(t, "v2", "v1", "v2")
+	encrypt := func(c *Cipher, plaintext string) string {
+		encrypted, err := c.Encrypt(plaintext)
+		require.NoError(t, err)
+		return encrypted
+	}
+	_, err = coll.InsertMany(ctx, []any{
+		bson.M{"_id": "plaintext", "telegram_username": "alice", "brief": bson.M{"username": "Alice"}},
+		bson.M{"_id": "old", "telegram_username": encrypt(old, "bob"), "brief": bson.M{"username": encrypt(old, "Bob")}},
+		bson.M{"_id": "current", "telegram_username": encrypt(cipher, "carol"), "brief": bson.M{"username": ""}},
+	})
+	require.NoError(t, err)
+
+	fields := []Field{
+		{Path: "telegram_username", Index: "telegram_username_index"},
+		{Path: "brief.username"},
+	}
+	rotated, err := cipher.Rotate(ctx, coll, fields)
+	require.NoError(t, err)
+	require.Equal(t, 2, rotated)
+
+	want := map[string][2]string{
+		"plaintext": {"alice", "Alice"},
+		"old":       {"bob", "Bob"},
+		"current":   {"carol", ""},
+	}
+	for id, values := range want {
+		var doc struct {
+			TelegramUsername      string `bson:"telegram_username"`
+			TelegramUsernameIndex string `bson:"telegram_username_index"`
+			Brief                 struct {
+				Username string `bson:"username"`
+			} `bson:"brief"`
+		}
+		require.NoError(t, coll.FindOne(ctx, bson.M{"_id": id}).Decode(&doc))
+
+		require.True(t, strings.HasPrefix(doc.TelegramUsername, "enc:v2:"), id)
+		telegramUsername, err := cipher.Decrypt(doc.TelegramUsername)
+		require.NoError(t, err)
+		require.Equal(t, values[0], telegramUsername, id)
+		briefUsername, err := cipher.Decrypt(doc.Brief.Username)
+		require.NoError(t, err)
+		require.Equal(t, values[1], briefUsername, id)
+		require.False(t, cipher.Stale(doc.Brief.Username), id)
+
+		// The current document was not rewritten, so it keeps no index.
+		if id != "current" {
+			require.Equal(t, cipher.BlindIndex(values[0]), doc.TelegramUsernameIndex, id)
+		}
+	}
+
+	rotated, err = cipher.Rotate(ctx, coll, fields)
+	require.NoError(t, err)
+	require.Zero(t, rotated)
+
+	disabled, err := New(Config{})
+	require.NoError(t, err)
+	_, err = disabled.Rotate(ctx, coll, fields)
+	require.EqualError(t, err, "encryption is off")
+}
@@ -0,0 +1,295 @@
+// Package computed_report_repository builds reports by reading every billing
+// through the usecase repositories, it serves storages without an
+// aggregation engine of their own and gives the same results as
+// mongo_report_repository.
+package computed_report_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/reporting"
+)
+
+var _ reporting.ReportRepository = &reportRepository{}
+
+type reportRepository struct {
+	userRepo    usecase.UserRepository
+	billingRepo usecase.BillingRepository
+}
+
+// periodLabel formats t the way mongo $dateToString does for the period,
+// weeks are ISO weeks so they never split across years.
+func periodLabel(period reporting.Period, t time.Time) (string, error) {
+	t = t.UTC()
+	switch period {
+	case reporting.PeriodDay:
+		return t.Format("2006-01-02"), nil
+	case reporting.PeriodWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), nil
+	case reporting.PeriodMonth:
+		return t.Format("2006-01"), nil
+	}
+	return "", reporting.ErrInvalidPeriod
+}
+
+func inRange(t time.Time, from time.Time, to time.Time) bool {
+	return !t.Before(from) && t.Before(to)
+}
+
+func (r *reportRepository) billings(ctx context.Context) ([]model_billing.Billing, error) {
+	billings, err := r.billingRepo.GetAll(ctx)
+	if err != nil {
+		return []model_billing.Billing{}, fmt.Errorf("getting billings: %w", err)
+	}
+	return billings, nil
+}
+
+func (r *reportRepository) CountByState(ctx context.Context) (map[model_billing.State]int, error) {
+	billings, err := r.billings(ctx)
+	if err != nil {
+		return map[model_billing.State]int{}, err
+	}
+	result := map[model_billing.State]int{}
+	for _, billing := range billings {
+		result[billing.GetState()]++
+	}
+	return result, nil
+}
+
+func (r *reportRepository) CreatedPerPeriod(ctx context.Context, period reporting.Period, from time.Time, to time.Time) ([]reporting.PeriodCount, error) {
+	return r.countPerPeriod(ctx, period, func(billing model_billing.Billing) (time.Time, bool) {
+		createdAt := billing.GetCreatedAt()
+		return createdAt, inRange(createdAt, from, to)
+	})
+}
+
+func (r *reportRepository) CompletedPerPeriod(ctx context.Context, period reporting.Period, from time.Time, to time.Time) ([]reporting.PeriodCount, error) {
+	return r.countPerPeriod(ctx, period, func(billing model_billing.Billing) (time.Time, bool) {
+		if billing.GetState() != model_billing.StateCompleted {
+			return time.Time{}, false
+		}
+		// A billing may be completed several times after reverts, the last completion counts.
+		var completedAt time.Time
+		for _, change := range billing.GetStateChanges() {
+			if change.State == model_billing.StateCompleted && change.EnteredAt.After(completedAt) {
+				completedAt = change.EnteredAt
+			}
+		}
+		return completedAt, !completedAt.IsZero() && inRange(completedAt, from, to)
+	})
+}
+
+// countPerPeriod counts billings for which at reports a moment.
+func (r *reportRepository) countPerPeriod(ctx context.Context, period reporting.Period, at func(billing model_billing.Billing) (time.Time, bool)) ([]reporting.PeriodCount, error) {
+	if _, err := periodLabel(period, time.Time{}); err != nil {
+		return []reporting.PeriodCount{}, err
+	}
+	billings, err := r.billings(ctx)
+	if err != nil {
+		return []reporting.PeriodCount{}, err
+	}
+
+	counts := map[string]int{}
+	for _, billing := range billings {
+		moment, ok := at(billing)
+		if !ok {
+			continue
+		}
+		label, _ := periodLabel(period, moment)
+		counts[label]++
+	}
+
+	result := []reporting.PeriodCount{}
+	for label, count := range counts {
+		result = append(result, reporting.PeriodCount{Period: label, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Period < result[j].Period
+	})
+	return result, nil
+}
+
+func (r *reportRepository) InvoicedPerPeriod(ctx context.Context, period reporting.Period, from time.Time, to time.Time) ([]reporting.PeriodAmount, error) {
+	return r.sumPerPeriod(ctx, period, func(billing model_billing.Billing, add func(at time.Time, amount int64)) {
+		if invoicedAt := billing.GetInvoicedAt(); inRange(invoicedAt, from, to) {
+			add(invoicedAt, billing.GetTotal())
+		}
+	})
+}
+
+func (r *reportRepository) CollectedPerPeriod(ctx context.Context, period reporting.Period, from time.Time, to time.Time) ([]reporting.PeriodAmount, error) {
+	return r.sumPerPeriod(ctx, period, func(billing model_billing.Billing, add func(at time.Time, amount int64)) {
+		for _, payment := range billing.GetPayments() {
+			if inRange(payment.PaidAt, from, to) {
+				add(payment.PaidAt, payment.Amount)
+			}
+		}
+	})
+}
+
+// sumPerPeriod sums amounts each billing reports through add.
+func (r *reportRepository) sumPerPeriod(ctx context.Context, period reporting.Period, amounts func(billing model_billing.Billing, add func(at time.Time, amount int64))) ([]reporting.PeriodAmount, error) {
+	if _, err := periodLabel(period, time.Time{}); err != nil {
+		return []reporting.PeriodAmount{}, err
+	}
+	billings, err := r.billings(ctx)
+	if err != nil {
+		return []reporting.PeriodAmount{}, err
+	}
+
+	sums := map[string]int64{}
+	for _, billing := range billings {
+		amounts(billing, func(at time.Time, amount int64) {
+			label, _ := periodLabel(period, at)
+			sums[label] += amount
+		})
+	}
+
+	result := []reporting.PeriodAmount{}
+	for label, amount := range sums {
+		result = append(result, reporting.PeriodAmount{Period: label, Amount: amount})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Period < result[j].Period
+	})
+	return result, nil
+}
+
+// ReceivablesByAge only counts payments made up to asOf so the report can be
+// rebuilt for past dates, invoices paid in full are left out.
+func (r *reportRepository) ReceivablesByAge(ctx context.Context, asOf time.Time, lowerBounds []int) ([]reporting.AgingBucket, error) {
+	if len(lowerBounds) == 0 {
+		return []reporting.AgingBucket{}, nil
+	}
+	billings, err := r.billings(ctx)
+	if err != nil {
+		return []reporting.AgingBucket{}, err
+	}
+
+	buckets := make([]reporting.AgingBucket, len(lowerBounds))
+	for i, bound := range lowerBounds {
+		buckets[i].MinDays = bound
+	}
+	for _, billing := range billings {
+		invoicedAt := billing.GetInvoicedAt()
+		if invoicedAt.IsZero() || invoicedAt.After(asOf) {
+			continue
+		}
+		outstanding := billing.GetTotal()
+		for _, payment := range billing.GetPayments() {
+			if !payment.PaidAt.After(asOf) {
+				outstanding -= payment.Amount
+			}
+		}
+		if outstanding <= 0 {
+			continue
+		}
+
+		ageDays := int(math.Floor(asOf.Sub(invoicedAt).Hours() / 24))
+		// Ages below the first bound land in the last bucket like
+		// the $switch default of mongo_report_repository.
+		index := len(buckets) - 1
+		for i := len(lowerBounds) - 1; i >= 0; i-- {
+			if ageDays >= lowerBounds[i] {
+				index = i
+				break
+			}
+		}
+		buckets[index].Count++
+		buckets[index].Outstanding += outstanding
+	}
+
+	result := make([]reporting.AgingBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		if bucket.Count > 0 {
+			result = append(result, bucket)
+		}
+	}
+	return result, nil
+}
+
+// StageDurations splits the state history of every billing into intervals
+// between consecutive state changes and computes nearest-rank percentiles
+// of the interval lengths per state.
+func (r *reportRepository) StageDurations(ctx context.Context) (map[model_billing.State]reporting.DurationStats, error) {
+	billings, err := r.billings(ctx)
+	if err != nil {
+		return map[model_billing.State]reporting.DurationStats{}, err
+	}
+
+	intervals := map[model_billing.State][]time.Duration{}
+	for _, billing := range billings {
+		changes := billing.GetStateChanges()
+		for i := 0; i+1 < len(changes); i++ {
+			intervals[changes[i].State] = append(intervals[changes[i].State], changes[i+1].EnteredAt.Sub(changes[i].EnteredAt))
+		}
+	}
+
+	result := map[model_billing.State]reporting.DurationStats{}
+	for state, durations := range intervals {
+		sort.Slice(durations, func(i, j int) bool {
+			return durations[i] < durations[j]
+		})
+		percentile := func(p float64) time.Duration {
+			return durations[int(math.Ceil(p*float64(len(durations))))-1]
+		}
+		result[state] = reporting.DurationStats{
+			Samples: len(durations),
+			Median:  percentile(0.5),
+			P90:     percentile(0.9),
+		}
+	}
+	return result, nil
+}
+
+func (r *reportRepository) TopClients(ctx context.Context, limit int) ([]reporting.ClientCount, error) {
+	billings, err := r.billings(ctx)
+	if err != nil {
+		return []reporting.ClientCount{}, err
+	}
+
+	counts := map[string]int{}
+	for _, billing := range billings {
+		counts[billing.UserId]++
+	}
+	result := []reporting.ClientCount{}
+	for userId, count := range counts {
+		result = append(result, reporting.ClientCount{UserId: userId, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].UserId < result[j].UserId
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	for i := range result {
+		user, err := r.userRepo.Get(ctx, result[i].UserId)
+		if err == nil {
+			result[i].TelegramUN = user.TelegramUN
+			continue
+		}
+		if !errors.Is(r.userRepo.GetNoDataError(), err) {
+			return []reporting.ClientCount{}, fmt.Errorf("getting user: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func New(userRepo usecase.UserRepository, billingRepo usecase.BillingRepository) *reportRepository {
+	return &reportRepository{
+		userRepo:    userRepo,
+		billingRepo: billingRepo,
+	}
+}
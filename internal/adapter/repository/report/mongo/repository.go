@@ -0,0 +1,443 @@
+package mongo_report_repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	repository_crypto "github.com/ThePositree/billing_manager/internal/adapter/repository/crypto"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/usecase/reporting"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var _ reporting.ReportRepository = &reportRepository{}
+
+type Config struct {
+	Database          string
+	BillingCollection string
+	UserCollection    string
+	Encryption        repository_crypto.Config
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Database == "" {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if cfg.BillingCollection == "" {
+		return fmt.Errorf("billing collection name cannot be empty")
+	}
+	if cfg.UserCollection == "" {
+		return fmt.Errorf("user collection name cannot be empty")
+	}
+	if err := cfg.Encryption.Validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type reportRepository struct {
+	billingColl    *mongo.Collection
+	userCollection string
+	client         *mongo.Client
+	// cipher decrypts telegram usernames joined from the user collection.
+	cipher *repository_crypto.Cipher
+}
+
+// periodFormats are $dateToString formats used as period labels,
+// weeks are ISO weeks so they never split across years.
+var periodFormats = map[reporting.Period]string{
+	reporting.PeriodDay:   "%Y-%m-%d",
+	reporting.PeriodWeek:  "%G-W%V",
+	reporting.PeriodMonth: "%Y-%m",
+}
+
+func (r *reportRepository) CountByState(ctx context.Context) (map[model_billing.State]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$state"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	var rows []struct {
+		State string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return map[model_billing.State]int{}, err
+	}
+
+	result := map[model_billing.State]int{}
+	for _, row := range rows {
+		state, err := model_billing.ParseState(row.State)
+		if err != nil {
+			return map[model_billing.State]int{}, err
+		}
+		result[state] = row.Count
+	}
+	return result, nil
+}
+
+func (r *reportRepository) CreatedPerPeriod(ctx context.Context, period reporting.Period, from time.Time, to time.Time) ([]reporting.PeriodCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "created_at", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "at", Value: "$created_at"},
+		}}},
+	}
+	return r.countPerPeriod(ctx, pipeline, period)
+}
+
+func (r *reportRepository) CompletedPerPeriod(ctx context.Context, period reporting.Period, from time.Time, to time.Time) ([]reporting.PeriodCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "state", Value: model_billing.StateCompleted.String()},
+		}}},
+		// A billing may be completed several times after reverts, the last completion counts.
+		{{Key: "$project", Value: bson.D{
+			{Key: "at", Value: bson.D{{Key: "$max", Value: bson.D{{Key: "$map", Value: bson.D{
+				{Key: "input", Value: bson.D{{Key: "$filter", Value: bson.D{
+					{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$state_changes", bson.A{}}}}},
+					{Key: "cond", Value: bson.D{{Key: "$eq", Value: bson.A{"$$this.state", model_billing.StateCompleted.String()}}}},
+				}}}},
+				{Key: "in", Value: "$$this.entered_at"},
+			}}}}}},
+		}}},
+		{{Key: "$match", Value: bson.D{
+			{Key: "at", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}},
+		}}},
+	}
+	return r.countPerPeriod(ctx, pipeline, period)
+}
+
+// countPerPeriod groups documents produced by the pipeline by their "at" field.
+func (r *reportRepository) countPerPeriod(ctx context.Context, pipeline mongo.Pipeline, period reporting.Period) ([]reporting.PeriodCount, error) {
+	format, ok := periodFormats[period]
+	if !ok {
+		return []reporting.PeriodCount{}, reporting.ErrInvalidPeriod
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$dateToString", Value: bson.D{
+				{Key: "format", Value: format},
+				{Key: "date", Value: "$at"},
+			}}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	)
+
+	var rows []struct {
+		Period string `bson:"_id"`
+		Count  int    `bson:"count"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return []reporting.PeriodCount{}, err
+	}
+
+	result := []reporting.PeriodCount{}
+	for _, row := range rows {
+		result = append(result, reporting.PeriodCount{Period: row.Period, Count: row.Count})
+	}
+	return result, nil
+}
+
+// totalExpr is the invoiced amount of a billing: its price plus all line items.
+var totalExpr = bson.D{{Key: "$add", Value: bson.A{
+	bson.D{{Key: "$ifNull", Value: bson.A{"$price", 0}}},
+	bson.D{{Key: "$sum", Value: "$line_items.amount"}},
+}}}
+
+func (r *reportRepository) InvoicedPerPeriod(ctx context.Context, period reporting.Period, from time.Time, to time.Time) ([]reporting.PeriodAmount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "invoiced_at", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "at", Value: "$invoiced_at"},
+			{Key: "amount", Value: totalExpr},
+		}}},
+	}
+	return r.sumPerPeriod(ctx, pipeline, period)
+}
+
+func (r *reportRepository) CollectedPerPeriod(ctx context.Context, period reporting.Period, from time.Time, to time.Time) ([]reporting.PeriodAmount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$unwind", Value: "$payments"}},
+		{{Key: "$match", Value: bson.D{
+			{Key: "payments.paid_at", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "at", Value: "$payments.paid_at"},
+			{Key: "amount", Value: "$payments.amount"},
+		}}},
+	}
+	return r.sumPerPeriod(ctx, pipeline, period)
+}
+
+// sumPerPeriod sums the "amount" field of documents produced by the pipeline
+// grouped by their "at" field.
+func (r *reportRepository) sumPerPeriod(ctx context.Context, pipeline mongo.Pipeline, period reporting.Period) ([]reporting.PeriodAmount, error) {
+	format, ok := periodFormats[period]
+	if !ok {
+		return []reporting.PeriodAmount{}, reporting.ErrInvalidPeriod
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$dateToString", Value: bson.D{
+				{Key: "format", Value: format},
+				{Key: "date", Value: "$at"},
+			}}}},
+			{Key: "amount", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	)
+
+	var rows []struct {
+		Period string `bson:"_id"`
+		Amount int64  `bson:"amount"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return []reporting.PeriodAmount{}, err
+	}
+
+	result := []reporting.PeriodAmount{}
+	for _, row := range rows {
+		result = append(result, reporting.PeriodAmount{Period: row.Period, Amount: row.Amount})
+	}
+	return result, nil
+}
+
+// ageBucketExpr labels an age in days with the largest lower bound not
+// above it. Ages below the first bound get the last bound, as in the
+// computed repository.
+func ageBucketExpr(ageDays any, lowerBounds []int) bson.D {
+	branches := bson.A{}
+	for i := len(lowerBounds) - 1; i >= 0; i-- {
+		branches = append(branches, bson.D{
+			{Key: "case", Value: bson.D{{Key: "$gte", Value: bson.A{ageDays, lowerBounds[i]}}}},
+			{Key: "then", Value: lowerBounds[i]},
+		})
+	}
+	return bson.D{{Key: "$switch", Value: bson.D{
+		{Key: "branches", Value: branches},
+		{Key: "default", Value: lowerBounds[len(lowerBounds)-1]},
+	}}}
+}
+
+// ReceivablesByAge only counts payments made up to asOf so the report can be
+// rebuilt for past dates, invoices paid in full are left out.
+func (r *reportRepository) ReceivablesByAge(ctx context.Context, asOf time.Time, lowerBounds []int) ([]reporting.AgingBucket, error) {
+	if len(lowerBounds) == 0 {
+		return []reporting.AgingBucket{}, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "invoiced_at", Value: bson.D{{Key: "$gt", Value: time.Time{}}, {Key: "$lte", Value: asOf}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "outstanding", Value: bson.D{{Key: "$subtract", Value: bson.A{
+				totalExpr,
+				bson.D{{Key: "$sum", Value: bson.D{{Key: "$map", Value: bson.D{
+					{Key: "input", Value: bson.D{{Key: "$filter", Value: bson.D{
+						{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$payments", bson.A{}}}}},
+						{Key: "cond", Value: bson.D{{Key: "$lte", Value: bson.A{"$$this.paid_at", asOf}}}},
+					}}}},
+					{Key: "in", Value: "$$this.amount"},
+				}}}}},
+			}}}},
+			{Key: "age_days", Value: bson.D{{Key: "$floor", Value: bson.D{{Key: "$divide", Value: bson.A{
+				bson.D{{Key: "$subtract", Value: bson.A{asOf, "$invoiced_at"}}},
+				int64(24 * time.Hour / time.Millisecond),
+			}}}}}},
+		}}},
+		{{Key: "$match", Value: bson.D{
+			{Key: "outstanding", Value: bson.D{{Key: "$gt", Value: 0}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: ageBucketExpr("$age_days", lowerBounds)},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "outstanding", Value: bson.D{{Key: "$sum", Value: "$outstanding"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	var rows []struct {
+		MinDays     int   `bson:"_id"`
+		Count       int   `bson:"count"`
+		Outstanding int64 `bson:"outstanding"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return []reporting.AgingBucket{}, err
+	}
+
+	result := make([]reporting.AgingBucket, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, reporting.AgingBucket{MinDays: row.MinDays, Count: row.Count, Outstanding: row.Outstanding})
+	}
+	return result, nil
+}
+
+// StageDurations splits the state history of every billing into intervals
+// between consecutive state changes and computes nearest-rank percentiles
+// of the interval lengths per state.
+func (r *reportRepository) StageDurations(ctx context.Context) (map[model_billing.State]reporting.DurationStats, error) {
+	percentile := func(p float64) bson.D {
+		return bson.D{{Key: "$arrayElemAt", Value: bson.A{
+			"$durations",
+			bson.D{{Key: "$toInt", Value: bson.D{{Key: "$subtract", Value: bson.A{
+				bson.D{{Key: "$ceil", Value: bson.D{{Key: "$multiply", Value: bson.A{p, "$samples"}}}}},
+				1,
+			}}}}},
+		}}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "state_changes.1", Value: bson.D{{Key: "$exists", Value: true}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "intervals", Value: bson.D{{Key: "$map", Value: bson.D{
+				{Key: "input", Value: bson.D{{Key: "$range", Value: bson.A{
+					0,
+					bson.D{{Key: "$subtract", Value: bson.A{bson.D{{Key: "$size", Value: "$state_changes"}}, 1}}},
+				}}}},
+				{Key: "as", Value: "i"},
+				{Key: "in", Value: bson.D{
+					{Key: "state", Value: bson.D{{Key: "$arrayElemAt", Value: bson.A{"$state_changes.state", "$$i"}}}},
+					{Key: "ms", Value: bson.D{{Key: "$subtract", Value: bson.A{
+						bson.D{{Key: "$arrayElemAt", Value: bson.A{"$state_changes.entered_at", bson.D{{Key: "$add", Value: bson.A{"$$i", 1}}}}}},
+						bson.D{{Key: "$arrayElemAt", Value: bson.A{"$state_changes.entered_at", "$$i"}}},
+					}}}},
+				}},
+			}}}},
+		}}},
+		{{Key: "$unwind", Value: "$intervals"}},
+		{{Key: "$sort", Value: bson.D{{Key: "intervals.ms", Value: 1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$intervals.state"},
+			{Key: "durations", Value: bson.D{{Key: "$push", Value: "$intervals.ms"}}},
+			{Key: "samples", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "samples", Value: 1},
+			{Key: "median", Value: percentile(0.5)},
+			{Key: "p90", Value: percentile(0.9)},
+		}}},
+	}
+
+	var rows []struct {
+		State   string `bson:"_id"`
+		Samples int    `bson:"samples"`
+		Median  int64  `bson:"median"`
+		P90     int64  `bson:"p90"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return map[model_billing.State]reporting.DurationStats{}, err
+	}
+
+	result := map[model_billing.State]reporting.DurationStats{}
+	for _, row := range rows {
+		state, err := model_billing.ParseState(row.State)
+		if err != nil {
+			return map[model_billing.State]reporting.DurationStats{}, err
+		}
+		result[state] = reporting.DurationStats{
+			Samples: row.Samples,
+			Median:  time.Duration(row.Median) * time.Millisecond,
+			P90:     time.Duration(row.P90) * time.Millisecond,
+		}
+	}
+	return result, nil
+}
+
+func (r *reportRepository) TopClients(ctx context.Context, limit int) ([]reporting.ClientCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$user_id"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: r.userCollection},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "user"},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "count", Value: 1},
+			{Key: "telegram_username", Value: bson.D{{Key: "$arrayElemAt", Value: bson.A{"$user.telegram_username", 0}}}},
+		}}},
+	}
+
+	var rows []struct {
+		UserId     string `bson:"_id"`
+		Count      int    `bson:"count"`
+		TelegramUN string `bson:"telegram_username"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return []reporting.ClientCount{}, err
+	}
+
+	result := []reporting.ClientCount{}
+	for _, row := range rows {
+		telegramUN, err := r.cipher.Decrypt(row.TelegramUN)
+		if err != nil {
+			return []reporting.ClientCount{}, fmt.Errorf("decrypt telegram username: %w", err)
+		}
+		result = append(result, reporting.ClientCount{
+			UserId:     row.UserId,
+			TelegramUN: telegramUN,
+			Count:      row.Count,
+		})
+	}
+	return result, nil
+}
+
+// aggregate runs the pipeline over billings that are not soft deleted.
+func (r *reportRepository) aggregate(ctx context.Context, pipeline mongo.Pipeline, rows any) error {
+	live := bson.D{{Key: "$match", Value: bson.D{{Key: "deleted_at", Value: nil}}}}
+	cursor, err := r.billingColl.Aggregate(ctx, append(mongo.Pipeline{live}, pipeline...))
+	if err != nil {
+		return fmt.Errorf("mongo aggregate: %w", err)
+	}
+	if err := cursor.All(ctx, rows); err != nil {
+		return fmt.Errorf("cursor all: %w", err)
+	}
+	return nil
+}
+
+func New(ctx context.Context, logger zerolog.Logger, client *mongo.Client, cfg Config) (*reportRepository, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return &reportRepository{}, fmt.Errorf("config validate: %w", err)
+	}
+
+	cipher, err := repository_crypto.New(cfg.Encryption)
+	if err != nil {
+		return &reportRepository{}, fmt.Errorf("create cipher: %w", err)
+	}
+
+	reportRepo := &reportRepository{
+		client:         client,
+		userCollection: cfg.UserCollection,
+		cipher:         cipher,
+	}
+
+	if err = reportRepo.client.Ping(ctx, nil); err != nil {
+		return &reportRepository{}, fmt.Errorf("mongo ping: %w", err)
+	}
+
+	reportRepo.billingColl = reportRepo.client.Database(cfg.Database).Collection(cfg.BillingCollection)
+
+	return reportRepo, nil
+}
@@ -0,0 +1,108 @@
+package mongo_report_repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/usecase/reporting"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var testLowerBounds = []int{0, 31, 61, 91}
+
+// evalSwitch evaluates the $switch built by ageBucketExpr for one age the
+// way mongo does: the first branch whose $gte holds wins.
+func evalSwitch(t *testing.T, expr bson.D, ageDays int) int {
+	t.Helper()
+	require.Equal(t, "$switch", expr[0].Key)
+	fields := expr[0].Value.(bson.D).Map()
+	for _, branch := range fields["branches"].(bson.A) {
+		branchFields := branch.(bson.D).Map()
+		gte := branchFields["case"].(bson.D).Map()["$gte"].(bson.A)
+		if ageDays >= gte[1].(int) {
+			return branchFields["then"].(int)
+		}
+	}
+	return fields["default"].(int)
+}
+
+func TestAgeBucketExpr(t *testing.T) {
+	expr := ageBucketExpr("$age_days", testLowerBounds)
+
+	tests := []struct {
+		ageDays int
+		want    int
+	}{
+		{ageDays: 0, want: 0},
+		{ageDays: 30, want: 0},
+		{ageDays: 31, want: 31},
+		{ageDays: 60, want: 31},
+		{ageDays: 61, want: 61},
+		{ageDays: 90, want: 61},
+		{ageDays: 91, want: 91},
+		{ageDays: 5000, want: 91},
+		// Ages below the first bound go to the last bucket like in the
+		// computed repository.
+		{ageDays: -1, want: 91},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.want, evalSwitch(t, expr, test.ageDays), "age %d", test.ageDays)
+	}
+}
+
+// TestReceivablesByAge runs the pipeline against the mongod at
+// BILLING_TEST_MONGO_URI in a throwaway database.
+func TestReceivablesByAge(t *testing.T) {
+	uri := os.Getenv("BILLING_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("BILLING_TEST_MONGO_URI is not set")
+	}
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(ctx) })
+
+	database := "billing_manager_test_" + uuid.NewString()[:8]
+	t.Cleanup(func() { client.Database(database).Drop(ctx) })
+
+	repo, err := New(ctx, zerolog.Nop(), client, Config{
+		Database:          database,
+		BillingCollection: "billings",
+		UserCollection:    "users",
+	})
+	require.NoError(t, err)
+
+	asOf := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time {
+		return asOf.Add(-time.Duration(days) * 24 * time.Hour)
+	}
+	_, err = client.Database(database).Collection("billings").InsertMany(ctx, []any{
+		bson.M{"_id": uuid.NewString(), "price": int64(100), "invoiced_at": daysAgo(10)},
+		bson.M{"_id": uuid.NewString(), "price": int64(200), "invoiced_at": daysAgo(45),
+			"payments": bson.A{bson.M{"amount": int64(50), "paid_at": daysAgo(1)}}},
+		bson.M{"_id": uuid.NewString(), "price": int64(300), "invoiced_at": daysAgo(91)},
+		bson.M{"_id": uuid.NewString(), "price": int64(400), "invoiced_at": daysAgo(400)},
+		// Paid in full, not invoiced yet and invoiced after asOf are left out.
+		bson.M{"_id": uuid.NewString(), "price": int64(500), "invoiced_at": daysAgo(5),
+			"payments": bson.A{bson.M{"amount": int64(500), "paid_at": daysAgo(1)}}},
+		bson.M{"_id": uuid.NewString(), "price": int64(600), "invoiced_at": time.Time{}},
+		bson.M{"_id": uuid.NewString(), "price": int64(700), "invoiced_at": asOf.Add(time.Hour)},
+	})
+	require.NoError(t, err)
+
+	buckets, err := repo.ReceivablesByAge(ctx, asOf, testLowerBounds)
+	require.NoError(t, err)
+	require.Equal(t, []reporting.AgingBucket{
+		{MinDays: 0, Count: 1, Outstanding: 100},
+		{MinDays: 31, Count: 1, Outstanding: 150},
+		{MinDays: 91, Count: 2, Outstanding: 700},
+	}, buckets)
+}
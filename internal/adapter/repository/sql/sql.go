@@ -0,0 +1,76 @@
+package sql_repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TimeLayout has a fixed width so that times stored as text sort in order.
+const TimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// SQLiteDSN opens the sqlite database at path with foreign keys and WAL so
+// readers do not block the writer, busy_timeout makes concurrent writers
+// wait instead of failing and _txlock=immediate takes the write lock when a
+// transaction begins.
+func SQLiteDSN(path string) string {
+	return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+}
+
+// Row is the part of *sql.Row and *sql.Rows the scan functions use.
+type Row interface {
+	Scan(dest ...any) error
+}
+
+// TimeValue converts a time into the value stored in a time column, the
+// dialects differ in how they store times and unset ones.
+type TimeValue func(t time.Time) any
+
+// NullTime stores the zero time as NULL, postgres uses it for timestamptz columns.
+func NullTime(t time.Time) any {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// TextTime stores times as UTC text and the zero time as an empty string,
+// sqlite uses it for TEXT columns.
+func TextTime(t time.Time) any {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(TimeLayout)
+}
+
+// TimeScanner reads a time column written by NullTime or TextTime into
+// Time in UTC, NULL and an empty string give the zero time.
+type TimeScanner struct {
+	Time *time.Time
+}
+
+func (s TimeScanner) Scan(src any) error {
+	switch value := src.(type) {
+	case nil:
+		*s.Time = time.Time{}
+	case time.Time:
+		*s.Time = value.UTC()
+	case string:
+		return s.parse(value)
+	case []byte:
+		return s.parse(string(value))
+	default:
+		return fmt.Errorf("unsupported time column type %T", src)
+	}
+	return nil
+}
+
+func (s TimeScanner) parse(value string) error {
+	if value == "" {
+		*s.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.Parse(TimeLayout, value)
+	if err != nil {
+		return fmt.Errorf("time parse: %w", err)
+	}
+	*s.Time = parsed.UTC()
+	return nil
+}
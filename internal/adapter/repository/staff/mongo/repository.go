@@ -0,0 +1,184 @@
+package mongo_staff_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	repository_cache "github.com/ThePositree/billing_manager/internal/adapter/repository/cache"
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/staff/mongo/dto"
+	model_staff "github.com/ThePositree/billing_manager/internal/model/staff"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var _ usecase.StaffRepository = &staffRepository{}
+
+type Config struct {
+	Database   string
+	Collection string
+	Cache      repository_cache.Config
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Database == "" {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if cfg.Collection == "" {
+		return fmt.Errorf("collection name cannot be empty")
+	}
+	if err := cfg.Cache.Validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type staffRepository struct {
+	coll   *mongo.Collection
+	client *mongo.Client
+	cache  *repository_cache.Cache[model_staff.Staff]
+}
+
+var ErrNoData = errors.New("no data")
+
+func (s *staffRepository) GetNoDataError() error {
+	return ErrNoData
+}
+
+func (s *staffRepository) Create(ctx context.Context, staff model_staff.Staff) (model_staff.Staff, error) {
+	staffDto := dto.NewStaffDTOFromModel(staff)
+
+	_, err := s.coll.InsertOne(ctx, staffDto)
+	if err != nil {
+		return model_staff.Staff{}, fmt.Errorf("mongo insert one: %w", err)
+	}
+
+	s.cache.Set(ctx, staff.Id, staff)
+
+	return staff, nil
+}
+
+func (s *staffRepository) Delete(ctx context.Context, id string) (model_staff.Staff, error) {
+	result := s.coll.FindOneAndDelete(ctx, bson.D{{Key: "_id", Value: id}})
+	err := result.Err()
+	if errors.Is(mongo.ErrNoDocuments, err) {
+		return model_staff.Staff{}, ErrNoData
+	}
+	if err != nil {
+		return model_staff.Staff{}, fmt.Errorf("mongo find one and delete: %w", err)
+	}
+
+	var staffDTO dto.Staff
+
+	if err := result.Decode(&staffDTO); err != nil {
+		return model_staff.Staff{}, fmt.Errorf("result decode: %w", err)
+	}
+
+	staff, err := staffDTO.ToModel()
+	if err != nil {
+		return model_staff.Staff{}, fmt.Errorf("dto to model: %w", err)
+	}
+	s.cache.Delete(ctx, staff.Id)
+
+	return staff, nil
+}
+
+func (s *staffRepository) Get(ctx context.Context, id string) (model_staff.Staff, error) {
+	staff, ok := s.cache.Get(ctx, id)
+	if ok {
+		return staff, nil
+	}
+	result := s.coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}})
+
+	err := result.Err()
+	if errors.Is(mongo.ErrNoDocuments, err) {
+		return model_staff.Staff{}, ErrNoData
+	}
+	if err != nil {
+		return model_staff.Staff{}, fmt.Errorf("mongo find one: %w", err)
+	}
+
+	var staffDTO dto.Staff
+
+	if err := result.Decode(&staffDTO); err != nil {
+		return model_staff.Staff{}, fmt.Errorf("result decode: %w", err)
+	}
+
+	staff, err = staffDTO.ToModel()
+	if err != nil {
+		return model_staff.Staff{}, fmt.Errorf("dto to model: %w", err)
+	}
+	s.cache.Set(ctx, staff.Id, staff)
+
+	return staff, nil
+}
+
+func (s *staffRepository) GetAll(ctx context.Context) ([]model_staff.Staff, error) {
+	if s.cache.Enabled(ctx) {
+		return s.cache.Values(), nil
+	}
+	cursor, err := s.coll.Find(ctx, bson.D{})
+	if err != nil {
+		return []model_staff.Staff{}, fmt.Errorf("mongo find: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var staffMembers []model_staff.Staff
+
+	for {
+		if cursor.TryNext(ctx) {
+			var result dto.Staff
+			if err := cursor.Decode(&result); err != nil {
+				return []model_staff.Staff{}, fmt.Errorf("result decode: %w", err)
+			}
+			staff, err := result.ToModel()
+			if err != nil {
+				return []model_staff.Staff{}, fmt.Errorf("dto to model: %w", err)
+			}
+			staffMembers = append(staffMembers, staff)
+			continue
+		}
+		if err := cursor.Err(); err != nil {
+			return []model_staff.Staff{}, fmt.Errorf("cursor error: %w", err)
+		}
+		if cursor.ID() == 0 {
+			break
+		}
+	}
+
+	return staffMembers, nil
+}
+
+func New(ctx context.Context, logger zerolog.Logger, client *mongo.Client, cfg Config) (*staffRepository, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return &staffRepository{}, fmt.Errorf("config validate: %w", err)
+	}
+
+	staffRepo := &staffRepository{
+		client: client,
+		cache:  repository_cache.New[model_staff.Staff]("staff", cfg.Cache),
+	}
+
+	if err = staffRepo.client.Ping(ctx, nil); err != nil {
+		return &staffRepository{}, fmt.Errorf("mongo ping: %w", err)
+	}
+
+	staffRepo.coll = staffRepo.client.Database(cfg.Database).Collection(cfg.Collection)
+
+	err = staffRepo.cache.Start(ctx, logger, staffRepo.coll, func(raw bson.Raw) (string, model_staff.Staff, error) {
+		var staffDTO dto.Staff
+		if err := bson.Unmarshal(raw, &staffDTO); err != nil {
+			return "", model_staff.Staff{}, err
+		}
+		staff, err := staffDTO.ToModel()
+		return staff.Id, staff, err
+	}, cfg.Cache.PollInterval)
+	if err != nil {
+		return &staffRepository{}, fmt.Errorf("start cache: %w", err)
+	}
+
+	return staffRepo, nil
+}
@@ -0,0 +1,87 @@
+package postgres_staff_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	sql_transaction "github.com/ThePositree/billing_manager/internal/adapter/transaction/sql"
+
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/staff/sql/dto"
+	model_staff "github.com/ThePositree/billing_manager/internal/model/staff"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/rs/zerolog"
+)
+
+var _ usecase.StaffRepository = &staffRepository{}
+
+var ErrNoData = errors.New("no data")
+
+type staffRepository struct {
+	db *sql.DB
+}
+
+func (s *staffRepository) GetNoDataError() error {
+	return ErrNoData
+}
+
+func (s *staffRepository) Create(ctx context.Context, staff model_staff.Staff) (model_staff.Staff, error) {
+	staffDTO := dto.NewStaffDTOFromModel(staff)
+
+	_, err := sql_transaction.GetExecutor(ctx, s.db).ExecContext(ctx,
+		"INSERT INTO staff ("+dto.Columns+") VALUES ($1, $2)",
+		dto.Args(staffDTO)...,
+	)
+	if err != nil {
+		return model_staff.Staff{}, fmt.Errorf("sql insert: %w", err)
+	}
+	return staff, nil
+}
+
+func (s *staffRepository) Delete(ctx context.Context, id string) (model_staff.Staff, error) {
+	row := sql_transaction.GetExecutor(ctx, s.db).QueryRowContext(ctx, "DELETE FROM staff WHERE id = $1 RETURNING "+dto.Columns, id)
+	return scanStaff(row)
+}
+
+func (s *staffRepository) Get(ctx context.Context, id string) (model_staff.Staff, error) {
+	row := sql_transaction.GetExecutor(ctx, s.db).QueryRowContext(ctx, "SELECT "+dto.Columns+" FROM staff WHERE id = $1", id)
+	return scanStaff(row)
+}
+
+func (s *staffRepository) GetAll(ctx context.Context) ([]model_staff.Staff, error) {
+	rows, err := sql_transaction.GetExecutor(ctx, s.db).QueryContext(ctx, "SELECT "+dto.Columns+" FROM staff ORDER BY id")
+	if err != nil {
+		return []model_staff.Staff{}, fmt.Errorf("sql query: %w", err)
+	}
+	defer rows.Close()
+
+	var staffMembers []model_staff.Staff
+	for rows.Next() {
+		staff, err := scanStaff(rows)
+		if err != nil {
+			return []model_staff.Staff{}, err
+		}
+		staffMembers = append(staffMembers, staff)
+	}
+	if err := rows.Err(); err != nil {
+		return []model_staff.Staff{}, fmt.Errorf("rows error: %w", err)
+	}
+	return staffMembers, nil
+}
+
+func scanStaff(row sql_repository.Row) (model_staff.Staff, error) {
+	staff, err := dto.Scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model_staff.Staff{}, ErrNoData
+	}
+	return staff, err
+}
+
+// New expects the schema to be migrated, see postgres_migration.
+func New(ctx context.Context, logger zerolog.Logger, db *sql.DB) (*staffRepository, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return &staffRepository{}, fmt.Errorf("sql ping: %w", err)
+	}
+	return &staffRepository{db: db}, nil
+}
@@ -0,0 +1,27 @@
+package dto
+
+import "github.com/ThePositree/billing_manager/internal/model/staff"
+
+type Staff struct {
+	Id   string
+	Name string
+}
+
+func (s Staff) GetId() string {
+	return s.Id
+}
+
+func (s Staff) GetName() string {
+	return s.Name
+}
+
+func (s Staff) ToModel() (staff.Staff, error) {
+	return staff.ToModelFromDTO(s)
+}
+
+func NewStaffDTOFromModel(staff staff.Staff) Staff {
+	return Staff{
+		Id:   staff.Id,
+		Name: staff.Name,
+	}
+}
@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"fmt"
+
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	"github.com/ThePositree/billing_manager/internal/model/staff"
+)
+
+// Columns are listed in the order Args and Scan use.
+const Columns = "id, name"
+
+func Args(staffDTO Staff) []any {
+	return []any{staffDTO.Id, staffDTO.Name}
+}
+
+// Scan wraps sql.ErrNoRows when there is no row.
+func Scan(row sql_repository.Row) (staff.Staff, error) {
+	var staffDTO Staff
+	if err := row.Scan(&staffDTO.Id, &staffDTO.Name); err != nil {
+		return staff.Staff{}, fmt.Errorf("row scan: %w", err)
+	}
+
+	result, err := staffDTO.ToModel()
+	if err != nil {
+		return staff.Staff{}, fmt.Errorf("dto to model: %w", err)
+	}
+	return result, nil
+}
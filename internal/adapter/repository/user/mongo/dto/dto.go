@@ -1,10 +1,21 @@
 package dto
 
-import "github.com/ThePositree/billing_manager/internal/model/user"
+import (
+	"fmt"
+	"time"
 
+	repository_crypto "github.com/ThePositree/billing_manager/internal/adapter/repository/crypto"
+	"github.com/ThePositree/billing_manager/internal/model/user"
+)
+
+// User holds the telegram username encrypted when encryption is on,
+// TelegramUNIndex is its blind index used for lookups.
 type User struct {
-	Id         string `bson:"_id"`
-	TelegramUN string `bson:"telegram_username"`
+	Id              string     `bson:"_id"`
+	TelegramUN      string     `bson:"telegram_username"`
+	TelegramUNIndex string     `bson:"telegram_username_index,omitempty"`
+	DeletedAt       *time.Time `bson:"deleted_at,omitempty"`
+	DeletedBy       string     `bson:"deleted_by,omitempty"`
 }
 
 func (u User) GetId() string {
@@ -15,13 +26,40 @@ func (u User) GetTelegramUN() string {
 	return u.TelegramUN
 }
 
-func (u User) ToModel() (user.User, error) {
+func (u User) GetDeletedAt() time.Time {
+	if u.DeletedAt == nil {
+		return time.Time{}
+	}
+	return *u.DeletedAt
+}
+
+func (u User) GetDeletedBy() string {
+	return u.DeletedBy
+}
+
+func (u User) ToModel(cipher *repository_crypto.Cipher) (user.User, error) {
+	telegramUN, err := cipher.Decrypt(u.TelegramUN)
+	if err != nil {
+		return user.User{}, fmt.Errorf("telegram username: %w", err)
+	}
+	u.TelegramUN = telegramUN
 	return user.ToModelFromDTO(u)
 }
 
-func NewUserDTOFromModel(user user.User) User {
-	return User{
-		Id:         user.Id,
-		TelegramUN: user.TelegramUN,
+func NewUserDTOFromModel(user user.User, cipher *repository_crypto.Cipher) (User, error) {
+	telegramUN, err := cipher.Encrypt(user.TelegramUN)
+	if err != nil {
+		return User{}, fmt.Errorf("telegram username: %w", err)
 	}
+	var deletedAt *time.Time
+	if userDeletedAt := user.GetDeletedAt(); !userDeletedAt.IsZero() {
+		deletedAt = &userDeletedAt
+	}
+	return User{
+		Id:              user.Id,
+		TelegramUN:      telegramUN,
+		TelegramUNIndex: cipher.BlindIndex(user.TelegramUN),
+		DeletedAt:       deletedAt,
+		DeletedBy:       user.GetDeletedBy(),
+	}, nil
 }
@@ -4,10 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sync"
+	"time"
 
+	repository_cache "github.com/ThePositree/billing_manager/internal/adapter/repository/cache"
+	repository_crypto "github.com/ThePositree/billing_manager/internal/adapter/repository/crypto"
 	"github.com/ThePositree/billing_manager/internal/adapter/repository/user/mongo/dto"
-	"github.com/ThePositree/billing_manager/internal/model/user"
 	model_user "github.com/ThePositree/billing_manager/internal/model/user"
 	"github.com/ThePositree/billing_manager/internal/usecase"
 	"github.com/rs/zerolog"
@@ -20,6 +21,8 @@ var _ usecase.UserRepository = &userRepository{}
 type Config struct {
 	Database   string
 	Collection string
+	Cache      repository_cache.Config
+	Encryption repository_crypto.Config
 }
 
 func (cfg Config) Validate() error {
@@ -29,50 +32,113 @@ func (cfg Config) Validate() error {
 	if cfg.Collection == "" {
 		return fmt.Errorf("collection name cannot be empty")
 	}
+	if err := cfg.Cache.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.Encryption.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
 type userRepository struct {
 	coll   *mongo.Collection
 	client *mongo.Client
-	mutex  sync.RWMutex
-	cache  map[string]user.User
+	cache  *repository_cache.Cache[model_user.User]
+	cipher *repository_crypto.Cipher
 }
 
-var ErrNoData = errors.New("no data")
+var (
+	ErrNoData        = errors.New("no data")
+	ErrAlreadyExists = errors.New("already exists")
+)
+
+var (
+	// live matches the users that are not soft deleted.
+	live    = bson.E{Key: "deleted_at", Value: nil}
+	deleted = bson.E{Key: "deleted_at", Value: bson.D{{Key: "$ne", Value: nil}}}
+)
 
 func (u *userRepository) GetNoDataError() error {
 	return ErrNoData
 }
 
+func (u *userRepository) GetAlreadyExistsError() error {
+	return ErrAlreadyExists
+}
+
 func (u *userRepository) Create(ctx context.Context, user model_user.User) (model_user.User, error) {
-	userDto := dto.NewUserDTOFromModel(user)
+	userDto, err := dto.NewUserDTOFromModel(user, u.cipher)
+	if err != nil {
+		return model_user.User{}, fmt.Errorf("model to dto: %w", err)
+	}
 
-	_, err := u.coll.InsertOne(ctx, userDto)
+	_, err = u.coll.InsertOne(ctx, userDto)
+	// The unique telegram_username index rejects concurrent registrations.
+	if mongo.IsDuplicateKeyError(err) {
+		return model_user.User{}, ErrAlreadyExists
+	}
 	if err != nil {
 		return model_user.User{}, fmt.Errorf("mongo insert one: %w", err)
 	}
 
-	u.mutex.Lock()
-	u.cache[user.Id] = user
-	u.mutex.Unlock()
+	u.cache.Set(ctx, user.Id, user)
+
+	return user, nil
+}
+
+func (u *userRepository) Update(ctx context.Context, user model_user.User) (model_user.User, error) {
+	userDto, err := dto.NewUserDTOFromModel(user, u.cipher)
+	if err != nil {
+		return model_user.User{}, fmt.Errorf("model to dto: %w", err)
+	}
+
+	result, err := u.coll.ReplaceOne(ctx, bson.D{{Key: "_id", Value: user.Id}}, userDto)
+	if mongo.IsDuplicateKeyError(err) {
+		return model_user.User{}, ErrAlreadyExists
+	}
+	if err != nil {
+		return model_user.User{}, fmt.Errorf("mongo replace one: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return model_user.User{}, ErrNoData
+	}
+
+	u.cache.Set(ctx, user.Id, user)
 
 	return user, nil
 }
 
 func (u *userRepository) GetByTelegramUN(ctx context.Context, telegramUN string) (model_user.User, error) {
-	u.mutex.RLock()
-	defer u.mutex.RUnlock()
-	for _, user := range u.cache {
-		if user.TelegramUN == telegramUN {
-			return user, nil
+	if u.cache.Enabled(ctx) {
+		users := u.cache.Filter(func(user model_user.User) bool {
+			return user.TelegramUN == telegramUN && !user.IsDeleted()
+		})
+		if len(users) == 0 {
+			return model_user.User{}, ErrNoData
 		}
+		return users[0], nil
 	}
-	return model_user.User{}, ErrNoData
+	return u.findOne(ctx, bson.D{u.telegramUNFilter(telegramUN), live})
 }
 
-func (u *userRepository) Delete(ctx context.Context, id string) (model_user.User, error) {
-	result := u.coll.FindOneAndDelete(ctx, bson.D{{Key: "_id", Value: id}})
+// telegramUNFilter looks encrypted usernames up by their blind index, users
+// stored before encryption was turned on are still matched by the plaintext.
+func (u *userRepository) telegramUNFilter(telegramUN string) bson.E {
+	if !u.cipher.Enabled() {
+		return bson.E{Key: "telegram_username", Value: telegramUN}
+	}
+	return bson.E{Key: "$or", Value: bson.A{
+		bson.D{{Key: "telegram_username_index", Value: u.cipher.BlindIndex(telegramUN)}},
+		bson.D{{Key: "telegram_username", Value: telegramUN}},
+	}}
+}
+
+func (u *userRepository) Delete(ctx context.Context, id string, deletedBefore time.Time) (model_user.User, error) {
+	result := u.coll.FindOneAndDelete(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "deleted_at", Value: bson.D{{Key: "$lt", Value: deletedBefore}}},
+	})
 	err := result.Err()
 	if errors.Is(mongo.ErrNoDocuments, err) {
 		return model_user.User{}, ErrNoData
@@ -87,25 +153,52 @@ func (u *userRepository) Delete(ctx context.Context, id string) (model_user.User
 		return model_user.User{}, fmt.Errorf("result decode: %w", err)
 	}
 
-	user, err := userDTO.ToModel()
+	user, err := userDTO.ToModel(u.cipher)
 	if err != nil {
 		return model_user.User{}, fmt.Errorf("dto to model: %w", err)
 	}
-	u.mutex.Lock()
-	delete(u.cache, user.Id)
-	u.mutex.Unlock()
+	u.cache.Delete(ctx, user.Id)
 
 	return user, nil
 }
 
 func (u *userRepository) Get(ctx context.Context, id string) (model_user.User, error) {
-	u.mutex.RLock()
-	user, ok := u.cache[id]
-	u.mutex.RUnlock()
-	if ok {
+	user, err := u.get(ctx, id)
+	if err != nil {
+		return model_user.User{}, err
+	}
+	if user.IsDeleted() {
+		return model_user.User{}, ErrNoData
+	}
+	return user, nil
+}
+
+func (u *userRepository) GetDeleted(ctx context.Context, id string) (model_user.User, error) {
+	user, err := u.get(ctx, id)
+	if err != nil {
+		return model_user.User{}, err
+	}
+	if !user.IsDeleted() {
+		return model_user.User{}, ErrNoData
+	}
+	return user, nil
+}
+
+func (u *userRepository) get(ctx context.Context, id string) (model_user.User, error) {
+	if user, ok := u.cache.Get(ctx, id); ok {
 		return user, nil
 	}
-	result := u.coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}})
+	user, err := u.findOne(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return model_user.User{}, err
+	}
+	u.cache.Set(ctx, user.Id, user)
+
+	return user, nil
+}
+
+func (u *userRepository) findOne(ctx context.Context, filter bson.D) (model_user.User, error) {
+	result := u.coll.FindOne(ctx, filter)
 
 	err := result.Err()
 	if errors.Is(mongo.ErrNoDocuments, err) {
@@ -121,26 +214,34 @@ func (u *userRepository) Get(ctx context.Context, id string) (model_user.User, e
 		return model_user.User{}, fmt.Errorf("result decode: %w", err)
 	}
 
-	user, err = userDTO.ToModel()
+	user, err := userDTO.ToModel(u.cipher)
 	if err != nil {
 		return model_user.User{}, fmt.Errorf("dto to model: %w", err)
 	}
-	u.mutex.Lock()
-	u.cache[user.Id] = user
-	u.mutex.Unlock()
 
 	return user, nil
 }
 
 func (u *userRepository) GetAll(ctx context.Context) ([]model_user.User, error) {
-	if len(u.cache) != 0 {
-		var result []model_user.User
-		for _, user := range u.cache {
-			result = append(result, user)
-		}
-		return result, nil
+	if u.cache.Enabled(ctx) {
+		return u.cache.Filter(func(user model_user.User) bool {
+			return !user.IsDeleted()
+		}), nil
+	}
+	return u.find(ctx, bson.D{live})
+}
+
+func (u *userRepository) GetAllDeleted(ctx context.Context) ([]model_user.User, error) {
+	if u.cache.Enabled(ctx) {
+		return u.cache.Filter(func(user model_user.User) bool {
+			return user.IsDeleted()
+		}), nil
 	}
-	cursor, err := u.coll.Find(ctx, bson.D{})
+	return u.find(ctx, bson.D{deleted})
+}
+
+func (u *userRepository) find(ctx context.Context, filter bson.D) ([]model_user.User, error) {
+	cursor, err := u.coll.Find(ctx, filter)
 	if err != nil {
 		return []model_user.User{}, fmt.Errorf("mongo find: %w", err)
 	}
@@ -154,7 +255,7 @@ func (u *userRepository) GetAll(ctx context.Context) ([]model_user.User, error)
 			if err := cursor.Decode(&result); err != nil {
 				return []model_user.User{}, fmt.Errorf("result decode: %w", err)
 			}
-			user, err := result.ToModel()
+			user, err := result.ToModel(u.cipher)
 			if err != nil {
 				return []model_user.User{}, fmt.Errorf("dto to model: %w", err)
 			}
@@ -178,8 +279,15 @@ func New(ctx context.Context, logger zerolog.Logger, client *mongo.Client, cfg C
 		return &userRepository{}, fmt.Errorf("config validate: %w", err)
 	}
 
+	cipher, err := repository_crypto.New(cfg.Encryption)
+	if err != nil {
+		return &userRepository{}, fmt.Errorf("create cipher: %w", err)
+	}
+
 	userRepo := &userRepository{
 		client: client,
+		cache:  repository_cache.New[model_user.User]("users", cfg.Cache),
+		cipher: cipher,
 	}
 
 	if err = userRepo.client.Ping(ctx, nil); err != nil {
@@ -190,18 +298,17 @@ func New(ctx context.Context, logger zerolog.Logger, client *mongo.Client, cfg C
 
 	userRepo.coll = coll
 
-	cache := map[string]user.User{}
-
-	users, err := userRepo.GetAll(ctx)
+	err = userRepo.cache.Start(ctx, logger, coll, func(raw bson.Raw) (string, model_user.User, error) {
+		var userDTO dto.User
+		if err := bson.Unmarshal(raw, &userDTO); err != nil {
+			return "", model_user.User{}, err
+		}
+		user, err := userDTO.ToModel(cipher)
+		return user.Id, user, err
+	}, cfg.Cache.PollInterval)
 	if err != nil {
-		return &userRepository{}, fmt.Errorf("get all users: %w", err)
+		return &userRepository{}, fmt.Errorf("start cache: %w", err)
 	}
 
-	for _, user := range users {
-		cache[user.Id] = user
-	}
-
-	userRepo.cache = cache
-
 	return userRepo, nil
 }
@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/user"
+)
+
+type User struct {
+	Id         string
+	TelegramUN string
+	DeletedAt  time.Time
+	DeletedBy  string
+}
+
+func (u User) GetId() string {
+	return u.Id
+}
+
+func (u User) GetTelegramUN() string {
+	return u.TelegramUN
+}
+
+func (u User) GetDeletedAt() time.Time {
+	return u.DeletedAt
+}
+
+func (u User) GetDeletedBy() string {
+	return u.DeletedBy
+}
+
+func (u User) ToModel() (user.User, error) {
+	return user.ToModelFromDTO(u)
+}
+
+func NewUserDTOFromModel(user user.User) User {
+	return User{
+		Id:         user.Id,
+		TelegramUN: user.TelegramUN,
+		DeletedAt:  user.GetDeletedAt(),
+		DeletedBy:  user.GetDeletedBy(),
+	}
+}
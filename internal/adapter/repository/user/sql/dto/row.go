@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"fmt"
+
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	"github.com/ThePositree/billing_manager/internal/model/user"
+)
+
+// Columns are listed in the order Args and Scan use.
+const Columns = "id, telegram_username, deleted_at, deleted_by"
+
+func Args(userDTO User, timeValue sql_repository.TimeValue) []any {
+	return []any{userDTO.Id, userDTO.TelegramUN, timeValue(userDTO.DeletedAt), userDTO.DeletedBy}
+}
+
+// Scan wraps sql.ErrNoRows when there is no row.
+func Scan(row sql_repository.Row) (user.User, error) {
+	var userDTO User
+	err := row.Scan(&userDTO.Id, &userDTO.TelegramUN, sql_repository.TimeScanner{Time: &userDTO.DeletedAt}, &userDTO.DeletedBy)
+	if err != nil {
+		return user.User{}, fmt.Errorf("row scan: %w", err)
+	}
+
+	result, err := userDTO.ToModel()
+	if err != nil {
+		return user.User{}, fmt.Errorf("dto to model: %w", err)
+	}
+	return result, nil
+}
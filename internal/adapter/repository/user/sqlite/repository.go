@@ -0,0 +1,161 @@
+package sqlite_user_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	sql_transaction "github.com/ThePositree/billing_manager/internal/adapter/transaction/sql"
+	"slices"
+	"time"
+
+	sql_repository "github.com/ThePositree/billing_manager/internal/adapter/repository/sql"
+	"github.com/ThePositree/billing_manager/internal/adapter/repository/user/sql/dto"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/rs/zerolog"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var _ usecase.UserRepository = &userRepository{}
+
+var (
+	ErrNoData        = errors.New("no data")
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrHasBillings is returned by Delete since billings keep a foreign
+	// key to their user.
+	ErrHasBillings = errors.New("user has billings")
+)
+
+// hasCode reports whether err is a SQLite error with one of the extended result codes.
+func hasCode(err error, codes ...int) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && slices.Contains(codes, sqliteErr.Code())
+}
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func (u *userRepository) GetNoDataError() error {
+	return ErrNoData
+}
+
+func (u *userRepository) GetAlreadyExistsError() error {
+	return ErrAlreadyExists
+}
+
+func (u *userRepository) Create(ctx context.Context, user model_user.User) (model_user.User, error) {
+	userDTO := dto.NewUserDTOFromModel(user)
+
+	_, err := sql_transaction.GetExecutor(ctx, u.db).ExecContext(ctx,
+		"INSERT INTO users ("+dto.Columns+") VALUES (?, ?, ?, ?)",
+		dto.Args(userDTO, sql_repository.TextTime)...,
+	)
+	if hasCode(err, sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY) {
+		return model_user.User{}, ErrAlreadyExists
+	}
+	if err != nil {
+		return model_user.User{}, fmt.Errorf("sql insert: %w", err)
+	}
+	return user, nil
+}
+
+func (u *userRepository) Update(ctx context.Context, user model_user.User) (model_user.User, error) {
+	args := dto.Args(dto.NewUserDTOFromModel(user), sql_repository.TextTime)
+
+	// The id goes last to match the WHERE clause.
+	result, err := sql_transaction.GetExecutor(ctx, u.db).ExecContext(ctx,
+		"UPDATE users SET telegram_username = ?, deleted_at = ?, deleted_by = ? WHERE id = ?",
+		append(args[1:], args[0])...,
+	)
+	if hasCode(err, sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY) {
+		return model_user.User{}, ErrAlreadyExists
+	}
+	if err != nil {
+		return model_user.User{}, fmt.Errorf("sql update: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return model_user.User{}, fmt.Errorf("rows affected: %w", err)
+	}
+	if updated == 0 {
+		return model_user.User{}, ErrNoData
+	}
+	return user, nil
+}
+
+func (u *userRepository) GetByTelegramUN(ctx context.Context, telegramUN string) (model_user.User, error) {
+	row := sql_transaction.GetExecutor(ctx, u.db).QueryRowContext(ctx, "SELECT "+dto.Columns+" FROM users WHERE telegram_username = ? AND deleted_at = ''", telegramUN)
+	return scanUser(row)
+}
+
+func (u *userRepository) Delete(ctx context.Context, id string, deletedBefore time.Time) (model_user.User, error) {
+	row := sql_transaction.GetExecutor(ctx, u.db).QueryRowContext(ctx,
+		"DELETE FROM users WHERE id = ? AND deleted_at != '' AND deleted_at < ? RETURNING "+dto.Columns,
+		id, sql_repository.TextTime(deletedBefore),
+	)
+	user, err := scanUser(row)
+	// With RETURNING the foreign key is checked when the statement ends,
+	// SQLite reports that violation with the trigger code.
+	if hasCode(err, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY, sqlite3.SQLITE_CONSTRAINT_TRIGGER) {
+		return model_user.User{}, ErrHasBillings
+	}
+	return user, err
+}
+
+func (u *userRepository) Get(ctx context.Context, id string) (model_user.User, error) {
+	row := sql_transaction.GetExecutor(ctx, u.db).QueryRowContext(ctx, "SELECT "+dto.Columns+" FROM users WHERE id = ? AND deleted_at = ''", id)
+	return scanUser(row)
+}
+
+func (u *userRepository) GetDeleted(ctx context.Context, id string) (model_user.User, error) {
+	row := sql_transaction.GetExecutor(ctx, u.db).QueryRowContext(ctx, "SELECT "+dto.Columns+" FROM users WHERE id = ? AND deleted_at != ''", id)
+	return scanUser(row)
+}
+
+func (u *userRepository) GetAll(ctx context.Context) ([]model_user.User, error) {
+	return u.query(ctx, "SELECT "+dto.Columns+" FROM users WHERE deleted_at = '' ORDER BY id")
+}
+
+func (u *userRepository) GetAllDeleted(ctx context.Context) ([]model_user.User, error) {
+	return u.query(ctx, "SELECT "+dto.Columns+" FROM users WHERE deleted_at != '' ORDER BY deleted_at, id")
+}
+
+func (u *userRepository) query(ctx context.Context, query string) ([]model_user.User, error) {
+	rows, err := sql_transaction.GetExecutor(ctx, u.db).QueryContext(ctx, query)
+	if err != nil {
+		return []model_user.User{}, fmt.Errorf("sql query: %w", err)
+	}
+	defer rows.Close()
+
+	var users []model_user.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return []model_user.User{}, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return []model_user.User{}, fmt.Errorf("rows error: %w", err)
+	}
+	return users, nil
+}
+
+func scanUser(row sql_repository.Row) (model_user.User, error) {
+	user, err := dto.Scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model_user.User{}, ErrNoData
+	}
+	return user, err
+}
+
+// New expects the schema to be migrated, see sqlite_migration.
+func New(ctx context.Context, logger zerolog.Logger, db *sql.DB) (*userRepository, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return &userRepository{}, fmt.Errorf("sql ping: %w", err)
+	}
+	return &userRepository{db: db}, nil
+}
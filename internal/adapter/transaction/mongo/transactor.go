@@ -0,0 +1,64 @@
+package mongo_transaction
+
+import (
+	"context"
+	"fmt"
+
+	repository_cache "github.com/ThePositree/billing_manager/internal/adapter/repository/cache"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var _ usecase.Transactor = transactor{}
+
+type transactor struct {
+	client *mongo.Client
+	// supported is false for a standalone server, which has no transactions.
+	supported bool
+}
+
+func (t transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !t.supported || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	// Cache changes of an attempt are applied only once it is committed,
+	// WithTransaction retries fn on transient errors.
+	var commitCache func()
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (any, error) {
+		var txCtx context.Context
+		txCtx, commitCache = repository_cache.WithTransaction(sessionCtx)
+		return nil, fn(txCtx)
+	})
+	if err != nil {
+		return err
+	}
+	commitCache()
+	return nil
+}
+
+// New checks whether the server is a replica set or a sharded cluster, on
+// a standalone server units of work run without a transaction.
+func New(ctx context.Context, logger zerolog.Logger, client *mongo.Client) (transactor, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return transactor{}, fmt.Errorf("hello command: %w", err)
+	}
+
+	supported := hello.SetName != "" || hello.Msg == "isdbgrid"
+	if !supported {
+		logger.Warn().Msg("Mongo is a standalone server, units of work run without transactions")
+	}
+	return transactor{client: client, supported: supported}, nil
+}
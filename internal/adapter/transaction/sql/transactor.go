@@ -0,0 +1,66 @@
+package sql_transaction
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ThePositree/billing_manager/internal/usecase"
+)
+
+var _ usecase.Transactor = transactor{}
+
+// Executor is the part of *sql.DB and *sql.Tx the repositories use.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// GetExecutor returns the transaction ctx runs in, or db outside of one.
+func GetExecutor(ctx context.Context, db *sql.DB) Executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return ok
+}
+
+type transactor struct {
+	db *sql.DB
+}
+
+func (t transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if InTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// New serves both the postgres and the sqlite storage, repositories take
+// part in the transaction through GetExecutor.
+func New(db *sql.DB) transactor {
+	return transactor{db: db}
+}
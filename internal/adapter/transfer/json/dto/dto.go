@@ -0,0 +1,364 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/model/user"
+)
+
+type Revision struct {
+	Stage       string    `json:"stage"`
+	RequestedBy string    `json:"requested_by"`
+	Note        string    `json:"note"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (r Revision) GetStage() string {
+	return r.Stage
+}
+
+func (r Revision) GetRequestedBy() string {
+	return r.RequestedBy
+}
+
+func (r Revision) GetNote() string {
+	return r.Note
+}
+
+func (r Revision) GetCreatedAt() time.Time {
+	return r.CreatedAt
+}
+
+type LineItem struct {
+	Description string `json:"description"`
+	Amount      int64  `json:"amount"`
+}
+
+func (l LineItem) GetDescription() string {
+	return l.Description
+}
+
+func (l LineItem) GetAmount() int64 {
+	return l.Amount
+}
+
+type StateChange struct {
+	State     string    `json:"state"`
+	EnteredAt time.Time `json:"entered_at"`
+}
+
+func (s StateChange) GetState() string {
+	return s.State
+}
+
+func (s StateChange) GetEnteredAt() time.Time {
+	return s.EnteredAt
+}
+
+type TimeEntry struct {
+	Id        string    `json:"id"`
+	StaffId   string    `json:"staff_id"`
+	Stage     string    `json:"stage"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Note      string    `json:"note"`
+}
+
+func (e TimeEntry) GetId() string {
+	return e.Id
+}
+
+func (e TimeEntry) GetStaffId() string {
+	return e.StaffId
+}
+
+func (e TimeEntry) GetStage() string {
+	return e.Stage
+}
+
+func (e TimeEntry) GetStartedAt() time.Time {
+	return e.StartedAt
+}
+
+func (e TimeEntry) GetEndedAt() time.Time {
+	return e.EndedAt
+}
+
+func (e TimeEntry) GetNote() string {
+	return e.Note
+}
+
+type Payment struct {
+	Id     string    `json:"id"`
+	Amount int64     `json:"amount"`
+	PaidAt time.Time `json:"paid_at"`
+	Note   string    `json:"note"`
+}
+
+func (p Payment) GetId() string {
+	return p.Id
+}
+
+func (p Payment) GetAmount() int64 {
+	return p.Amount
+}
+
+func (p Payment) GetPaidAt() time.Time {
+	return p.PaidAt
+}
+
+func (p Payment) GetNote() string {
+	return p.Note
+}
+
+type Billing struct {
+	Id                string                   `json:"id"`
+	UserId            string                   `json:"user_id"`
+	State             string                   `json:"state"`
+	Username          string                   `json:"username"`
+	Revisions         []Revision               `json:"revisions"`
+	IncludedRevisions int                      `json:"included_revisions"`
+	RevisionPolicy    string                   `json:"revision_policy"`
+	RevisionSurcharge int64                    `json:"revision_surcharge"`
+	LineItems         []LineItem               `json:"line_items"`
+	CreatedAt         time.Time                `json:"created_at"`
+	StateChanges      []StateChange            `json:"state_changes"`
+	Deadline          time.Time                `json:"deadline"`
+	StageTargets      map[string]time.Duration `json:"stage_targets"`
+	Priority          string                   `json:"priority"`
+	Assignees         map[string]string        `json:"assignees"`
+	TimeEntries       []TimeEntry              `json:"time_entries"`
+	Price             int64                    `json:"price"`
+	InvoicedAt        time.Time                `json:"invoiced_at"`
+	Payments          []Payment                `json:"payments"`
+}
+
+func (u Billing) GetUsername() string {
+	return u.Username
+}
+
+func (u Billing) ToModel() (billing.Billing, error) {
+	return billing.ToModelFromDTO(u)
+}
+
+func (u Billing) GetId() string {
+	return u.Id
+}
+
+func (u Billing) GetUserId() string {
+	return u.UserId
+}
+
+func (u Billing) GetState() string {
+	return u.State
+}
+
+func (u Billing) GetRevisions() []billing.RevisionDTO {
+	var result []billing.RevisionDTO
+	for _, revision := range u.Revisions {
+		result = append(result, revision)
+	}
+	return result
+}
+
+func (u Billing) GetIncludedRevisions() int {
+	return u.IncludedRevisions
+}
+
+func (u Billing) GetRevisionPolicy() string {
+	return u.RevisionPolicy
+}
+
+func (u Billing) GetRevisionSurcharge() int64 {
+	return u.RevisionSurcharge
+}
+
+func (u Billing) GetLineItems() []billing.LineItemDTO {
+	var result []billing.LineItemDTO
+	for _, lineItem := range u.LineItems {
+		result = append(result, lineItem)
+	}
+	return result
+}
+
+func (u Billing) GetCreatedAt() time.Time {
+	return u.CreatedAt
+}
+
+func (u Billing) GetStateChanges() []billing.StateChangeDTO {
+	var result []billing.StateChangeDTO
+	for _, stateChange := range u.StateChanges {
+		result = append(result, stateChange)
+	}
+	return result
+}
+
+func (u Billing) GetDeadline() time.Time {
+	return u.Deadline
+}
+
+func (u Billing) GetStageTargets() map[string]time.Duration {
+	return u.StageTargets
+}
+
+func (u Billing) GetPriority() string {
+	return u.Priority
+}
+
+func (u Billing) GetAssignees() map[string]string {
+	return u.Assignees
+}
+
+func (u Billing) GetTimeEntries() []billing.TimeEntryDTO {
+	var result []billing.TimeEntryDTO
+	for _, timeEntry := range u.TimeEntries {
+		result = append(result, timeEntry)
+	}
+	return result
+}
+
+func (u Billing) GetPrice() int64 {
+	return u.Price
+}
+
+func (u Billing) GetInvoicedAt() time.Time {
+	return u.InvoicedAt
+}
+
+// GetDeletedAt returns zero time, only live billings are transferred.
+func (u Billing) GetDeletedAt() time.Time {
+	return time.Time{}
+}
+
+func (u Billing) GetDeletedBy() string {
+	return ""
+}
+
+func (u Billing) GetPayments() []billing.PaymentDTO {
+	var result []billing.PaymentDTO
+	for _, payment := range u.Payments {
+		result = append(result, payment)
+	}
+	return result
+}
+
+func NewTimeEntryDTOFromModel(timeEntry billing.TimeEntry) TimeEntry {
+	return TimeEntry{
+		Id:        timeEntry.Id,
+		StaffId:   timeEntry.StaffId,
+		Stage:     timeEntry.Stage.String(),
+		StartedAt: timeEntry.StartedAt,
+		EndedAt:   timeEntry.EndedAt,
+		Note:      timeEntry.Note,
+	}
+}
+
+func NewBillingDTOFromModel(billing billing.Billing) Billing {
+	var revisions []Revision
+	for _, revision := range billing.GetRevisions() {
+		revisions = append(revisions, Revision{
+			Stage:       revision.Stage.String(),
+			RequestedBy: revision.RequestedBy,
+			Note:        revision.Note,
+			CreatedAt:   revision.CreatedAt,
+		})
+	}
+	var lineItems []LineItem
+	for _, lineItem := range billing.GetLineItems() {
+		lineItems = append(lineItems, LineItem{
+			Description: lineItem.Description,
+			Amount:      lineItem.Amount,
+		})
+	}
+	var stateChanges []StateChange
+	for _, stateChange := range billing.GetStateChanges() {
+		stateChanges = append(stateChanges, StateChange{
+			State:     stateChange.State.String(),
+			EnteredAt: stateChange.EnteredAt,
+		})
+	}
+	stageTargets := map[string]time.Duration{}
+	for stage, target := range billing.GetStageTargets() {
+		stageTargets[stage.String()] = target
+	}
+	assignees := map[string]string{}
+	for stage, staffId := range billing.GetAssignees() {
+		assignees[stage.String()] = staffId
+	}
+	var timeEntries []TimeEntry
+	for _, timeEntry := range billing.GetTimeEntries() {
+		timeEntries = append(timeEntries, NewTimeEntryDTOFromModel(timeEntry))
+	}
+	var payments []Payment
+	for _, payment := range billing.GetPayments() {
+		payments = append(payments, Payment{
+			Id:     payment.Id,
+			Amount: payment.Amount,
+			PaidAt: payment.PaidAt,
+			Note:   payment.Note,
+		})
+	}
+	revisionLimit := billing.GetRevisionLimit()
+	return Billing{
+		Id:                billing.Id,
+		UserId:            billing.UserId,
+		State:             billing.GetState().String(),
+		Username:          billing.GetBriefInfo().Username,
+		Revisions:         revisions,
+		IncludedRevisions: revisionLimit.Included,
+		RevisionPolicy:    revisionLimit.Policy.String(),
+		RevisionSurcharge: revisionLimit.Surcharge,
+		LineItems:         lineItems,
+		CreatedAt:         billing.GetCreatedAt(),
+		StateChanges:      stateChanges,
+		Deadline:          billing.GetDeadline(),
+		StageTargets:      stageTargets,
+		Priority:          billing.GetPriority().String(),
+		Assignees:         assignees,
+		TimeEntries:       timeEntries,
+		Price:             billing.GetPrice(),
+		InvoicedAt:        billing.GetInvoicedAt(),
+		Payments:          payments,
+	}
+}
+
+type User struct {
+	Id         string `json:"id"`
+	TelegramUN string `json:"telegram_username"`
+}
+
+func (u User) GetId() string {
+	return u.Id
+}
+
+func (u User) GetTelegramUN() string {
+	return u.TelegramUN
+}
+
+// GetDeletedAt returns zero time, only live users are transferred.
+func (u User) GetDeletedAt() time.Time {
+	return time.Time{}
+}
+
+func (u User) GetDeletedBy() string {
+	return ""
+}
+
+func (u User) ToModel() (user.User, error) {
+	return user.ToModelFromDTO(u)
+}
+
+func NewUserDTOFromModel(user user.User) User {
+	return User{
+		Id:         user.Id,
+		TelegramUN: user.TelegramUN,
+	}
+}
+
+// Record is one line of NDJSON or one element of the JSON array.
+type Record struct {
+	Kind    string   `json:"kind"`
+	User    *User    `json:"user,omitempty"`
+	Billing *Billing `json:"billing,omitempty"`
+}
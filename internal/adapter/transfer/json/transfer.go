@@ -0,0 +1,169 @@
+package json_transfer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/transfer/json/dto"
+	"github.com/ThePositree/billing_manager/internal/usecase/data_transferring"
+)
+
+var (
+	_ data_transferring.RecordWriter = &writer{}
+	_ data_transferring.RecordReader = &reader{}
+)
+
+var ErrInvalidFormat = errors.New("format must be one of ndjson, json")
+
+type Format string
+
+const (
+	// FormatNDJSON writes one record per line.
+	FormatNDJSON Format = "ndjson"
+	// FormatJSON writes a single array of records.
+	FormatJSON Format = "json"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(value); format {
+	case FormatNDJSON, FormatJSON:
+		return format, nil
+	}
+	return "", ErrInvalidFormat
+}
+
+type writer struct {
+	out     io.Writer
+	format  Format
+	written int
+}
+
+func (w *writer) Write(record data_transferring.Record) error {
+	recordDTO := dto.Record{Kind: string(record.Kind)}
+	switch record.Kind {
+	case data_transferring.RecordUser:
+		userDTO := dto.NewUserDTOFromModel(record.User)
+		recordDTO.User = &userDTO
+	case data_transferring.RecordBilling:
+		billingDTO := dto.NewBillingDTOFromModel(record.Billing)
+		recordDTO.Billing = &billingDTO
+	default:
+		return data_transferring.ErrUnknownRecord
+	}
+
+	bytes, err := json.Marshal(recordDTO)
+	if err != nil {
+		return fmt.Errorf("marshaling record json: %w", err)
+	}
+
+	separator := "\n"
+	if w.format == FormatJSON {
+		separator = ",\n"
+		if w.written == 0 {
+			separator = "[\n"
+		}
+		bytes = append([]byte(separator), bytes...)
+	} else {
+		bytes = append(bytes, separator...)
+	}
+	if _, err := w.out.Write(bytes); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+	w.written++
+	return nil
+}
+
+// Close finishes the JSON array, it does not close the underlying writer.
+func (w *writer) Close() error {
+	if w.format != FormatJSON {
+		return nil
+	}
+	closing := "\n]\n"
+	if w.written == 0 {
+		closing = "[]\n"
+	}
+	if _, err := io.WriteString(w.out, closing); err != nil {
+		return fmt.Errorf("closing json array: %w", err)
+	}
+	return nil
+}
+
+func NewWriter(out io.Writer, format Format) (*writer, error) {
+	if _, err := ParseFormat(string(format)); err != nil {
+		return &writer{}, err
+	}
+	return &writer{
+		out:    out,
+		format: format,
+	}, nil
+}
+
+type reader struct {
+	decoder *json.Decoder
+	format  Format
+	started bool
+	read    int
+}
+
+// Read decodes the next record and validates it with the model ToModelFromDTO.
+func (r *reader) Read() (data_transferring.Record, error) {
+	if r.format == FormatJSON {
+		if !r.started {
+			token, err := r.decoder.Token()
+			if err != nil {
+				return data_transferring.Record{}, fmt.Errorf("reading json array start: %w", err)
+			}
+			if delim, ok := token.(json.Delim); !ok || delim != '[' {
+				return data_transferring.Record{}, fmt.Errorf("json input must be an array of records")
+			}
+			r.started = true
+		}
+		if !r.decoder.More() {
+			return data_transferring.Record{}, io.EOF
+		}
+	}
+
+	var recordDTO dto.Record
+	err := r.decoder.Decode(&recordDTO)
+	if errors.Is(err, io.EOF) {
+		return data_transferring.Record{}, io.EOF
+	}
+	r.read++
+	if err != nil {
+		return data_transferring.Record{}, fmt.Errorf("record %d: json decode: %w", r.read, err)
+	}
+
+	switch data_transferring.RecordKind(recordDTO.Kind) {
+	case data_transferring.RecordUser:
+		if recordDTO.User == nil {
+			return data_transferring.Record{}, fmt.Errorf("record %d: user is missing", r.read)
+		}
+		user, err := recordDTO.User.ToModel()
+		if err != nil {
+			return data_transferring.Record{}, fmt.Errorf("record %d: %w", r.read, err)
+		}
+		return data_transferring.Record{Kind: data_transferring.RecordUser, User: user}, nil
+	case data_transferring.RecordBilling:
+		if recordDTO.Billing == nil {
+			return data_transferring.Record{}, fmt.Errorf("record %d: billing is missing", r.read)
+		}
+		billing, err := recordDTO.Billing.ToModel()
+		if err != nil {
+			return data_transferring.Record{}, fmt.Errorf("record %d: %w", r.read, err)
+		}
+		return data_transferring.Record{Kind: data_transferring.RecordBilling, Billing: billing}, nil
+	}
+	return data_transferring.Record{}, fmt.Errorf("record %d: %w", r.read, data_transferring.ErrUnknownRecord)
+}
+
+func NewReader(in io.Reader, format Format) (*reader, error) {
+	if _, err := ParseFormat(string(format)); err != nil {
+		return &reader{}, err
+	}
+	return &reader{
+		decoder: json.NewDecoder(in),
+		format:  format,
+	}, nil
+}
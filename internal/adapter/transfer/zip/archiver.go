@@ -0,0 +1,68 @@
+package zip_transfer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/adapter/transfer/json/dto"
+	"github.com/ThePositree/billing_manager/internal/usecase/personal_data"
+)
+
+var _ personal_data.Archiver = archiver{}
+
+type manifest struct {
+	UserId     string    `json:"user_id"`
+	ExportedAt time.Time `json:"exported_at"`
+	Billings   int       `json:"billings"`
+}
+
+// archiver writes a zip with the manifest, the user and its billings as
+// separate json files in the format of the data export.
+type archiver struct{}
+
+func (a archiver) Write(out io.Writer, export personal_data.Export) error {
+	billings := []dto.Billing{}
+	for _, billing := range export.Billings {
+		billings = append(billings, dto.NewBillingDTOFromModel(billing))
+	}
+
+	zipWriter := zip.NewWriter(out)
+	files := []struct {
+		name    string
+		content any
+	}{
+		{"manifest.json", manifest{
+			UserId:     export.User.Id,
+			ExportedAt: export.ExportedAt,
+			Billings:   len(billings),
+		}},
+		{"user.json", dto.NewUserDTOFromModel(export.User)},
+		{"billings.json", billings},
+	}
+	for _, file := range files {
+		writer, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", file.name, err)
+		}
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return fmt.Errorf("writing %s: %w", file.name, err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("closing zip: %w", err)
+	}
+	return nil
+}
+
+func NewArchiver() archiver {
+	return archiver{}
+}
@@ -1,29 +1,516 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	repository_crypto "github.com/ThePositree/billing_manager/internal/adapter/repository/crypto"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts every environment variable read by Load,
+// the rest of the name is the upper-cased key, e.g. BILLING_HTTP_PORT.
+const EnvPrefix = "BILLING_"
+
+const redacted = "[redacted]"
+
+// Storages are the values of the storage key.
+const (
+	StorageMongo    = "mongo"
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
 )
 
-type config struct {
-	MongoURI          string `json:"mongo_uri"`
-	Database          string `json:"database"`
-	UserCollection    string `json:"user_collection"`
-	BillingCollection string `json:"billing_collection"`
-	AdminPassword     string `json:"admin_password"`
-	HttpPort          int    `json:"http_port"`
+// dsnPassword matches the password of a key=value PostgreSQL DSN.
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(\\.|[^'])*'|\S+)`)
+
+// ErrInvalidConfig lists every problem found by Validate.
+type ErrInvalidConfig struct {
+	Problems []string
 }
 
-func New() (config, error) {
-	bytes, err := os.ReadFile("config.json")
-	if err != nil {
-		return config{}, fmt.Errorf("read file: %w", err)
+func (e ErrInvalidConfig) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Config keys are the json tags, the same keys are used in YAML and TOML files,
+// in BILLING_* env vars and in flag overrides. Keys ending with _file name a
+// file holding the secret instead of the secret itself.
+type Config struct {
+	Storage               string            `json:"storage"`
+	MongoURI              string            `json:"mongo_uri"`
+	MongoURIFile          string            `json:"mongo_uri_file"`
+	PostgresDSN           string            `json:"postgres_dsn"`
+	PostgresDSNFile       string            `json:"postgres_dsn_file"`
+	SQLitePath            string            `json:"sqlite_path"`
+	Database              string            `json:"database"`
+	UserCollection        string            `json:"user_collection"`
+	BillingCollection     string            `json:"billing_collection"`
+	StaffCollection       string            `json:"staff_collection"`
+	EventCollection       string            `json:"event_collection"`
+	SnapshotCollection    string            `json:"snapshot_collection"`
+	AuditCollection       string            `json:"audit_collection"`
+	AdminPassword         string            `json:"admin_password"`
+	AdminPasswordFile     string            `json:"admin_password_file"`
+	HttpPort              int               `json:"http_port"`
+	IncludedRevisions     int               `json:"included_revisions"`
+	RevisionPolicy        string            `json:"revision_policy"`
+	RevisionSurcharge     int64             `json:"revision_surcharge"`
+	DefaultDeadline       string            `json:"default_deadline"`
+	StageTargets          map[string]string `json:"stage_targets"`
+	DeadlineWarning       string            `json:"deadline_warning"`
+	DeadlineCheckInterval string            `json:"deadline_check_interval"`
+	LogLevel              string            `json:"log_level"`
+	RateLimitRPS          float64           `json:"rate_limit_rps"`
+	RateLimitBurst        int               `json:"rate_limit_burst"`
+	NotificationTemplates map[string]string `json:"notification_templates"`
+	CacheDisabled         bool              `json:"cache_disabled"`
+	CachePollInterval     string            `json:"cache_poll_interval"`
+	EventSourcing         bool              `json:"event_sourcing"`
+	SnapshotInterval      int               `json:"snapshot_interval"`
+	DeletedRetention      string            `json:"deleted_retention"`
+	PurgeInterval         string            `json:"purge_interval"`
+	EncryptionKeys        map[string]string `json:"encryption_keys"`
+	EncryptionKeysFile    string            `json:"encryption_keys_file"`
+	EncryptionKeyId       string            `json:"encryption_key_id"`
+	BlindIndexKey         string            `json:"blind_index_key"`
+	BlindIndexKeyFile     string            `json:"blind_index_key_file"`
+}
+
+// Default returns the values used for keys missing from every source:
+// storage in a local MongoDB at mongodb://localhost:27017 with the billing_manager
+// database, users, billings, staff, billing_events and billing_snapshots
+// collections or in billing_manager.db when SQLite is picked, HTTP on port 3000,
+// unlimited revisions, no deadlines and a deadline check every 10 minutes,
+// info logs, no rate limit, built-in notification templates,
+// repository caches polled every 30 seconds without change streams and
+// billings stored as state, a snapshot every 50 events when they are event sourced,
+// soft deleted records kept for 30 days and purged hourly.
+// There is no default admin password and no encryption keys, so personal
+// data is stored in plaintext.
+func Default() Config {
+	return Config{
+		Storage:               StorageMongo,
+		MongoURI:              "mongodb://localhost:27017",
+		SQLitePath:            "billing_manager.db",
+		Database:              "billing_manager",
+		UserCollection:        "users",
+		BillingCollection:     "billings",
+		StaffCollection:       "staff",
+		EventCollection:       "billing_events",
+		SnapshotCollection:    "billing_snapshots",
+		AuditCollection:       "audit_log",
+		HttpPort:              3000,
+		RevisionPolicy:        model_billing.RevisionPolicyUnlimited.String(),
+		StageTargets:          map[string]string{},
+		DeadlineCheckInterval: "10m",
+		LogLevel:              zerolog.InfoLevel.String(),
+		RateLimitBurst:        20,
+		NotificationTemplates: map[string]string{},
+		CachePollInterval:     "30s",
+		SnapshotInterval:      50,
+		DeletedRetention:      "720h",
+		PurgeInterval:         "1h",
+		EncryptionKeys:        map[string]string{},
+	}
+}
+
+type Options struct {
+	// Path of the config file, the extension picks the format:
+	// .json, .yaml, .yml or .toml. Empty means no file.
+	Path string
+	// Required makes a missing file an error, otherwise defaults
+	// and env vars are enough to run.
+	Required bool
+	// Overrides are key and value pairs from flags, applied last.
+	Overrides map[string]string
+	// LookupEnv reads env vars, nil means os.LookupEnv.
+	LookupEnv func(key string) (string, bool)
+}
+
+// Load layers defaults, the config file, env vars and overrides, later sources
+// win. Secrets from *_file keys are read at the end. The result is not validated.
+func Load(opts Options) (Config, error) {
+	cfg := Default()
+
+	if opts.Path != "" {
+		err := cfg.loadFile(opts.Path)
+		if errors.Is(err, fs.ErrNotExist) && !opts.Required {
+			err = nil
+		}
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	lookupEnv := opts.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	for _, key := range Keys() {
+		value, ok := lookupEnv(EnvPrefix + strings.ToUpper(key))
+		if !ok {
+			continue
+		}
+		if err := cfg.Set(key, value); err != nil {
+			return Config{}, fmt.Errorf("env %s%s: %w", EnvPrefix, strings.ToUpper(key), err)
+		}
+	}
+
+	for key, value := range opts.Overrides {
+		if err := cfg.Set(key, value); err != nil {
+			return Config{}, fmt.Errorf("override %s: %w", key, err)
+		}
+	}
+
+	if err := cfg.readSecretFiles(); err != nil {
+		return Config{}, err
 	}
-	var result config
-	err = json.Unmarshal(bytes, &result)
+
+	return cfg, nil
+}
+
+// loadFile decodes YAML and TOML into generic maps and passes them through
+// JSON so that every format shares the json tags and rejects unknown keys.
+func (c *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return config{}, fmt.Errorf("unmarshal json: %w", err)
+		return fmt.Errorf("read file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+	case ".yaml", ".yml":
+		var values map[string]any
+		if err := yaml.Unmarshal(content, &values); err != nil {
+			return fmt.Errorf("unmarshal yaml: %w", err)
+		}
+		if content, err = json.Marshal(values); err != nil {
+			return fmt.Errorf("marshal yaml values: %w", err)
+		}
+	case ".toml":
+		var values map[string]any
+		if err := toml.Unmarshal(content, &values); err != nil {
+			return fmt.Errorf("unmarshal toml: %w", err)
+		}
+		if content, err = json.Marshal(values); err != nil {
+			return fmt.Errorf("marshal toml values: %w", err)
+		}
+	default:
+		return fmt.Errorf("config file %s must have .json, .yaml, .yml or .toml extension", path)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) readSecretFiles() error {
+	secrets := []struct {
+		path  string
+		value *string
+	}{
+		{path: c.MongoURIFile, value: &c.MongoURI},
+		{path: c.PostgresDSNFile, value: &c.PostgresDSN},
+		{path: c.AdminPasswordFile, value: &c.AdminPassword},
+		{path: c.BlindIndexKeyFile, value: &c.BlindIndexKey},
+	}
+	for _, secret := range secrets {
+		if secret.path == "" {
+			continue
+		}
+		content, err := os.ReadFile(secret.path)
+		if err != nil {
+			return fmt.Errorf("read secret file: %w", err)
+		}
+		*secret.value = strings.TrimSpace(string(content))
+	}
+
+	// The keys file holds id=key pairs, one per line or comma separated.
+	if c.EncryptionKeysFile != "" {
+		content, err := os.ReadFile(c.EncryptionKeysFile)
+		if err != nil {
+			return fmt.Errorf("read secret file: %w", err)
+		}
+		pairs := strings.Join(strings.Fields(string(content)), ",")
+		if err := c.Set("encryption_keys", pairs); err != nil {
+			return fmt.Errorf("encryption_keys_file: %w", err)
+		}
+	}
+	return nil
+}
+
+// Keys returns every config key in declaration order.
+func Keys() []string {
+	var keys []string
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		keys = append(keys, configType.Field(i).Tag.Get("json"))
+	}
+	return keys
+}
+
+// Set parses value into the field with the given key. Maps are written
+// as comma separated pairs: design=120h,layout=72h.
+func (c *Config) Set(key string, value string) error {
+	configValue := reflect.ValueOf(c).Elem()
+	configType := configValue.Type()
+	for i := 0; i < configType.NumField(); i++ {
+		if configType.Field(i).Tag.Get("json") != key {
+			continue
+		}
+
+		field := configValue.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int, reflect.Int64:
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer", key)
+			}
+			field.SetInt(parsed)
+		case reflect.Bool:
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false", key)
+			}
+			field.SetBool(parsed)
+		case reflect.Float64:
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s must be a number", key)
+			}
+			field.SetFloat(parsed)
+		case reflect.Map:
+			pairs := map[string]string{}
+			for _, pair := range strings.Split(value, ",") {
+				if strings.TrimSpace(pair) == "" {
+					continue
+				}
+				name, pairValue, ok := strings.Cut(pair, "=")
+				if !ok {
+					return fmt.Errorf("%s must be a list of name=value pairs", key)
+				}
+				pairs[strings.TrimSpace(name)] = strings.TrimSpace(pairValue)
+			}
+			field.Set(reflect.ValueOf(pairs))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown config key %s", key)
+}
+
+// Validate checks every key and reports all problems at once.
+func (c Config) Validate() error {
+	var problems []string
+	addProblem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.Storage {
+	case StorageMongo:
+		c.validateMongo(addProblem)
+		c.validateEncryption(addProblem)
+	case StoragePostgres:
+		if c.PostgresDSN == "" {
+			addProblem("postgres_dsn cannot be empty, set it with %sPOSTGRES_DSN or postgres_dsn_file", EnvPrefix)
+		}
+	case StorageSQLite:
+		if c.SQLitePath == "" {
+			addProblem("sqlite_path cannot be empty")
+		}
+	default:
+		addProblem("storage must be %s, %s or %s", StorageMongo, StoragePostgres, StorageSQLite)
+	}
+	if c.Storage != StorageMongo && len(c.EncryptionKeys) > 0 {
+		addProblem("encryption_keys are only supported by the %s storage", StorageMongo)
+	}
+	if c.AdminPassword == "" {
+		addProblem("admin_password cannot be empty, set it with %sADMIN_PASSWORD or admin_password_file", EnvPrefix)
+	}
+	if c.HttpPort < 1 || c.HttpPort > 65535 {
+		addProblem("http_port must be between 1 and 65535, got %d", c.HttpPort)
+	}
+	if c.IncludedRevisions < 0 {
+		addProblem("included_revisions cannot be negative")
+	}
+	if _, err := model_billing.ParseRevisionPolicy(c.RevisionPolicy); err != nil {
+		addProblem("revision_policy: %s", err)
+	}
+	if c.RevisionSurcharge < 0 {
+		addProblem("revision_surcharge cannot be negative")
+	}
+	for _, duration := range []struct{ key, value string }{
+		{key: "default_deadline", value: c.DefaultDeadline},
+		{key: "deadline_warning", value: c.DeadlineWarning},
+	} {
+		if duration.value == "" {
+			continue
+		}
+		if parsed, err := time.ParseDuration(duration.value); err != nil || parsed < 0 {
+			addProblem("%s must be a non-negative duration like 24h", duration.key)
+		}
+	}
+	if interval, err := time.ParseDuration(c.DeadlineCheckInterval); err != nil || interval <= 0 {
+		addProblem("deadline_check_interval must be a positive duration like 10m")
+	}
+	if interval, err := time.ParseDuration(c.CachePollInterval); !c.CacheDisabled && (err != nil || interval <= 0) {
+		addProblem("cache_poll_interval must be a positive duration like 30s")
+	}
+	if c.SnapshotInterval < 1 {
+		addProblem("snapshot_interval must be at least 1")
+	}
+	if retention, err := time.ParseDuration(c.DeletedRetention); err != nil || retention < 0 {
+		addProblem("deleted_retention must be a non-negative duration like 720h")
+	}
+	if interval, err := time.ParseDuration(c.PurgeInterval); err != nil || interval <= 0 {
+		addProblem("purge_interval must be a positive duration like 1h")
+	}
+	stages := make([]string, 0, len(c.StageTargets))
+	for stage := range c.StageTargets {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	for _, stage := range stages {
+		target := c.StageTargets[stage]
+		if _, err := model_billing.ParseState(stage); err != nil {
+			addProblem("stage_targets: %s is not a billing state", stage)
+		}
+		if duration, err := time.ParseDuration(target); err != nil || duration <= 0 {
+			addProblem("stage_targets: %s target must be a positive duration", stage)
+		}
+	}
+
+	if _, err := zerolog.ParseLevel(c.LogLevel); err != nil || c.LogLevel == "" {
+		addProblem("log_level must be one of trace, debug, info, warn, error")
+	}
+	if c.RateLimitRPS < 0 {
+		addProblem("rate_limit_rps cannot be negative")
+	}
+	if c.RateLimitRPS > 0 && c.RateLimitBurst < 1 {
+		addProblem("rate_limit_burst must be at least 1 when rate_limit_rps is set")
+	}
+	statuses := make([]string, 0, len(c.NotificationTemplates))
+	for status := range c.NotificationTemplates {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		if _, err := model_billing.ParseDeadlineStatus(status); err != nil {
+			addProblem("notification_templates: %s is not a deadline status", status)
+		}
+		if _, err := template.New(status).Parse(c.NotificationTemplates[status]); err != nil {
+			addProblem("notification_templates: %s", err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return ErrInvalidConfig{Problems: problems}
+	}
+	return nil
+}
+
+// validateMongo checks keys used only by the mongo storage.
+func (c Config) validateMongo(addProblem func(format string, args ...any)) {
+	if c.MongoURI == "" {
+		addProblem("mongo_uri cannot be empty")
+	} else if _, err := url.Parse(c.MongoURI); err != nil {
+		addProblem("mongo_uri is not a valid uri")
+	}
+	if c.Database == "" {
+		addProblem("database cannot be empty")
+	}
+	collections := map[string]string{}
+	for _, collection := range []struct{ key, name string }{
+		{key: "user_collection", name: c.UserCollection},
+		{key: "billing_collection", name: c.BillingCollection},
+		{key: "staff_collection", name: c.StaffCollection},
+		{key: "event_collection", name: c.EventCollection},
+		{key: "snapshot_collection", name: c.SnapshotCollection},
+		{key: "audit_collection", name: c.AuditCollection},
+	} {
+		if collection.name == "" {
+			addProblem("%s cannot be empty", collection.key)
+			continue
+		}
+		if other, ok := collections[collection.name]; ok {
+			addProblem("%s and %s use the same collection %s", other, collection.key, collection.name)
+		}
+		collections[collection.name] = collection.key
+	}
+}
+
+// validateEncryption checks the field encryption keys with the rules of the
+// cipher that uses them.
+func (c Config) validateEncryption(addProblem func(format string, args ...any)) {
+	if err := c.Encryption().Validate(); err != nil {
+		addProblem("%s", err)
+	}
+}
+
+// Encryption is the field encryption of Mongo documents, it is off without
+// encryption keys.
+func (c Config) Encryption() repository_crypto.Config {
+	return repository_crypto.Config{
+		Keys:     c.EncryptionKeys,
+		KeyId:    c.EncryptionKeyId,
+		IndexKey: c.BlindIndexKey,
+	}
+}
+
+// Redacted returns a copy safe to log: the admin password, encryption keys and
+// passwords in the Mongo URI and the PostgreSQL DSN are hidden.
+func (c Config) Redacted() Config {
+	if c.AdminPassword != "" {
+		c.AdminPassword = redacted
+	}
+	if mongoURI, err := url.Parse(c.MongoURI); err == nil {
+		c.MongoURI = mongoURI.Redacted()
+	} else {
+		c.MongoURI = redacted
+	}
+	if postgresURL, err := url.Parse(c.PostgresDSN); err == nil && postgresURL.Scheme != "" {
+		c.PostgresDSN = postgresURL.Redacted()
+	} else {
+		c.PostgresDSN = dsnPassword.ReplaceAllString(c.PostgresDSN, "${1}"+redacted)
+	}
+	stageTargets := map[string]string{}
+	for stage, target := range c.StageTargets {
+		stageTargets[stage] = target
+	}
+	c.StageTargets = stageTargets
+	encryptionKeys := map[string]string{}
+	for id := range c.EncryptionKeys {
+		encryptionKeys[id] = redacted
+	}
+	c.EncryptionKeys = encryptionKeys
+	if c.BlindIndexKey != "" {
+		c.BlindIndexKey = redacted
+	}
+	notificationTemplates := map[string]string{}
+	for status, source := range c.NotificationTemplates {
+		notificationTemplates[status] = source
 	}
-	return result, nil
+	c.NotificationTemplates = notificationTemplates
+	return c
 }
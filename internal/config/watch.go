@@ -0,0 +1,85 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+)
+
+// watchDebounce merges the bursts of events editors produce on save.
+const watchDebounce = 200 * time.Millisecond
+
+// Watch calls reload with the reason whenever the config file at path changes
+// or the process receives SIGHUP, until ctx is done. The directory is watched
+// rather than the file so that editors replacing the file are noticed too.
+// Watcher errors, like a dropped event on overflow, are logged and watching
+// goes on.
+func Watch(ctx context.Context, logger zerolog.Logger, path string, reload func(reason string)) error {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	var absolutePath string
+	if path != "" {
+		if absolutePath, err = filepath.Abs(path); err != nil {
+			return fmt.Errorf("resolving config path: %w", err)
+		}
+		if err := watcher.Add(filepath.Dir(absolutePath)); err != nil {
+			return fmt.Errorf("watching config directory: %w", err)
+		}
+	}
+
+	watchEvents(ctx, logger, absolutePath, watcher.Events, watcher.Errors, hangup, reload)
+	return nil
+}
+
+// watchEvents reloads on events of the file at path and on hangup until ctx
+// is done or the watcher channels are closed.
+func watchEvents(
+	ctx context.Context,
+	logger zerolog.Logger,
+	path string,
+	events <-chan fsnotify.Event,
+	errs <-chan error,
+	hangup <-chan os.Signal,
+	reload func(reason string),
+) {
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			reload("SIGHUP")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Name == path && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename)) {
+				debounce.Reset(watchDebounce)
+			}
+		case <-debounce.C:
+			reload("file changed")
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			logger.Warn().Err(err).Msg("Config file watcher")
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type watchHarness struct {
+	events  chan fsnotify.Event
+	errs    chan error
+	hangup  chan os.Signal
+	reloads chan string
+	done    chan struct{}
+	cancel  context.CancelFunc
+}
+
+func startWatch(t *testing.T, path string) watchHarness {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	h := watchHarness{
+		events:  make(chan fsnotify.Event),
+		errs:    make(chan error),
+		hangup:  make(chan os.Signal),
+		reloads: make(chan string, 10),
+		done:    make(chan struct{}),
+		cancel:  cancel,
+	}
+	go func() {
+		defer close(h.done)
+		watchEvents(ctx, zerolog.Nop(), path, h.events, h.errs, h.hangup, func(reason string) { h.reloads <- reason })
+	}()
+	t.Cleanup(cancel)
+	return h
+}
+
+func (h watchHarness) requireReload(t *testing.T, want string) {
+	t.Helper()
+	select {
+	case reason := <-h.reloads:
+		require.Equal(t, want, reason)
+	case <-time.After(5 * watchDebounce):
+		t.Fatalf("no %q reload", want)
+	}
+}
+
+func (h watchHarness) requireStopped(t *testing.T) {
+	t.Helper()
+	select {
+	case <-h.done:
+	case <-time.After(time.Second):
+		t.Fatal("watch did not stop")
+	}
+}
+
+func TestWatchEventsSurvivesErrors(t *testing.T) {
+	path := "/etc/billing_manager/config.yaml"
+	h := startWatch(t, path)
+
+	h.errs <- fsnotify.ErrEventOverflow
+	h.errs <- errors.New("read error")
+
+	h.hangup <- syscall.SIGHUP
+	h.requireReload(t, "SIGHUP")
+
+	// A burst of events reloads once, events of other files are ignored.
+	h.events <- fsnotify.Event{Name: path, Op: fsnotify.Write}
+	h.events <- fsnotify.Event{Name: path, Op: fsnotify.Rename}
+	h.events <- fsnotify.Event{Name: path, Op: fsnotify.Create}
+	h.events <- fsnotify.Event{Name: "/etc/billing_manager/other.yaml", Op: fsnotify.Write}
+	h.requireReload(t, "file changed")
+	select {
+	case reason := <-h.reloads:
+		t.Fatalf("unexpected %q reload", reason)
+	case <-time.After(2 * watchDebounce):
+	}
+
+	h.cancel()
+	h.requireStopped(t)
+}
+
+func TestWatchEventsStopsOnClosedChannels(t *testing.T) {
+	h := startWatch(t, "config.yaml")
+	close(h.errs)
+	h.requireStopped(t)
+
+	h = startWatch(t, "config.yaml")
+	close(h.events)
+	h.requireStopped(t)
+}
@@ -5,18 +5,25 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/ThePositree/billing_manager/internal/controller/http/handlers"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
 	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing"
+	"github.com/ThePositree/billing_manager/internal/usecase/personal_data"
+	"github.com/ThePositree/billing_manager/internal/usecase/reporting"
+	"github.com/ThePositree/billing_manager/internal/usecase/staff_managing"
 	"github.com/ThePositree/billing_manager/internal/usecase/user_managing"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog"
 )
 
 type handlerInfo struct {
-	handler http.HandlerFunc
+	handler http.Handler
 	path    string
 	method  string
+	// raw handlers send text or attachments, they skip JSON content negotiation.
+	raw bool
 }
 
 type http_controller struct {
@@ -24,73 +31,263 @@ type http_controller struct {
 	logger          zerolog.Logger
 	billingManaging billing_managing.BillingManaging
 	userManaging    user_managing.UserManaging
-	adminPassword   string
+	staffManaging   staff_managing.StaffManaging
+	reporting       reporting.Reporting
+	personalData    personal_data.PersonalData
+	auditing        auditing.Auditing
+	adminPassword   *handlers.AdminPassword
+	rateLimiter     *rateLimiter
+}
+
+// SetAdminPassword takes effect for the next request.
+func (hc http_controller) SetAdminPassword(password string) {
+	hc.adminPassword.Set(password)
+}
+
+func (hc http_controller) SetRateLimit(limit RateLimit) {
+	hc.rateLimiter.Set(limit)
 }
 
 func (hc http_controller) Start(ctx context.Context) {
 	handlersInfo := []handlerInfo{
 		{
-			handler: func(w http.ResponseWriter, r *http.Request) {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte("OK"))
-			},
+			}),
 			path:   "/ping",
 			method: http.MethodGet,
+			raw:    true,
 		},
 		{
-			handler: handlers.GetAllBillings(hc.billingManaging, hc.logger, hc.adminPassword),
+			handler: handlers.Adapt(handlers.GetAllBillings(hc.billingManaging)),
 			path:    "/admin/billings",
 			method:  http.MethodGet,
 		},
 		{
-			handler: handlers.GetAllUsers(hc.userManaging, hc.logger, hc.adminPassword),
+			handler: handlers.Adapt(handlers.GetAllUsers(hc.userManaging)),
 			path:    "/admin/users",
 			method:  http.MethodGet,
 		},
 		{
-			handler: handlers.GetBilling(hc.billingManaging, hc.logger),
+			handler: handlers.Adapt(handlers.DeleteUser(hc.userManaging)),
+			path:    "/admin/user/{id}",
+			method:  http.MethodDelete,
+		},
+		{
+			handler: handlers.Adapt(handlers.PatchUserRestore(hc.userManaging)),
+			path:    "/admin/user/restore/{id}",
+			method:  http.MethodPatch,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetDeletedUsers(hc.userManaging)),
+			path:    "/admin/users/deleted",
+			method:  http.MethodGet,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetUserPersonalData(hc.personalData)),
+			path:    "/admin/user/personal-data/{id}",
+			method:  http.MethodGet,
+			raw:     true,
+		},
+		{
+			handler: handlers.Adapt(handlers.PatchUserErase(hc.personalData)),
+			path:    "/admin/user/erase/{id}",
+			method:  http.MethodPatch,
+		},
+		{
+			handler: handlers.Adapt(handlers.DeleteBilling(hc.billingManaging)),
+			path:    "/admin/billing/{id}",
+			method:  http.MethodDelete,
+		},
+		{
+			handler: handlers.Adapt(handlers.PatchBillingRestore(hc.billingManaging)),
+			path:    "/admin/billing/restore/{id}",
+			method:  http.MethodPatch,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetDeletedBillings(hc.billingManaging)),
+			path:    "/admin/billings/deleted",
+			method:  http.MethodGet,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetBilling(hc.billingManaging)),
 			path:    "/billing",
 			method:  http.MethodGet,
 		},
 		{
-			handler: handlers.GetUserByTelegramUN(hc.userManaging, hc.logger),
+			handler: handlers.Adapt(handlers.GetUserByTelegramUN(hc.userManaging)),
 			path:    "/user",
 			method:  http.MethodGet,
 		},
 		{
-			handler: handlers.PatchBilling(hc.billingManaging, hc.logger),
+			handler: handlers.Adapt(handlers.GetPersonalData(hc.userManaging, hc.personalData)),
+			path:    "/user/personal-data",
+			method:  http.MethodGet,
+			raw:     true,
+		},
+		{
+			handler: handlers.Adapt(handlers.PatchBilling(hc.billingManaging)),
 			path:    "/billing/{id}",
 			method:  http.MethodPatch,
 		},
 		{
-			handler: handlers.PatchBillingNextState(hc.billingManaging, hc.logger, hc.adminPassword),
+			handler: handlers.Adapt(handlers.PatchBillingNextState(hc.billingManaging)),
 			path:    "/admin/billing/state/next/{id}",
 			method:  http.MethodPatch,
 		},
 		{
-			handler: handlers.PatchBillingPrevState(hc.billingManaging, hc.logger, hc.adminPassword),
+			handler: handlers.Adapt(handlers.PatchBillingPrevState(hc.billingManaging)),
 			path:    "/admin/billing/state/prev/{id}",
 			method:  http.MethodPatch,
 		},
 		{
-			handler: handlers.PostBilling(hc.billingManaging, hc.logger),
+			handler: handlers.Adapt(handlers.PatchBillingRevisionLimit(hc.billingManaging)),
+			path:    "/admin/billing/revision/limit/{id}",
+			method:  http.MethodPatch,
+		},
+		{
+			handler: handlers.Adapt(handlers.PatchBillingDeadlines(hc.billingManaging)),
+			path:    "/admin/billing/deadlines/{id}",
+			method:  http.MethodPatch,
+		},
+		{
+			handler: handlers.Adapt(handlers.PatchBillingPriority(hc.billingManaging)),
+			path:    "/admin/billing/priority/{id}",
+			method:  http.MethodPatch,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetQueue(hc.billingManaging)),
+			path:    "/admin/queue",
+			method:  http.MethodGet,
+		},
+		{
+			handler: handlers.Adapt(handlers.PatchBillingAssignee(hc.billingManaging)),
+			path:    "/admin/billing/assignee/{id}",
+			method:  http.MethodPatch,
+		},
+		{
+			handler: handlers.Adapt(handlers.PostBillingTimeEntry(hc.billingManaging)),
+			path:    "/admin/billing/time/{id}",
+			method:  http.MethodPost,
+		},
+		{
+			handler: handlers.Adapt(handlers.PostBillingTimerStart(hc.billingManaging)),
+			path:    "/admin/billing/timer/start/{id}",
+			method:  http.MethodPost,
+		},
+		{
+			handler: handlers.Adapt(handlers.PostBillingTimerStop(hc.billingManaging)),
+			path:    "/admin/billing/timer/stop/{id}",
+			method:  http.MethodPost,
+		},
+		{
+			handler: handlers.Adapt(handlers.PatchBillingPrice(hc.billingManaging)),
+			path:    "/admin/billing/price/{id}",
+			method:  http.MethodPatch,
+		},
+		{
+			handler: handlers.Adapt(handlers.PostBillingInvoice(hc.billingManaging)),
+			path:    "/admin/billing/invoice/{id}",
+			method:  http.MethodPost,
+		},
+		{
+			handler: handlers.Adapt(handlers.PostBillingPayment(hc.billingManaging)),
+			path:    "/admin/billing/payment/{id}",
+			method:  http.MethodPost,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetReportSummary(hc.reporting)),
+			path:    "/admin/reports/summary",
+			method:  http.MethodGet,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetReportRevenue(hc.reporting)),
+			path:    "/admin/reports/revenue",
+			method:  http.MethodGet,
+			raw:     true,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetReportReceivables(hc.reporting)),
+			path:    "/admin/reports/receivables",
+			method:  http.MethodGet,
+			raw:     true,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetReportStatement(hc.reporting)),
+			path:    "/admin/reports/statement/{id}",
+			method:  http.MethodGet,
+			raw:     true,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetAllStaff(hc.staffManaging)),
+			path:    "/admin/staff",
+			method:  http.MethodGet,
+		},
+		{
+			handler: handlers.Adapt(handlers.PostStaff(hc.staffManaging)),
+			path:    "/admin/staff",
+			method:  http.MethodPost,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetStaffWorkloads(hc.staffManaging)),
+			path:    "/admin/staff/workload",
+			method:  http.MethodGet,
+		},
+		{
+			handler: handlers.Adapt(handlers.DeleteStaff(hc.staffManaging)),
+			path:    "/admin/staff/{id}",
+			method:  http.MethodDelete,
+		},
+		{
+			handler: handlers.Adapt(handlers.GetAuditEntries(hc.auditing)),
+			path:    "/admin/audit",
+			method:  http.MethodGet,
+		},
+		{
+			handler: handlers.GetMetrics(),
+			path:    "/admin/metrics",
+			method:  http.MethodGet,
+		},
+		{
+			handler: handlers.Adapt(handlers.PostBillingRevision(hc.billingManaging)),
+			path:    "/billing/revision/{id}",
+			method:  http.MethodPost,
+		},
+		{
+			handler: handlers.Adapt(handlers.PostBilling(hc.billingManaging)),
 			path:    "/billing",
 			method:  http.MethodPost,
 		},
 		{
-			handler: handlers.PostUser(hc.userManaging, hc.logger),
+			handler: handlers.Adapt(handlers.PostUser(hc.userManaging)),
 			path:    "/user",
 			method:  http.MethodPost,
 		},
 	}
 
 	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(handlers.NotFound)
+	r.MethodNotAllowedHandler = http.HandlerFunc(handlers.MethodNotAllowed)
 	for _, handlerInfo := range handlersInfo {
-		r.Handle(handlerInfo.path, handlerInfo.handler).Methods(handlerInfo.method)
+		routeMiddlewares := []middleware{routeLogger(handlerInfo.path)}
+		if strings.HasPrefix(handlerInfo.path, "/admin/") {
+			routeMiddlewares = append(routeMiddlewares, adminAuth(hc.adminPassword))
+		}
+		if !handlerInfo.raw {
+			routeMiddlewares = append(routeMiddlewares, negotiateJSON)
+		}
+		r.Handle(handlerInfo.path, chain(handlerInfo.handler, routeMiddlewares...)).Methods(handlerInfo.method)
 	}
 	httpServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", hc.port),
-		Handler: r,
+		Addr: fmt.Sprintf(":%d", hc.port),
+		Handler: chain(r,
+			requestID(hc.logger),
+			accessLog,
+			recoverPanic,
+			hc.rateLimiter.middleware,
+			limitBody,
+		),
 		BaseContext: func(_ net.Listener) context.Context {
 			return ctx
 		},
@@ -110,14 +307,24 @@ func New(
 	logger zerolog.Logger,
 	billingManaging billing_managing.BillingManaging,
 	userManaging user_managing.UserManaging,
+	staffManaging staff_managing.StaffManaging,
+	reporting reporting.Reporting,
+	personalData personal_data.PersonalData,
+	auditing auditing.Auditing,
 	port int,
 	adminPassword string,
+	rateLimit RateLimit,
 ) http_controller {
 	return http_controller{
 		port:            port,
 		logger:          logger,
 		billingManaging: billingManaging,
 		userManaging:    userManaging,
-		adminPassword:   adminPassword,
+		staffManaging:   staffManaging,
+		reporting:       reporting,
+		personalData:    personalData,
+		auditing:        auditing,
+		adminPassword:   handlers.NewAdminPassword(adminPassword),
+		rateLimiter:     newRateLimiter(rateLimit),
 	}
 }
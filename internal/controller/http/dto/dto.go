@@ -1,13 +1,21 @@
 package dto
 
 import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/audit"
 	"github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/model/staff"
 	"github.com/ThePositree/billing_manager/internal/model/user"
 )
 
 type User struct {
-	Id         string `json:"id"`
-	TelegramUN string `json:"telegram_username"`
+	Id         string     `json:"id"`
+	TelegramUN string     `json:"telegram_username"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy  string     `json:"deleted_by,omitempty"`
 }
 
 type CreateUserInfo struct {
@@ -22,22 +30,193 @@ func (u User) GetTelegramUN() string {
 	return u.TelegramUN
 }
 
+func (u User) GetDeletedAt() time.Time {
+	if u.DeletedAt == nil {
+		return time.Time{}
+	}
+	return *u.DeletedAt
+}
+
+func (u User) GetDeletedBy() string {
+	return u.DeletedBy
+}
+
 func (u User) ToModel() (user.User, error) {
 	return user.ToModelFromDTO(u)
 }
 
 func NewUserDTOFromModel(user user.User) User {
+	var deletedAt *time.Time
+	if userDeletedAt := user.GetDeletedAt(); !userDeletedAt.IsZero() {
+		deletedAt = &userDeletedAt
+	}
 	return User{
 		Id:         user.Id,
 		TelegramUN: user.TelegramUN,
+		DeletedAt:  deletedAt,
+		DeletedBy:  user.GetDeletedBy(),
 	}
 }
 
+type Revision struct {
+	Stage       string    `json:"stage"`
+	RequestedBy string    `json:"requested_by"`
+	Note        string    `json:"note"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (r Revision) GetStage() string {
+	return r.Stage
+}
+
+func (r Revision) GetRequestedBy() string {
+	return r.RequestedBy
+}
+
+func (r Revision) GetNote() string {
+	return r.Note
+}
+
+func (r Revision) GetCreatedAt() time.Time {
+	return r.CreatedAt
+}
+
+type LineItem struct {
+	Description string `json:"description"`
+	Amount      int64  `json:"amount"`
+}
+
+func (l LineItem) GetDescription() string {
+	return l.Description
+}
+
+func (l LineItem) GetAmount() int64 {
+	return l.Amount
+}
+
+// Duration is a time.Duration encoded in JSON as a Go duration string like "72h".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(bytes []byte) error {
+	var value string
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+type StateChange struct {
+	State     string    `json:"state"`
+	EnteredAt time.Time `json:"entered_at"`
+}
+
+func (s StateChange) GetState() string {
+	return s.State
+}
+
+func (s StateChange) GetEnteredAt() time.Time {
+	return s.EnteredAt
+}
+
+type RevisionLimit struct {
+	Included  int    `json:"included"`
+	Policy    string `json:"policy"`
+	Surcharge int64  `json:"surcharge"`
+}
+
+type TimeEntry struct {
+	Id        string     `json:"id"`
+	StaffId   string     `json:"staff_id"`
+	Stage     string     `json:"stage"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Note      string     `json:"note"`
+}
+
+func (e TimeEntry) GetId() string {
+	return e.Id
+}
+
+func (e TimeEntry) GetStaffId() string {
+	return e.StaffId
+}
+
+func (e TimeEntry) GetStage() string {
+	return e.Stage
+}
+
+func (e TimeEntry) GetStartedAt() time.Time {
+	return e.StartedAt
+}
+
+func (e TimeEntry) GetEndedAt() time.Time {
+	if e.EndedAt == nil {
+		return time.Time{}
+	}
+	return *e.EndedAt
+}
+
+func (e TimeEntry) GetNote() string {
+	return e.Note
+}
+
+type Payment struct {
+	Id     string    `json:"id"`
+	Amount int64     `json:"amount"`
+	PaidAt time.Time `json:"paid_at"`
+	Note   string    `json:"note"`
+}
+
+func (p Payment) GetId() string {
+	return p.Id
+}
+
+func (p Payment) GetAmount() int64 {
+	return p.Amount
+}
+
+func (p Payment) GetPaidAt() time.Time {
+	return p.PaidAt
+}
+
+func (p Payment) GetNote() string {
+	return p.Note
+}
+
 type Billing struct {
-	Id       string `json:"id"`
-	UserId   string `json:"user_id"`
-	State    string `json:"state"`
-	Username string `json:"username"`
+	Id            string              `json:"id"`
+	UserId        string              `json:"user_id"`
+	State         string              `json:"state"`
+	Username      string              `json:"username"`
+	Revisions     []Revision          `json:"revisions"`
+	RevisionLimit RevisionLimit       `json:"revision_limit"`
+	LineItems     []LineItem          `json:"line_items"`
+	CreatedAt     time.Time           `json:"created_at"`
+	StateChanges  []StateChange       `json:"state_changes"`
+	Deadline      *time.Time          `json:"deadline"`
+	StageTargets  map[string]Duration `json:"stage_targets"`
+	Priority      string              `json:"priority"`
+	Assignees     map[string]string   `json:"assignees"`
+	TimeEntries   []TimeEntry         `json:"time_entries"`
+	TimeTotals    map[string]Duration `json:"time_totals"`
+	Price         int64               `json:"price"`
+	Total         int64               `json:"total"`
+	InvoicedAt    *time.Time          `json:"invoiced_at"`
+	Payments      []Payment           `json:"payments"`
+	Paid          int64               `json:"paid"`
+	Outstanding   int64               `json:"outstanding"`
+	Overdue       bool                `json:"overdue"`
+	DeletedAt     *time.Time          `json:"deleted_at,omitempty"`
+	DeletedBy     string              `json:"deleted_by,omitempty"`
 }
 
 type CreateBillingInfo struct {
@@ -64,15 +243,411 @@ func (u Billing) GetState() string {
 	return u.State
 }
 
+func (u Billing) GetRevisions() []billing.RevisionDTO {
+	var result []billing.RevisionDTO
+	for _, revision := range u.Revisions {
+		result = append(result, revision)
+	}
+	return result
+}
+
+func (u Billing) GetIncludedRevisions() int {
+	return u.RevisionLimit.Included
+}
+
+func (u Billing) GetRevisionPolicy() string {
+	return u.RevisionLimit.Policy
+}
+
+func (u Billing) GetRevisionSurcharge() int64 {
+	return u.RevisionLimit.Surcharge
+}
+
+func (u Billing) GetLineItems() []billing.LineItemDTO {
+	var result []billing.LineItemDTO
+	for _, lineItem := range u.LineItems {
+		result = append(result, lineItem)
+	}
+	return result
+}
+
+func (u Billing) GetCreatedAt() time.Time {
+	return u.CreatedAt
+}
+
+func (u Billing) GetStateChanges() []billing.StateChangeDTO {
+	var result []billing.StateChangeDTO
+	for _, stateChange := range u.StateChanges {
+		result = append(result, stateChange)
+	}
+	return result
+}
+
+func (u Billing) GetDeadline() time.Time {
+	if u.Deadline == nil {
+		return time.Time{}
+	}
+	return *u.Deadline
+}
+
+func (u Billing) GetStageTargets() map[string]time.Duration {
+	result := make(map[string]time.Duration, len(u.StageTargets))
+	for stage, target := range u.StageTargets {
+		result[stage] = time.Duration(target)
+	}
+	return result
+}
+
+func (u Billing) GetPriority() string {
+	return u.Priority
+}
+
+func (u Billing) GetAssignees() map[string]string {
+	return u.Assignees
+}
+
+func (u Billing) GetTimeEntries() []billing.TimeEntryDTO {
+	var result []billing.TimeEntryDTO
+	for _, timeEntry := range u.TimeEntries {
+		result = append(result, timeEntry)
+	}
+	return result
+}
+
+func (u Billing) GetPrice() int64 {
+	return u.Price
+}
+
+func (u Billing) GetInvoicedAt() time.Time {
+	if u.InvoicedAt == nil {
+		return time.Time{}
+	}
+	return *u.InvoicedAt
+}
+
+func (u Billing) GetDeletedAt() time.Time {
+	if u.DeletedAt == nil {
+		return time.Time{}
+	}
+	return *u.DeletedAt
+}
+
+func (u Billing) GetDeletedBy() string {
+	return u.DeletedBy
+}
+
+func (u Billing) GetPayments() []billing.PaymentDTO {
+	var result []billing.PaymentDTO
+	for _, payment := range u.Payments {
+		result = append(result, payment)
+	}
+	return result
+}
+
+func NewTimeEntryDTOFromModel(timeEntry billing.TimeEntry) TimeEntry {
+	var endedAt *time.Time
+	if !timeEntry.IsRunning() {
+		endedAt = &timeEntry.EndedAt
+	}
+	return TimeEntry{
+		Id:        timeEntry.Id,
+		StaffId:   timeEntry.StaffId,
+		Stage:     timeEntry.Stage.String(),
+		StartedAt: timeEntry.StartedAt,
+		EndedAt:   endedAt,
+		Note:      timeEntry.Note,
+	}
+}
+
 func NewBillingDTOFromModel(billing billing.Billing) Billing {
+	revisions := []Revision{}
+	for _, revision := range billing.GetRevisions() {
+		revisions = append(revisions, Revision{
+			Stage:       revision.Stage.String(),
+			RequestedBy: revision.RequestedBy,
+			Note:        revision.Note,
+			CreatedAt:   revision.CreatedAt,
+		})
+	}
+	lineItems := []LineItem{}
+	for _, lineItem := range billing.GetLineItems() {
+		lineItems = append(lineItems, LineItem{
+			Description: lineItem.Description,
+			Amount:      lineItem.Amount,
+		})
+	}
+	stateChanges := []StateChange{}
+	for _, stateChange := range billing.GetStateChanges() {
+		stateChanges = append(stateChanges, StateChange{
+			State:     stateChange.State.String(),
+			EnteredAt: stateChange.EnteredAt,
+		})
+	}
+	var deadline *time.Time
+	if billingDeadline := billing.GetDeadline(); !billingDeadline.IsZero() {
+		deadline = &billingDeadline
+	}
+	stageTargets := map[string]Duration{}
+	for stage, target := range billing.GetStageTargets() {
+		stageTargets[stage.String()] = Duration(target)
+	}
+	assignees := map[string]string{}
+	for stage, staffId := range billing.GetAssignees() {
+		assignees[stage.String()] = staffId
+	}
+	timeEntries := []TimeEntry{}
+	for _, timeEntry := range billing.GetTimeEntries() {
+		timeEntries = append(timeEntries, NewTimeEntryDTOFromModel(timeEntry))
+	}
+	timeTotals := map[string]Duration{}
+	for stage, total := range billing.GetTimeTotals() {
+		timeTotals[stage.String()] = Duration(total)
+	}
+	payments := []Payment{}
+	for _, payment := range billing.GetPayments() {
+		payments = append(payments, Payment{
+			Id:     payment.Id,
+			Amount: payment.Amount,
+			PaidAt: payment.PaidAt,
+			Note:   payment.Note,
+		})
+	}
+	var invoicedAt *time.Time
+	if billingInvoicedAt := billing.GetInvoicedAt(); !billingInvoicedAt.IsZero() {
+		invoicedAt = &billingInvoicedAt
+	}
+	var deletedAt *time.Time
+	if billingDeletedAt := billing.GetDeletedAt(); !billingDeletedAt.IsZero() {
+		deletedAt = &billingDeletedAt
+	}
+	revisionLimit := billing.GetRevisionLimit()
 	return Billing{
-		Id:       billing.Id,
-		UserId:   billing.UserId,
-		State:    billing.GetState().String(),
-		Username: billing.GetBriefInfo().Username,
+		Id:        billing.Id,
+		UserId:    billing.UserId,
+		State:     billing.GetState().String(),
+		Username:  billing.GetBriefInfo().Username,
+		Revisions: revisions,
+		RevisionLimit: RevisionLimit{
+			Included:  revisionLimit.Included,
+			Policy:    revisionLimit.Policy.String(),
+			Surcharge: revisionLimit.Surcharge,
+		},
+		LineItems:    lineItems,
+		CreatedAt:    billing.GetCreatedAt(),
+		StateChanges: stateChanges,
+		Deadline:     deadline,
+		StageTargets: stageTargets,
+		Priority:     billing.GetPriority().String(),
+		Assignees:    assignees,
+		TimeEntries:  timeEntries,
+		TimeTotals:   timeTotals,
+		Price:        billing.GetPrice(),
+		Total:        billing.GetTotal(),
+		InvoicedAt:   invoicedAt,
+		Payments:     payments,
+		Paid:         billing.GetPaid(),
+		Outstanding:  billing.GetOutstanding(),
+		Overdue:      billing.IsOverdue(time.Now()),
+		DeletedAt:    deletedAt,
+		DeletedBy:    billing.GetDeletedBy(),
 	}
 }
 
 type BriefInfo struct {
 	Username string `json:"username"`
 }
+
+type RevisionInfo struct {
+	RequestedBy string `json:"requested_by"`
+	Note        string `json:"note"`
+}
+
+type DeadlinesInfo struct {
+	Deadline     *time.Time          `json:"deadline"`
+	StageTargets map[string]Duration `json:"stage_targets"`
+}
+
+type TimeEntryInfo struct {
+	StaffId   string     `json:"staff_id"`
+	Stage     string     `json:"stage"`
+	StartedAt *time.Time `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Duration  *Duration  `json:"duration"`
+	Note      string     `json:"note"`
+}
+
+type TimerInfo struct {
+	StaffId string `json:"staff_id"`
+	Note    string `json:"note"`
+}
+
+type PriceInfo struct {
+	Price int64 `json:"price"`
+}
+
+type PaymentInfo struct {
+	Amount int64      `json:"amount"`
+	PaidAt *time.Time `json:"paid_at"`
+	Note   string     `json:"note"`
+}
+
+type PriorityInfo struct {
+	Priority string `json:"priority"`
+}
+
+type QueueGroup struct {
+	State    string    `json:"state"`
+	Billings []Billing `json:"billings"`
+}
+
+type AssignInfo struct {
+	Stage   string `json:"stage"`
+	StaffId string `json:"staff_id"`
+}
+
+type Staff struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type CreateStaffInfo struct {
+	Name string `json:"name"`
+}
+
+func (s Staff) GetId() string {
+	return s.Id
+}
+
+func (s Staff) GetName() string {
+	return s.Name
+}
+
+func (s Staff) ToModel() (staff.Staff, error) {
+	return staff.ToModelFromDTO(s)
+}
+
+func NewStaffDTOFromModel(staff staff.Staff) Staff {
+	return Staff{
+		Id:   staff.Id,
+		Name: staff.Name,
+	}
+}
+
+type Workload struct {
+	Staff   Staff          `json:"staff"`
+	ByState map[string]int `json:"by_state"`
+	Total   int            `json:"total"`
+}
+
+type PeriodCount struct {
+	Period string `json:"period"`
+	Count  int    `json:"count"`
+}
+
+type DurationStats struct {
+	Samples int      `json:"samples"`
+	Median  Duration `json:"median"`
+	P90     Duration `json:"p90"`
+}
+
+type ClientCount struct {
+	UserId     string `json:"user_id"`
+	TelegramUN string `json:"telegram_username"`
+	Count      int    `json:"count"`
+}
+
+type Summary struct {
+	Period         string                   `json:"period"`
+	From           time.Time                `json:"from"`
+	To             time.Time                `json:"to"`
+	CountsByState  map[string]int           `json:"counts_by_state"`
+	Created        []PeriodCount            `json:"created"`
+	Completed      []PeriodCount            `json:"completed"`
+	StageDurations map[string]DurationStats `json:"stage_durations"`
+	TopClients     []ClientCount            `json:"top_clients"`
+}
+
+type PeriodRevenue struct {
+	Period    string `json:"period"`
+	Invoiced  int64  `json:"invoiced"`
+	Collected int64  `json:"collected"`
+}
+
+type Revenue struct {
+	Period  string          `json:"period"`
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Periods []PeriodRevenue `json:"periods"`
+}
+
+type AgingBucket struct {
+	Name        string `json:"name"`
+	Count       int    `json:"count"`
+	Outstanding int64  `json:"outstanding"`
+}
+
+type Receivables struct {
+	AsOf    time.Time     `json:"as_of"`
+	Buckets []AgingBucket `json:"buckets"`
+	Total   int64         `json:"total"`
+}
+
+type StatementLine struct {
+	Date        time.Time `json:"date"`
+	BillingId   string    `json:"billing_id"`
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	Debit       int64     `json:"debit"`
+	Credit      int64     `json:"credit"`
+	Balance     int64     `json:"balance"`
+}
+
+type Statement struct {
+	UserId         string          `json:"user_id"`
+	TelegramUN     string          `json:"telegram_username"`
+	From           *time.Time      `json:"from,omitempty"`
+	To             time.Time       `json:"to"`
+	OpeningBalance int64           `json:"opening_balance"`
+	Lines          []StatementLine `json:"lines"`
+	ClosingBalance int64           `json:"closing_balance"`
+}
+
+type AuditChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type AuditEntry struct {
+	Id         string        `json:"id"`
+	Actor      string        `json:"actor"`
+	RequestId  string        `json:"request_id"`
+	IP         string        `json:"ip"`
+	Action     string        `json:"action"`
+	TargetType string        `json:"target_type"`
+	TargetId   string        `json:"target_id"`
+	Changes    []AuditChange `json:"changes"`
+	OccurredAt time.Time     `json:"occurred_at"`
+}
+
+func NewAuditEntryDTOFromModel(entry audit.Entry) AuditEntry {
+	changes := []AuditChange{}
+	for _, change := range entry.Changes {
+		changes = append(changes, AuditChange{
+			Field:  change.Field,
+			Before: change.Before,
+			After:  change.After,
+		})
+	}
+	return AuditEntry{
+		Id:         entry.Id,
+		Actor:      entry.Actor.Name,
+		RequestId:  entry.Actor.RequestId,
+		IP:         entry.Actor.IP,
+		Action:     entry.Action,
+		TargetType: entry.Target.Type,
+		TargetId:   entry.Target.Id,
+		Changes:    changes,
+		OccurredAt: entry.OccurredAt,
+	}
+}
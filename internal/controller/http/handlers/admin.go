@@ -1,236 +1,180 @@
 package handlers
 
 import (
-	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
 	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
 	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing"
 	"github.com/ThePositree/billing_manager/internal/usecase/user_managing"
 	"github.com/gorilla/mux"
-	"github.com/rs/zerolog"
 )
 
-func PatchBillingNextState(billingManaging billing_managing.BillingManaging, logger zerolog.Logger, password string) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		logger = logger.With().Str("Handler", "admin/billing/state/next/{id}").Str("Method", "PATCH").Logger()
-
-		_, userPassword, ok := r.BasicAuth()
-		if !ok || password != userPassword {
-			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			if err := WriteResponse(
-				w,
-				http.StatusUnauthorized,
-				ResponseMessageDTO{Message: "you are unauthorized"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Request with incorrect password")
-			}
-			return
+func PatchBillingNextState(billingManaging billing_managing.BillingManaging) Handler[NoBody, dto.Billing] {
+	return func(r *http.Request, _ NoBody) (dto.Billing, error) {
+		billing, err := billingManaging.NextState(r.Context(), mux.Vars(r)["id"])
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing next state: %w", err)
 		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
 
-		ctx := r.Context()
-
-		billingId, ok := mux.Vars(r)["id"]
-		if !ok {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "billing id in path param not found"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Request without billing id")
-			}
-			return
+func PatchBillingPrevState(billingManaging billing_managing.BillingManaging) Handler[NoBody, dto.Billing] {
+	return func(r *http.Request, _ NoBody) (dto.Billing, error) {
+		billing, err := billingManaging.PrevState(r.Context(), mux.Vars(r)["id"])
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing prev state: %w", err)
 		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
 
-		billing, err := billingManaging.NextState(ctx, billingId)
-		if errors.Is(billing_managing.ErrBillingNotFound, err) {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "billing not found"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Billing not found")
+func GetAllBillings(billingManaging billing_managing.BillingManaging) Handler[NoBody, []dto.Billing] {
+	return func(r *http.Request, _ NoBody) ([]dto.Billing, error) {
+		var filter billing_managing.Filter
+		queryParams := r.URL.Query()
+		if queryParams.Has("overdue") {
+			overdue, err := strconv.ParseBool(queryParams.Get("overdue"))
+			if err != nil {
+				return nil, ValidationProblem(FieldError{Field: "overdue", Code: FieldInvalid, Message: "overdue in query param must be a boolean"})
 			}
-			return
-		}
-		if errors.Is(model_billing.ErrNextCompletedState{}, err) {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "impossible to next the state from completed state"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Next from completed state")
-			}
-			return
+			filter.Overdue = &overdue
 		}
+		filter.AssigneeId = queryParams.Get("assignee_id")
+
+		billings, err := billingManaging.GetAll(r.Context(), filter)
 		if err != nil {
-			logger.Error().Err(err).Msg("Billing managing next state")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
+			return nil, fmt.Errorf("billing managing get all: %w", err)
 		}
 
-		dto := dto.NewBillingDTOFromModel(billing)
-		if err := WriteResponse(w, http.StatusOK, dto); err != nil {
-			logger.Error().Err(err).Msg("Write OK response")
+		var result []dto.Billing
+		for _, billing := range billings {
+			result = append(result, dto.NewBillingDTOFromModel(billing))
 		}
+		return result, nil
 	}
 }
 
-func PatchBillingPrevState(billingManaging billing_managing.BillingManaging, logger zerolog.Logger, password string) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		logger = logger.With().Str("Handler", "admin/billing/state/prev/{id}").Str("Method", "PATCH").Logger()
-		ctx := r.Context()
-
-		_, userPassword, ok := r.BasicAuth()
-		if !ok || password != userPassword {
-			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			if err := WriteResponse(
-				w,
-				http.StatusUnauthorized,
-				ResponseMessageDTO{Message: "you are unauthorized"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Request with incorrect password")
-			}
-			return
+func GetAllUsers(userManaging user_managing.UserManaging) Handler[NoBody, []dto.User] {
+	return func(r *http.Request, _ NoBody) ([]dto.User, error) {
+		users, err := userManaging.GetAll(r.Context())
+		if err != nil {
+			return nil, fmt.Errorf("user managing get all: %w", err)
 		}
 
-		billingId, ok := mux.Vars(r)["id"]
-		if !ok {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "billing id in path param not found"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Request without billing id")
-			}
-			return
+		var result []dto.User
+		for _, user := range users {
+			result = append(result, dto.NewUserDTOFromModel(user))
 		}
+		return result, nil
+	}
+}
 
-		billing, err := billingManaging.PrevState(ctx, billingId)
-		if errors.Is(billing_managing.ErrBillingNotFound, err) {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "billing not found"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Billing not found")
-			}
-			return
-		}
-		if errors.Is(model_billing.ErrPrevPendingState{}, err) {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "impossible to prev the state from pending state"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Prev from pending state")
-			}
-			return
-		}
+func PatchBillingRevisionLimit(billingManaging billing_managing.BillingManaging) Handler[dto.RevisionLimit, dto.Billing] {
+	return func(r *http.Request, revisionLimit dto.RevisionLimit) (dto.Billing, error) {
+		policy, err := model_billing.ParseRevisionPolicy(revisionLimit.Policy)
 		if err != nil {
-			logger.Error().Err(err).Msg("Billing managing prev state")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
+			return dto.Billing{}, ValidationProblem(FieldError{Field: "policy", Code: FieldInvalid, Message: err.Error()})
 		}
 
-		dto := dto.NewBillingDTOFromModel(billing)
-		if err := WriteResponse(w, http.StatusOK, dto); err != nil {
-			logger.Error().Err(err).Msg("Write OK response")
+		billing, err := billingManaging.SetRevisionLimit(r.Context(), mux.Vars(r)["id"], model_billing.RevisionLimit{
+			Included:  revisionLimit.Included,
+			Policy:    policy,
+			Surcharge: revisionLimit.Surcharge,
+		})
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing set revision limit: %w", err)
 		}
+		return dto.NewBillingDTOFromModel(billing), nil
 	}
 }
 
-func GetAllBillings(billingManaging billing_managing.BillingManaging, logger zerolog.Logger, password string) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		logger = logger.With().Str("Handler", "admin/billings").Str("Method", "GET").Logger()
-		ctx := r.Context()
-
-		_, userPassword, ok := r.BasicAuth()
-		if !ok || password != userPassword {
-			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			if err := WriteResponse(
-				w,
-				http.StatusUnauthorized,
-				ResponseMessageDTO{Message: "you are unauthorized"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Request with incorrect password")
+func PatchBillingDeadlines(billingManaging billing_managing.BillingManaging) Handler[dto.DeadlinesInfo, dto.Billing] {
+	return func(r *http.Request, deadlinesInfo dto.DeadlinesInfo) (dto.Billing, error) {
+		var deadline time.Time
+		if deadlinesInfo.Deadline != nil {
+			deadline = *deadlinesInfo.Deadline
+		}
+		stageTargets := map[model_billing.State]time.Duration{}
+		for dtoStage, target := range deadlinesInfo.StageTargets {
+			stage, err := model_billing.ParseState(dtoStage)
+			if err != nil {
+				return dto.Billing{}, ValidationProblem(FieldError{Field: "stage_targets." + dtoStage, Code: FieldInvalid, Message: err.Error()})
 			}
-			return
+			stageTargets[stage] = time.Duration(target)
 		}
 
-		billings, err := billingManaging.GetAll(ctx)
+		billing, err := billingManaging.SetDeadlines(r.Context(), mux.Vars(r)["id"], deadline, stageTargets)
 		if err != nil {
-			logger.Error().Err(err).Msg("Billing managing get all")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
+			return dto.Billing{}, fmt.Errorf("billing managing set deadlines: %w", err)
 		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
 
-		var result []dto.Billing
-		for _, billing := range billings {
-			result = append(result, dto.NewBillingDTOFromModel(billing))
+func PatchBillingPriority(billingManaging billing_managing.BillingManaging) Handler[dto.PriorityInfo, dto.Billing] {
+	return func(r *http.Request, priorityInfo dto.PriorityInfo) (dto.Billing, error) {
+		priority, err := model_billing.ParsePriority(priorityInfo.Priority)
+		if err != nil {
+			return dto.Billing{}, ValidationProblem(FieldError{Field: "priority", Code: FieldInvalid, Message: err.Error()})
 		}
-		if err := WriteResponse(w, http.StatusOK, result); err != nil {
-			logger.Error().Err(err).Msg("Write OK response")
+
+		billing, err := billingManaging.SetPriority(r.Context(), mux.Vars(r)["id"], priority)
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing set priority: %w", err)
 		}
+		return dto.NewBillingDTOFromModel(billing), nil
 	}
 }
 
-func GetAllUsers(userManaging user_managing.UserManaging, logger zerolog.Logger, password string) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		logger = logger.With().Str("Handler", "admin/users").Str("Method", "GET").Logger()
-		ctx := r.Context()
+func GetQueue(billingManaging billing_managing.BillingManaging) Handler[NoBody, []dto.QueueGroup] {
+	return func(r *http.Request, _ NoBody) ([]dto.QueueGroup, error) {
+		groups, err := billingManaging.GetQueue(r.Context())
+		if err != nil {
+			return nil, fmt.Errorf("billing managing get queue: %w", err)
+		}
 
-		_, userPassword, ok := r.BasicAuth()
-		if !ok || password != userPassword {
-			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			if err := WriteResponse(
-				w,
-				http.StatusUnauthorized,
-				ResponseMessageDTO{Message: "you are unauthorized"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Request with incorrect password")
+		result := []dto.QueueGroup{}
+		for _, group := range groups {
+			billings := []dto.Billing{}
+			for _, billing := range group.Billings {
+				billings = append(billings, dto.NewBillingDTOFromModel(billing))
 			}
-			return
+			result = append(result, dto.QueueGroup{
+				State:    group.State.String(),
+				Billings: billings,
+			})
 		}
+		return result, nil
+	}
+}
 
-		users, err := userManaging.GetAll(ctx)
+func PatchBillingAssignee(billingManaging billing_managing.BillingManaging) Handler[dto.AssignInfo, dto.Billing] {
+	return func(r *http.Request, assignInfo dto.AssignInfo) (dto.Billing, error) {
+		stage, err := model_billing.ParseState(assignInfo.Stage)
 		if err != nil {
-			logger.Error().Err(err).Msg("Billing managing get all")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
+			return dto.Billing{}, ValidationProblem(FieldError{Field: "stage", Code: FieldInvalid, Message: err.Error()})
 		}
 
-		var result []dto.User
-		for _, user := range users {
-			result = append(result, dto.NewUserDTOFromModel(user))
+		billing, err := billingManaging.Assign(r.Context(), mux.Vars(r)["id"], stage, assignInfo.StaffId)
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing assign: %w", err)
 		}
-		if err := WriteResponse(w, http.StatusOK, result); err != nil {
-			logger.Error().Err(err).Msg("Write OK response")
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
+
+func DeleteUser(userManaging user_managing.UserManaging) Handler[NoBody, dto.User] {
+	return func(r *http.Request, _ NoBody) (dto.User, error) {
+		ctx := r.Context()
+		user, err := userManaging.Delete(ctx, mux.Vars(r)["id"], deletedBy(ctx))
+		if err != nil {
+			return dto.User{}, fmt.Errorf("user managing delete: %w", err)
 		}
+		return dto.NewUserDTOFromModel(user), nil
 	}
 }
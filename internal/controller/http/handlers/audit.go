@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
+)
+
+func GetAuditEntries(auditingUsecase auditing.Auditing) Handler[NoBody, []dto.AuditEntry] {
+	return func(r *http.Request, _ NoBody) ([]dto.AuditEntry, error) {
+		queryParams := r.URL.Query()
+		filter := audit.Filter{
+			Actor:      queryParams.Get("actor"),
+			Action:     queryParams.Get("action"),
+			TargetType: queryParams.Get("target_type"),
+			TargetId:   queryParams.Get("target_id"),
+		}
+		if err := parseTimeParams(queryParams, map[string]*time.Time{"from": &filter.From, "to": &filter.To}); err != nil {
+			return nil, err
+		}
+		if queryParams.Has("limit") {
+			limit, err := strconv.Atoi(queryParams.Get("limit"))
+			if err != nil || limit <= 0 {
+				return nil, ValidationProblem(FieldError{Field: "limit", Code: FieldInvalid, Message: "limit in query param must be a positive integer"})
+			}
+			filter.Limit = limit
+		}
+
+		entries, err := auditingUsecase.Find(r.Context(), filter)
+		if err != nil {
+			return nil, fmt.Errorf("auditing find: %w", err)
+		}
+
+		result := []dto.AuditEntry{}
+		for _, entry := range entries {
+			result = append(result, dto.NewAuditEntryDTOFromModel(entry))
+		}
+		return result, nil
+	}
+}
@@ -1,269 +1,86 @@
 package handlers
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
-	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
 	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing"
 	"github.com/gorilla/mux"
-	"github.com/rs/zerolog"
 )
 
-func GetBilling(billingManaging billing_managing.BillingManaging, logger zerolog.Logger) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		logger = logger.With().Str("Handler", "billing").Str("Method", "GET").Logger()
-
-		ctx := r.Context()
-
+func GetBilling(billingManaging billing_managing.BillingManaging) Handler[NoBody, []dto.Billing] {
+	return func(r *http.Request, _ NoBody) ([]dto.Billing, error) {
 		queryParams := r.URL.Query()
-		ok := queryParams.Has("user_id")
-		if !ok {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "user_id in query param not found"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Request without user_id")
-			}
-			return
+		if !queryParams.Has("user_id") {
+			return nil, ValidationProblem(FieldError{Field: "user_id", Code: FieldRequired, Message: "user_id in query param not found"})
 		}
 
-		userID := queryParams.Get("user_id")
-		billings, err := billingManaging.GetAllByUserId(ctx, userID)
-		if errors.Is(billing_managing.ErrUserNotFound, err) {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "user not found"},
-			); err != nil {
-				logger.Error().Err(err).Msg("User not found")
-			}
-			return
-		}
+		billings, err := billingManaging.GetAllByUserId(r.Context(), queryParams.Get("user_id"))
 		if err != nil {
-			logger.Error().Err(err).Msg("Billing managing get all by user id")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
+			return nil, fmt.Errorf("billing managing get all by user id: %w", err)
 		}
 		var result []dto.Billing
 		for _, billing := range billings {
-			billingDTO := dto.NewBillingDTOFromModel(billing)
-			result = append(result, billingDTO)
-		}
-		if err := WriteResponse(w, http.StatusOK, result); err != nil {
-			logger.Error().Err(err).Msg("Write OK response")
+			result = append(result, dto.NewBillingDTOFromModel(billing))
 		}
+		return result, nil
 	}
 }
 
-func PostBilling(billingManaging billing_managing.BillingManaging, logger zerolog.Logger) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		logger = logger.With().Str("Handler", "billing").Str("Method", "POST").Logger()
-		ctx := r.Context()
-
-		bytes, err := io.ReadAll(r.Body)
-		if err != nil {
-			logger.Error().Err(err).Msg("Read body")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
-		}
-
-		var billingInfo dto.CreateBillingInfo
-
-		err = json.Unmarshal(bytes, &billingInfo)
-		if err != nil {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: fmt.Sprintf("wrong structure body: %s", err.Error())},
-			); err != nil {
-				logger.Error().Err(err).Msg("Json unmarshal")
-			}
-			return
-		}
+func PostBilling(billingManaging billing_managing.BillingManaging) Handler[dto.CreateBillingInfo, dto.Billing] {
+	return func(r *http.Request, billingInfo dto.CreateBillingInfo) (dto.Billing, error) {
 		if billingInfo.UserId == "" {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "user_id cannot be empty"},
-			); err != nil {
-				logger.Error().Err(err).Msg("user_id cannot be empty")
-			}
-			return
+			return dto.Billing{}, ValidationProblem(FieldError{Field: "user_id", Code: FieldRequired, Message: "user_id cannot be empty"})
 		}
 
-		billing, err := billingManaging.Create(ctx, billingInfo.UserId)
-		if errors.Is(billing_managing.ErrUserNotFound, err) {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "user not found"},
-			); err != nil {
-				logger.Error().Err(err).Msg("User not found")
-			}
-			return
-		}
+		billing, err := billingManaging.Create(r.Context(), billingInfo.UserId)
 		if err != nil {
-			logger.Error().Err(err).Msg("Billing managing create")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
-		}
-		dto := dto.NewBillingDTOFromModel(billing)
-		if err := WriteResponse(w, http.StatusOK, dto); err != nil {
-			logger.Error().Err(err).Msg("Write OK response")
+			return dto.Billing{}, fmt.Errorf("billing managing create: %w", err)
 		}
+		return dto.NewBillingDTOFromModel(billing), nil
 	}
 }
 
-func PatchBilling(billingManaging billing_managing.BillingManaging, logger zerolog.Logger) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		logger = logger.With().Str("Handler", "billing/{id}").Str("Method", "PATCH").Logger()
+func PatchBilling(billingManaging billing_managing.BillingManaging) Handler[dto.BriefInfo, dto.Billing] {
+	return func(r *http.Request, briefInfo dto.BriefInfo) (dto.Billing, error) {
 		ctx := r.Context()
-
-		billingId, ok := mux.Vars(r)["id"]
-		if !ok {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "billing id in path param not found"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Request without billing id")
-			}
-			return
-		}
+		billingId := mux.Vars(r)["id"]
 
 		billing, err := billingManaging.GetById(ctx, billingId)
-		if errors.Is(billing_managing.ErrBillingNotFound, err) {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "billing not found"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Billing not found")
-			}
-			return
-		}
 		if err != nil {
-			logger.Error().Err(err).Msg("Billing managing get by id")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
+			return dto.Billing{}, fmt.Errorf("billing managing get by id: %w", err)
 		}
-
 		if billing.GetBriefInfo().Username != "" {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "brief info already existing"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Brief info already existing")
-			}
-			return
+			return dto.Billing{}, NewProblem(http.StatusConflict, CodeBriefAlreadySet, "brief info already existing")
 		}
-
-		bytes, err := io.ReadAll(r.Body)
-		if err != nil {
-			logger.Error().Err(err).Msg("Read body")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
+		if briefInfo.Username == "" {
+			return dto.Billing{}, ValidationProblem(FieldError{Field: "username", Code: FieldRequired, Message: "username cannot be empty"})
 		}
 
-		var briefInfo dto.BriefInfo
-
-		err = json.Unmarshal(bytes, &briefInfo)
+		_, err = billingManaging.SetBriefInfo(ctx, billingId, briefInfo.Username)
 		if err != nil {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: fmt.Sprintf("wrong structure body: %s", err.Error())},
-			); err != nil {
-				logger.Error().Err(err).Msg("Json unmarshal")
-			}
-			return
-		}
-		if briefInfo.Username == "" {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "username cannot be empty"},
-			); err != nil {
-				logger.Error().Err(err).Msg("username cannot be empty")
-			}
-			return
+			return dto.Billing{}, fmt.Errorf("billing managing set brief info: %w", err)
 		}
 
-		billing, err = billingManaging.SetBriefInfo(ctx, billingId, briefInfo.Username)
+		billing, err = billingManaging.NextState(ctx, billingId)
 		if err != nil {
-			logger.Error().Err(err).Msg("Billing managing set brief info")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
+			return dto.Billing{}, fmt.Errorf("billing managing next state: %w", err)
 		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
 
-		billing, err = billingManaging.NextState(ctx, billingId)
-		if errors.Is(model_billing.ErrNextCompletedState{}, err) {
-			if err := WriteResponse(
-				w,
-				http.StatusBadRequest,
-				ResponseMessageDTO{Message: "impossible to next the state from completed state"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Next from completed state")
-			}
-			return
+func PostBillingRevision(billingManaging billing_managing.BillingManaging) Handler[dto.RevisionInfo, dto.Billing] {
+	return func(r *http.Request, revisionInfo dto.RevisionInfo) (dto.Billing, error) {
+		if revisionInfo.RequestedBy == "" {
+			return dto.Billing{}, ValidationProblem(FieldError{Field: "requested_by", Code: FieldRequired, Message: "requested_by cannot be empty"})
 		}
+
+		billing, err := billingManaging.RequestRevision(r.Context(), mux.Vars(r)["id"], revisionInfo.RequestedBy, revisionInfo.Note)
 		if err != nil {
-			logger.Error().Err(err).Msg("Billing managing next state")
-			if err := WriteResponse(
-				w,
-				http.StatusInternalServerError,
-				ResponseMessageDTO{Message: "internal server error"},
-			); err != nil {
-				logger.Error().Err(err).Msg("Internal server error")
-			}
-			return
-		}
-		dto := dto.NewBillingDTOFromModel(billing)
-		if err := WriteResponse(w, http.StatusOK, dto); err != nil {
-			logger.Error().Err(err).Msg("Write OK response")
+			return dto.Billing{}, fmt.Errorf("billing managing request revision: %w", err)
 		}
+		return dto.NewBillingDTOFromModel(billing), nil
 	}
 }
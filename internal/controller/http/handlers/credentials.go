@@ -0,0 +1,23 @@
+package handlers
+
+import "sync/atomic"
+
+// AdminPassword is shared by all admin handlers and may be replaced
+// while the server is running.
+type AdminPassword struct {
+	value atomic.Pointer[string]
+}
+
+func (p *AdminPassword) Get() string {
+	return *p.value.Load()
+}
+
+func (p *AdminPassword) Set(password string) {
+	p.value.Store(&password)
+}
+
+func NewAdminPassword(password string) *AdminPassword {
+	adminPassword := &AdminPassword{}
+	adminPassword.Set(password)
+	return adminPassword
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
+	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing"
+	"github.com/ThePositree/billing_manager/internal/usecase/user_managing"
+	"github.com/gorilla/mux"
+)
+
+// deletedBy names the admin in soft deletion records, the auth middleware
+// puts the admin into ctx as the actor of the request.
+func deletedBy(ctx context.Context) string {
+	actor, _ := auditing.ActorFromContext(ctx)
+	return actor.Name
+}
+
+func DeleteBilling(billingManaging billing_managing.BillingManaging) Handler[NoBody, dto.Billing] {
+	return func(r *http.Request, _ NoBody) (dto.Billing, error) {
+		ctx := r.Context()
+		billing, err := billingManaging.Delete(ctx, mux.Vars(r)["id"], deletedBy(ctx))
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing delete: %w", err)
+		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
+
+func PatchBillingRestore(billingManaging billing_managing.BillingManaging) Handler[NoBody, dto.Billing] {
+	return func(r *http.Request, _ NoBody) (dto.Billing, error) {
+		billing, err := billingManaging.Restore(r.Context(), mux.Vars(r)["id"])
+		if errors.Is(billing_managing.ErrUserNotFound, err) {
+			return dto.Billing{}, NewProblem(http.StatusConflict, CodeUserDeleted, "user of the billing is deleted")
+		}
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing restore: %w", err)
+		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
+
+func PatchUserRestore(userManaging user_managing.UserManaging) Handler[NoBody, dto.User] {
+	return func(r *http.Request, _ NoBody) (dto.User, error) {
+		user, err := userManaging.Restore(r.Context(), mux.Vars(r)["id"])
+		if err != nil {
+			return dto.User{}, fmt.Errorf("user managing restore: %w", err)
+		}
+		return dto.NewUserDTOFromModel(user), nil
+	}
+}
+
+func GetDeletedBillings(billingManaging billing_managing.BillingManaging) Handler[NoBody, []dto.Billing] {
+	return func(r *http.Request, _ NoBody) ([]dto.Billing, error) {
+		billings, err := billingManaging.GetAllDeleted(r.Context())
+		if err != nil {
+			return nil, fmt.Errorf("billing managing get all deleted: %w", err)
+		}
+
+		result := []dto.Billing{}
+		for _, billing := range billings {
+			result = append(result, dto.NewBillingDTOFromModel(billing))
+		}
+		return result, nil
+	}
+}
+
+func GetDeletedUsers(userManaging user_managing.UserManaging) Handler[NoBody, []dto.User] {
+	return func(r *http.Request, _ NoBody) ([]dto.User, error) {
+		users, err := userManaging.GetAllDeleted(r.Context())
+		if err != nil {
+			return nil, fmt.Errorf("user managing get all deleted: %w", err)
+		}
+
+		result := []dto.User{}
+		for _, user := range users {
+			result = append(result, dto.NewUserDTOFromModel(user))
+		}
+		return result, nil
+	}
+}
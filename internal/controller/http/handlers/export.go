@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ParseFormat reads the format query param, json is the default.
+func ParseFormat(r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		return FormatJSON, true
+	case FormatJSON, FormatCSV, FormatXLSX:
+		return format, true
+	}
+	return "", false
+}
+
+// formatParam is ParseFormat with the validation problem for handlers.
+func formatParam(r *http.Request) (string, error) {
+	format, ok := ParseFormat(r)
+	if !ok {
+		return "", ValidationProblem(FieldError{Field: "format", Code: FieldInvalid, Message: "format must be one of json, csv, xlsx"})
+	}
+	return format, nil
+}
+
+// Report is a response sent as JSON, or as a table with Header and Rows
+// when a table format was asked for.
+type Report[T any] struct {
+	Result   T
+	Format   string
+	Filename string
+	Header   []string
+	Rows     [][]string
+}
+
+func (r Report[T]) Render(w http.ResponseWriter) error {
+	if r.Format != FormatJSON {
+		return WriteTable(w, r.Format, r.Filename, r.Header, r.Rows)
+	}
+	return WriteResponse(w, http.StatusOK, r.Result)
+}
+
+// WriteTable sends the rows as a CSV or XLSX attachment named filename
+// with the matching extension.
+func WriteTable(w http.ResponseWriter, format string, filename string, header []string, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+		w.WriteHeader(http.StatusOK)
+
+		writer := csv.NewWriter(w)
+		if err := writer.Write(header); err != nil {
+			return fmt.Errorf("writing csv header: %w", err)
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return fmt.Errorf("writing csv rows: %w", err)
+		}
+		return nil
+	case FormatXLSX:
+		file := excelize.NewFile()
+		defer file.Close()
+
+		sheet := file.GetSheetName(0)
+		for i, row := range append([][]string{header}, rows...) {
+			cell, err := excelize.CoordinatesToCellName(1, i+1)
+			if err != nil {
+				return fmt.Errorf("getting cell name: %w", err)
+			}
+			if err := file.SetSheetRow(sheet, cell, &row); err != nil {
+				return fmt.Errorf("setting xlsx row: %w", err)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, filename))
+		w.WriteHeader(http.StatusOK)
+		if err := file.Write(w); err != nil {
+			return fmt.Errorf("writing xlsx: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported table format %q", format)
+}
+
+// FormatAmount renders minor currency units as a decimal with two places.
+func FormatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
+	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing"
+	"github.com/gorilla/mux"
+)
+
+func PatchBillingPrice(billingManaging billing_managing.BillingManaging) Handler[dto.PriceInfo, dto.Billing] {
+	return func(r *http.Request, priceInfo dto.PriceInfo) (dto.Billing, error) {
+		billing, err := billingManaging.SetPrice(r.Context(), mux.Vars(r)["id"], priceInfo.Price)
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing set price: %w", err)
+		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
+
+func PostBillingInvoice(billingManaging billing_managing.BillingManaging) Handler[NoBody, dto.Billing] {
+	return func(r *http.Request, _ NoBody) (dto.Billing, error) {
+		billing, err := billingManaging.Invoice(r.Context(), mux.Vars(r)["id"])
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing invoice: %w", err)
+		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
+
+func PostBillingPayment(billingManaging billing_managing.BillingManaging) Handler[dto.PaymentInfo, dto.Billing] {
+	return func(r *http.Request, paymentInfo dto.PaymentInfo) (dto.Billing, error) {
+		var paidAt time.Time
+		if paymentInfo.PaidAt != nil {
+			paidAt = *paymentInfo.PaidAt
+		}
+
+		billing, err := billingManaging.AddPayment(r.Context(), mux.Vars(r)["id"], paymentInfo.Amount, paidAt, paymentInfo.Note)
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing add payment: %w", err)
+		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rs/zerolog"
+)
+
+// NoBody is the request of handlers that read nothing from the body.
+type NoBody struct{}
+
+// Handler is an endpoint reduced to its typed request and response, path
+// and query params are still read from r. A returned Problem is sent as
+// is, other errors go through the problem mappings.
+type Handler[Req any, Resp any] func(r *http.Request, req Req) (Resp, error)
+
+// Renderer is a response that writes itself instead of being sent as
+// JSON, attachments and tables are renderers.
+type Renderer interface {
+	Render(w http.ResponseWriter) error
+}
+
+// Adapt turns a handler into an http.HandlerFunc: it decodes the JSON
+// body into Req, calls the handler and writes the response or the problem.
+// Auth, logging and content negotiation are left to the middleware.
+func Adapt[Req any, Resp any](handler Handler[Req, Resp]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := zerolog.Ctx(r.Context())
+
+		var req Req
+		if _, noBody := any(req).(NoBody); !noBody {
+			if err := decodeBody(r, &req); err != nil {
+				writeError(w, r, err)
+				return
+			}
+		}
+
+		resp, err := handler(r, req)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		if renderer, ok := any(resp).(Renderer); ok {
+			if err := renderer.Render(w); err != nil {
+				logger.Error().Err(err).Msg("Render response")
+			}
+			return
+		}
+		if err := WriteResponse(w, http.StatusOK, resp); err != nil {
+			logger.Error().Err(err).Msg("Write OK response")
+		}
+	}
+}
+
+func decodeBody(r *http.Request, req any) error {
+	bytes, err := io.ReadAll(r.Body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return NewProblem(http.StatusRequestEntityTooLarge, CodeBodyTooLarge, fmt.Sprintf("body is larger than %d bytes", maxBytesErr.Limit))
+	}
+	if err != nil {
+		return fmt.Errorf("reading body: %w", err)
+	}
+	if err := json.Unmarshal(bytes, req); err != nil {
+		return MalformedBodyProblem(err)
+	}
+	return nil
+}
+
+// Attachment is a file sent with Content-Disposition attachment.
+type Attachment struct {
+	ContentType string
+	Filename    string
+	Body        []byte
+}
+
+func (a Attachment) Render(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, a.Filename))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(a.Body); err != nil {
+		return fmt.Errorf("writing attachment: %w", err)
+	}
+	return nil
+}
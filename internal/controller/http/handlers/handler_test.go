@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type echoRequest struct {
+	Name string `json:"name"`
+}
+
+type echoResponse struct {
+	Greeting string `json:"greeting"`
+}
+
+func echo(r *http.Request, req echoRequest) (echoResponse, error) {
+	return echoResponse{Greeting: "hello " + req.Name}, nil
+}
+
+func TestAdaptBody(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		limit           int64
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+		wantCode        string
+	}{
+		{
+			name:            "json",
+			body:            `{"name":"alice"}`,
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `{"greeting":"hello alice"}`,
+		},
+		{
+			name:       "malformed",
+			body:       `{"name":`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeMalformedBody,
+		},
+		{
+			name:       "wrong type",
+			body:       `{"name":42}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeMalformedBody,
+		},
+		{
+			name:       "empty",
+			body:       "",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeMalformedBody,
+		},
+		{
+			name:       "too large",
+			body:       `{"name":"` + strings.Repeat("a", 64) + `"}`,
+			limit:      32,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   CodeBodyTooLarge,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(test.body))
+			recorder := httptest.NewRecorder()
+			if test.limit > 0 {
+				request.Body = http.MaxBytesReader(recorder, request.Body, test.limit)
+			}
+			Adapt(echo).ServeHTTP(recorder, request)
+
+			require.Equal(t, test.wantStatus, recorder.Code)
+			if test.wantCode == "" {
+				require.Equal(t, test.wantContentType, recorder.Header().Get("Content-Type"))
+				require.JSONEq(t, test.wantBody, recorder.Body.String())
+				return
+			}
+			require.Equal(t, problemContentType, recorder.Header().Get("Content-Type"))
+			var problem Problem
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+			require.Equal(t, test.wantCode, problem.Code)
+		})
+	}
+}
+
+func TestAdaptNoBody(t *testing.T) {
+	handler := Adapt(func(r *http.Request, _ NoBody) ([]string, error) {
+		return []string{r.URL.Query().Get("q")}, nil
+	})
+	// The body of NoBody handlers is never read, not even when it is not JSON.
+	request := httptest.NewRequest(http.MethodGet, "/users?q=alice", strings.NewReader("not json"))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	require.JSONEq(t, `["alice"]`, recorder.Body.String())
+}
+
+func TestAdaptRenderer(t *testing.T) {
+	handler := Adapt(func(r *http.Request, _ NoBody) (Attachment, error) {
+		return Attachment{ContentType: "text/csv", Filename: "report.csv", Body: []byte("a,b\n")}, nil
+	})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/report", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	require.Equal(t, `attachment; filename="report.csv"`, recorder.Header().Get("Content-Disposition"))
+	require.Equal(t, "a,b\n", recorder.Body.String())
+}
+
+func TestWriteResponseUnmarshalable(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := WriteResponse(recorder, http.StatusOK, map[string]any{"ch": make(chan int)})
+	require.Error(t, err)
+	// Nothing is sent, so the caller can still write an error.
+	require.Empty(t, recorder.Header().Get("Content-Type"))
+	require.Zero(t, recorder.Body.Len())
+}
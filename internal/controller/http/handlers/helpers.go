@@ -7,16 +7,12 @@ import (
 )
 
 func WriteResponse(w http.ResponseWriter, status int, jsonMessage any) error {
-	w.WriteHeader(status)
-	w.Header().Add("Content-Type", "application/json")
 	bytes, err := json.Marshal(jsonMessage)
 	if err != nil {
 		return fmt.Errorf("marshaling message json: %w", err)
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	w.Write(bytes)
 	return nil
 }
-
-type ResponseMessageDTO struct {
-	Message string `json:"message"`
-}
\ No newline at end of file
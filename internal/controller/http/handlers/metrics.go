@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+)
+
+// GetMetrics serves the expvar variables, repository cache hits and misses
+// among them, as JSON.
+func GetMetrics() http.Handler {
+	return expvar.Handler()
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
+	"github.com/ThePositree/billing_manager/internal/usecase/personal_data"
+	"github.com/ThePositree/billing_manager/internal/usecase/user_managing"
+	"github.com/gorilla/mux"
+)
+
+// exportPersonalData builds the archive in memory first so that a failed
+// export still gets a problem response.
+func exportPersonalData(ctx context.Context, personalData personal_data.PersonalData, userId string) (Attachment, error) {
+	var archive bytes.Buffer
+	if err := personalData.Export(ctx, userId, &archive); err != nil {
+		return Attachment{}, fmt.Errorf("personal data export: %w", err)
+	}
+	return Attachment{
+		ContentType: "application/zip",
+		Filename:    fmt.Sprintf("personal-data-%s.zip", userId),
+		Body:        archive.Bytes(),
+	}, nil
+}
+
+func GetPersonalData(userManaging user_managing.UserManaging, personalData personal_data.PersonalData) Handler[NoBody, Attachment] {
+	return func(r *http.Request, _ NoBody) (Attachment, error) {
+		ctx := r.Context()
+
+		queryParams := r.URL.Query()
+		if !queryParams.Has("telegram_username") {
+			return Attachment{}, ValidationProblem(FieldError{Field: "telegram_username", Code: FieldRequired, Message: "telegram_username in query param not found"})
+		}
+
+		user, err := userManaging.GetByTelegramUN(ctx, queryParams.Get("telegram_username"))
+		if err != nil {
+			return Attachment{}, fmt.Errorf("user managing get by telegram username: %w", err)
+		}
+		return exportPersonalData(ctx, personalData, user.Id)
+	}
+}
+
+func GetUserPersonalData(personalData personal_data.PersonalData) Handler[NoBody, Attachment] {
+	return func(r *http.Request, _ NoBody) (Attachment, error) {
+		return exportPersonalData(r.Context(), personalData, mux.Vars(r)["id"])
+	}
+}
+
+func PatchUserErase(personalData personal_data.PersonalData) Handler[NoBody, dto.User] {
+	return func(r *http.Request, _ NoBody) (dto.User, error) {
+		user, err := personalData.Erase(r.Context(), mux.Vars(r)["id"])
+		if err != nil {
+			return dto.User{}, fmt.Errorf("personal data erase: %w", err)
+		}
+		return dto.NewUserDTOFromModel(user), nil
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_staff "github.com/ThePositree/billing_manager/internal/model/staff"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
+	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing"
+	"github.com/ThePositree/billing_manager/internal/usecase/personal_data"
+	"github.com/ThePositree/billing_manager/internal/usecase/reporting"
+	"github.com/ThePositree/billing_manager/internal/usecase/staff_managing"
+	"github.com/ThePositree/billing_manager/internal/usecase/user_managing"
+	"github.com/rs/zerolog"
+)
+
+const problemContentType = "application/problem+json"
+
+// Codes of problems are stable, clients match on them instead of the detail.
+const (
+	CodeInternal              = "internal"
+	CodeUnauthorized          = "unauthorized"
+	CodeNotFound              = "not_found"
+	CodeMethodNotAllowed      = "method_not_allowed"
+	CodeTooManyRequests       = "too_many_requests"
+	CodeNotAcceptable         = "not_acceptable"
+	CodeUnsupportedMediaType  = "unsupported_media_type"
+	CodeBodyTooLarge          = "body_too_large"
+	CodeValidationFailed      = "validation_failed"
+	CodeMalformedBody         = "malformed_body"
+	CodeUserNotFound          = "user_not_found"
+	CodeBillingNotFound       = "billing_not_found"
+	CodeStaffNotFound         = "staff_not_found"
+	CodeUserExists            = "user_exists"
+	CodeUserHasBillings       = "user_has_billings"
+	CodeUserDeleted           = "user_deleted"
+	CodeStaffHasBillings      = "staff_has_billings"
+	CodeBriefAlreadySet       = "brief_already_set"
+	CodeNextCompletedState    = "next_completed_state"
+	CodePrevPendingState      = "prev_pending_state"
+	CodeRevisionInvalidState  = "revision_invalid_state"
+	CodeRevisionLimitExceeded = "revision_limit_exceeded"
+	CodeInvalidRevisionLimit  = "invalid_revision_limit"
+	CodeInvalidStageTarget    = "invalid_stage_target"
+	CodeAssignInvalidStage    = "assign_invalid_stage"
+	CodeTimeEntryInvalidStage = "time_entry_invalid_stage"
+	CodeInvalidTimeEntry      = "invalid_time_entry"
+	CodeTimerAlreadyRunning   = "timer_already_running"
+	CodeTimerNotRunning       = "timer_not_running"
+	CodeInvalidAmount         = "invalid_amount"
+	CodeAlreadyInvoiced       = "already_invoiced"
+	CodeNotInvoiced           = "not_invoiced"
+	CodeNothingToInvoice      = "nothing_to_invoice"
+	CodeConcurrentUpdate      = "concurrent_update"
+)
+
+// Codes of field errors in validation problems.
+const (
+	FieldRequired = "required"
+	FieldInvalid  = "invalid"
+)
+
+// Problem is an RFC 7807 problem details object. Type is about:blank,
+// so Title is the status text and Code tells problems apart.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError points at a body field or a query or path param.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("%s: %s", p.Code, p.Detail)
+}
+
+func NewProblem(status int, code string, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func ValidationProblem(fieldErrors ...FieldError) Problem {
+	problem := NewProblem(http.StatusBadRequest, CodeValidationFailed, "request is invalid")
+	problem.Errors = fieldErrors
+	return problem
+}
+
+func MalformedBodyProblem(err error) Problem {
+	return NewProblem(http.StatusBadRequest, CodeMalformedBody, fmt.Sprintf("wrong structure body: %s", err.Error()))
+}
+
+type problemMapping struct {
+	match  func(err error) bool
+	status int
+	code   string
+	// field turns the problem into a validation problem about the field.
+	field string
+	// detail replaces the error text.
+	detail string
+}
+
+func is(target error) func(err error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+func as[T error]() func(err error) bool {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+// problemMappings is the single place where usecase and model errors get
+// their status and code, the error text becomes the detail.
+var problemMappings = []problemMapping{
+	{match: is(billing_managing.ErrBillingNotFound), status: http.StatusNotFound, code: CodeBillingNotFound},
+	{match: is(billing_managing.ErrUserNotFound), status: http.StatusNotFound, code: CodeUserNotFound},
+	{match: is(billing_managing.ErrStaffNotFound), status: http.StatusNotFound, code: CodeStaffNotFound},
+	{match: is(billing_managing.ErrConcurrentUpdate), status: http.StatusConflict, code: CodeConcurrentUpdate},
+	{match: is(user_managing.ErrUserNotFound), status: http.StatusNotFound, code: CodeUserNotFound},
+	{match: is(user_managing.ErrExistingUser), status: http.StatusConflict, code: CodeUserExists},
+	{match: is(user_managing.ErrUserHasBillings), status: http.StatusConflict, code: CodeUserHasBillings},
+	{match: is(staff_managing.ErrStaffNotFound), status: http.StatusNotFound, code: CodeStaffNotFound},
+	{match: is(staff_managing.ErrStaffHasBillings), status: http.StatusConflict, code: CodeStaffHasBillings},
+	{match: is(personal_data.ErrUserNotFound), status: http.StatusNotFound, code: CodeUserNotFound},
+	{match: is(reporting.ErrUserNotFound), status: http.StatusNotFound, code: CodeUserNotFound},
+	{match: is(reporting.ErrInvalidPeriod), status: http.StatusBadRequest, code: FieldInvalid, field: "period", detail: "period must be one of day, week, month"},
+	{match: is(reporting.ErrInvalidRange), status: http.StatusBadRequest, code: FieldInvalid, field: "from", detail: "from must be before to"},
+	{match: is(auditing.ErrInvalidLimit), status: http.StatusBadRequest, code: FieldInvalid, field: "limit"},
+	{match: as[model_staff.ErrInvalidStaffId](), status: http.StatusBadRequest, code: FieldInvalid, field: "staff_id"},
+	{match: as[model_billing.ErrNextCompletedState](), status: http.StatusConflict, code: CodeNextCompletedState},
+	{match: as[model_billing.ErrPrevPendingState](), status: http.StatusConflict, code: CodePrevPendingState},
+	{match: as[model_billing.ErrRevisionInvalidState](), status: http.StatusConflict, code: CodeRevisionInvalidState},
+	{match: as[model_billing.ErrRevisionLimitExceeded](), status: http.StatusConflict, code: CodeRevisionLimitExceeded},
+	{match: as[model_billing.ErrInvalidRevisionLimit](), status: http.StatusUnprocessableEntity, code: CodeInvalidRevisionLimit},
+	{match: as[model_billing.ErrInvalidStageTarget](), status: http.StatusUnprocessableEntity, code: CodeInvalidStageTarget},
+	{match: as[model_billing.ErrAssignInvalidStage](), status: http.StatusUnprocessableEntity, code: CodeAssignInvalidStage},
+	{match: as[model_billing.ErrTimeEntryInvalidStage](), status: http.StatusUnprocessableEntity, code: CodeTimeEntryInvalidStage},
+	{match: as[model_billing.ErrInvalidTimeEntry](), status: http.StatusUnprocessableEntity, code: CodeInvalidTimeEntry},
+	{match: as[model_billing.ErrTimerAlreadyRunning](), status: http.StatusConflict, code: CodeTimerAlreadyRunning},
+	{match: as[model_billing.ErrTimerNotRunning](), status: http.StatusConflict, code: CodeTimerNotRunning},
+	{match: as[model_billing.ErrInvalidAmount](), status: http.StatusUnprocessableEntity, code: CodeInvalidAmount},
+	{match: as[model_billing.ErrAlreadyInvoiced](), status: http.StatusConflict, code: CodeAlreadyInvoiced},
+	{match: as[model_billing.ErrNotInvoiced](), status: http.StatusConflict, code: CodeNotInvoiced},
+	{match: as[model_billing.ErrNothingToInvoice](), status: http.StatusConflict, code: CodeNothingToInvoice},
+}
+
+// ProblemFromError returns false for errors without a mapping, they are
+// internal server errors. A Problem returned by a handler is kept as is.
+func ProblemFromError(err error) (Problem, bool) {
+	var problem Problem
+	if errors.As(err, &problem) {
+		return problem, true
+	}
+	for _, mapping := range problemMappings {
+		if !mapping.match(err) {
+			continue
+		}
+		detail := err.Error()
+		if mapping.detail != "" {
+			detail = mapping.detail
+		}
+		if mapping.field != "" {
+			return ValidationProblem(FieldError{Field: mapping.field, Code: mapping.code, Message: detail}), true
+		}
+		return NewProblem(mapping.status, mapping.code, detail), true
+	}
+	return Problem{}, false
+}
+
+func WriteProblem(w http.ResponseWriter, r *http.Request, problem Problem) error {
+	problem.Instance = r.URL.Path
+	bytes, err := json.Marshal(problem)
+	if err != nil {
+		return fmt.Errorf("marshaling problem json: %w", err)
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	w.Write(bytes)
+	return nil
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	if err := WriteProblem(w, r, problem); err != nil {
+		zerolog.Ctx(r.Context()).Error().Err(err).Str("Code", problem.Code).Msg("Write problem response")
+	}
+}
+
+// writeError writes the problem mapped from err, unmapped errors are
+// logged and hidden behind an internal server error.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	problem, ok := ProblemFromError(err)
+	if !ok {
+		zerolog.Ctx(r.Context()).Error().Err(err).Msg("Handle request")
+		problem = NewProblem(http.StatusInternalServerError, CodeInternal, "internal server error")
+	}
+	writeProblem(w, r, problem)
+}
+
+// NotFound and MethodNotAllowed answer requests that match no route.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, NewProblem(http.StatusNotFound, CodeNotFound, "no route for the path"))
+}
+
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, NewProblem(http.StatusMethodNotAllowed, CodeMethodNotAllowed, r.Method+" is not allowed for the path"))
+}
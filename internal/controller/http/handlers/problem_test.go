@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_staff "github.com/ThePositree/billing_manager/internal/model/staff"
+	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing"
+	"github.com/ThePositree/billing_manager/internal/usecase/reporting"
+	"github.com/ThePositree/billing_manager/internal/usecase/staff_managing"
+	"github.com/ThePositree/billing_manager/internal/usecase/user_managing"
+	"github.com/stretchr/testify/require"
+)
+
+// serveError runs a handler returning err through Adapt and decodes the problem.
+func serveError(t *testing.T, err error) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+	handler := Adapt(func(r *http.Request, _ NoBody) (NoBody, error) {
+		return NoBody{}, err
+	})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/billing/42", nil))
+
+	require.Equal(t, problemContentType, recorder.Header().Get("Content-Type"))
+	var problem Problem
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+	require.Equal(t, recorder.Code, problem.Status)
+	require.Equal(t, "about:blank", problem.Type)
+	require.Equal(t, http.StatusText(recorder.Code), problem.Title)
+	require.Equal(t, "/admin/billing/42", problem.Instance)
+	return recorder, problem
+}
+
+func TestProblemMappings(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{err: billing_managing.ErrBillingNotFound, wantStatus: http.StatusNotFound, wantCode: CodeBillingNotFound},
+		{err: billing_managing.ErrUserNotFound, wantStatus: http.StatusNotFound, wantCode: CodeUserNotFound},
+		{err: billing_managing.ErrConcurrentUpdate, wantStatus: http.StatusConflict, wantCode: CodeConcurrentUpdate},
+		{err: user_managing.ErrExistingUser, wantStatus: http.StatusConflict, wantCode: CodeUserExists},
+		{err: user_managing.ErrUserHasBillings, wantStatus: http.StatusConflict, wantCode: CodeUserHasBillings},
+		{err: staff_managing.ErrStaffHasBillings, wantStatus: http.StatusConflict, wantCode: CodeStaffHasBillings},
+		{err: model_billing.ErrNextCompletedState{}, wantStatus: http.StatusConflict, wantCode: CodeNextCompletedState},
+		{err: model_billing.ErrPrevPendingState{}, wantStatus: http.StatusConflict, wantCode: CodePrevPendingState},
+		{err: model_billing.ErrInvalidAmount{Amount: -1}, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeInvalidAmount},
+		{err: model_billing.ErrAlreadyInvoiced{}, wantStatus: http.StatusConflict, wantCode: CodeAlreadyInvoiced},
+		{err: model_billing.ErrAssignInvalidStage{Stage: model_billing.StateCompleted}, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeAssignInvalidStage},
+	}
+	for _, test := range tests {
+		t.Run(test.wantCode, func(t *testing.T) {
+			// Handlers wrap usecase errors with their context.
+			err := fmt.Errorf("billing managing next state: %w", test.err)
+			recorder, problem := serveError(t, err)
+			require.Equal(t, test.wantStatus, recorder.Code)
+			require.Equal(t, test.wantCode, problem.Code)
+			require.Equal(t, err.Error(), problem.Detail)
+			require.Empty(t, problem.Errors)
+		})
+	}
+}
+
+func TestProblemValidationMappings(t *testing.T) {
+	tests := []struct {
+		err       error
+		wantField FieldError
+	}{
+		{
+			err:       reporting.ErrInvalidPeriod,
+			wantField: FieldError{Field: "period", Code: FieldInvalid, Message: "period must be one of day, week, month"},
+		},
+		{
+			err:       model_staff.ErrInvalidStaffId{StaffId: "x"},
+			wantField: FieldError{Field: "staff_id", Code: FieldInvalid, Message: "assign: " + model_staff.ErrInvalidStaffId{StaffId: "x"}.Error()},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.wantField.Field, func(t *testing.T) {
+			recorder, problem := serveError(t, fmt.Errorf("assign: %w", test.err))
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+			require.Equal(t, CodeValidationFailed, problem.Code)
+			require.Equal(t, []FieldError{test.wantField}, problem.Errors)
+		})
+	}
+}
+
+func TestProblemReturnedAsIs(t *testing.T) {
+	want := ValidationProblem(FieldError{Field: "limit", Code: FieldInvalid, Message: "limit must be positive"})
+	recorder, problem := serveError(t, fmt.Errorf("parsing query: %w", want))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	want.Instance = "/admin/billing/42"
+	require.Equal(t, want, problem)
+}
+
+func TestProblemUnmappedError(t *testing.T) {
+	recorder, problem := serveError(t, errors.New("mongo: connection refused to 10.0.0.5"))
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	require.Equal(t, CodeInternal, problem.Code)
+	// The cause is logged, not sent to the client.
+	require.Equal(t, "internal server error", problem.Detail)
+}
+
+func TestNotFoundAndMethodNotAllowed(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NotFound(recorder, httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	require.Equal(t, problemContentType, recorder.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"no route for the path","instance":"/nowhere","code":"not_found"}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	MethodNotAllowed(recorder, httptest.NewRequest(http.MethodPut, "/ping", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	require.Equal(t, problemContentType, recorder.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"type":"about:blank","title":"Method Not Allowed","status":405,"detail":"PUT is not allowed for the path","instance":"/ping","code":"method_not_allowed"}`, recorder.Body.String())
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
+	"github.com/ThePositree/billing_manager/internal/usecase/reporting"
+	"github.com/gorilla/mux"
+)
+
+// parseTimeParams sets the values of the RFC3339 time query params that
+// are present.
+func parseTimeParams(queryParams url.Values, values map[string]*time.Time) error {
+	for name, value := range values {
+		if !queryParams.Has(name) {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, queryParams.Get(name))
+		if err != nil {
+			return ValidationProblem(FieldError{Field: name, Code: FieldInvalid, Message: name + " in query param must be RFC3339 time"})
+		}
+		*value = parsed
+	}
+	return nil
+}
+
+func GetReportSummary(reportingUsecase reporting.Reporting) Handler[NoBody, dto.Summary] {
+	return func(r *http.Request, _ NoBody) (dto.Summary, error) {
+		queryParams := r.URL.Query()
+		query := reporting.SummaryQuery{
+			Period: reporting.Period(queryParams.Get("period")),
+		}
+		if err := parseTimeParams(queryParams, map[string]*time.Time{"from": &query.From, "to": &query.To}); err != nil {
+			return dto.Summary{}, err
+		}
+		if queryParams.Has("top") {
+			top, err := strconv.Atoi(queryParams.Get("top"))
+			if err != nil || top <= 0 {
+				return dto.Summary{}, ValidationProblem(FieldError{Field: "top", Code: FieldInvalid, Message: "top in query param must be a positive integer"})
+			}
+			query.TopClients = top
+		}
+
+		summary, err := reportingUsecase.GetSummary(r.Context(), query)
+		if err != nil {
+			return dto.Summary{}, fmt.Errorf("reporting get summary: %w", err)
+		}
+
+		result := dto.Summary{
+			Period:         string(summary.Query.Period),
+			From:           summary.Query.From,
+			To:             summary.Query.To,
+			CountsByState:  map[string]int{},
+			Created:        []dto.PeriodCount{},
+			Completed:      []dto.PeriodCount{},
+			StageDurations: map[string]dto.DurationStats{},
+			TopClients:     []dto.ClientCount{},
+		}
+		for state, count := range summary.CountsByState {
+			result.CountsByState[state.String()] = count
+		}
+		for _, periodCount := range summary.Created {
+			result.Created = append(result.Created, dto.PeriodCount{Period: periodCount.Period, Count: periodCount.Count})
+		}
+		for _, periodCount := range summary.Completed {
+			result.Completed = append(result.Completed, dto.PeriodCount{Period: periodCount.Period, Count: periodCount.Count})
+		}
+		for state, stats := range summary.StageDurations {
+			result.StageDurations[state.String()] = dto.DurationStats{
+				Samples: stats.Samples,
+				Median:  dto.Duration(stats.Median),
+				P90:     dto.Duration(stats.P90),
+			}
+		}
+		for _, client := range summary.TopClients {
+			result.TopClients = append(result.TopClients, dto.ClientCount{
+				UserId:     client.UserId,
+				TelegramUN: client.TelegramUN,
+				Count:      client.Count,
+			})
+		}
+		return result, nil
+	}
+}
+
+func GetReportRevenue(reportingUsecase reporting.Reporting) Handler[NoBody, Report[dto.Revenue]] {
+	return func(r *http.Request, _ NoBody) (Report[dto.Revenue], error) {
+		format, err := formatParam(r)
+		if err != nil {
+			return Report[dto.Revenue]{}, err
+		}
+
+		queryParams := r.URL.Query()
+		query := reporting.RevenueQuery{
+			Period: reporting.Period(queryParams.Get("period")),
+		}
+		if err := parseTimeParams(queryParams, map[string]*time.Time{"from": &query.From, "to": &query.To}); err != nil {
+			return Report[dto.Revenue]{}, err
+		}
+
+		revenue, err := reportingUsecase.GetRevenue(r.Context(), query)
+		if err != nil {
+			return Report[dto.Revenue]{}, fmt.Errorf("reporting get revenue: %w", err)
+		}
+
+		report := Report[dto.Revenue]{
+			Result: dto.Revenue{
+				Period:  string(revenue.Query.Period),
+				From:    revenue.Query.From,
+				To:      revenue.Query.To,
+				Periods: []dto.PeriodRevenue{},
+			},
+			Format:   format,
+			Filename: "revenue",
+			Header:   []string{"period", "invoiced", "collected"},
+			Rows:     [][]string{},
+		}
+		for _, period := range revenue.Periods {
+			report.Result.Periods = append(report.Result.Periods, dto.PeriodRevenue{
+				Period:    period.Period,
+				Invoiced:  period.Invoiced,
+				Collected: period.Collected,
+			})
+			report.Rows = append(report.Rows, []string{period.Period, FormatAmount(period.Invoiced), FormatAmount(period.Collected)})
+		}
+		return report, nil
+	}
+}
+
+func GetReportReceivables(reportingUsecase reporting.Reporting) Handler[NoBody, Report[dto.Receivables]] {
+	return func(r *http.Request, _ NoBody) (Report[dto.Receivables], error) {
+		format, err := formatParam(r)
+		if err != nil {
+			return Report[dto.Receivables]{}, err
+		}
+
+		var asOf time.Time
+		if err := parseTimeParams(r.URL.Query(), map[string]*time.Time{"as_of": &asOf}); err != nil {
+			return Report[dto.Receivables]{}, err
+		}
+
+		receivables, err := reportingUsecase.GetReceivables(r.Context(), asOf)
+		if err != nil {
+			return Report[dto.Receivables]{}, fmt.Errorf("reporting get receivables: %w", err)
+		}
+
+		report := Report[dto.Receivables]{
+			Result: dto.Receivables{
+				AsOf:    receivables.AsOf,
+				Buckets: []dto.AgingBucket{},
+				Total:   receivables.Total,
+			},
+			Format:   format,
+			Filename: "receivables",
+			Header:   []string{"bucket", "count", "outstanding"},
+			Rows:     [][]string{},
+		}
+		for _, bucket := range receivables.Buckets {
+			report.Result.Buckets = append(report.Result.Buckets, dto.AgingBucket{
+				Name:        bucket.Name,
+				Count:       bucket.Count,
+				Outstanding: bucket.Outstanding,
+			})
+			report.Rows = append(report.Rows, []string{bucket.Name, strconv.Itoa(bucket.Count), FormatAmount(bucket.Outstanding)})
+		}
+		report.Rows = append(report.Rows, []string{"total", "", FormatAmount(receivables.Total)})
+		return report, nil
+	}
+}
+
+func GetReportStatement(reportingUsecase reporting.Reporting) Handler[NoBody, Report[dto.Statement]] {
+	return func(r *http.Request, _ NoBody) (Report[dto.Statement], error) {
+		format, err := formatParam(r)
+		if err != nil {
+			return Report[dto.Statement]{}, err
+		}
+
+		query := reporting.StatementQuery{
+			UserId: mux.Vars(r)["id"],
+		}
+		if err := parseTimeParams(r.URL.Query(), map[string]*time.Time{"from": &query.From, "to": &query.To}); err != nil {
+			return Report[dto.Statement]{}, err
+		}
+
+		statement, err := reportingUsecase.GetStatement(r.Context(), query)
+		if err != nil {
+			return Report[dto.Statement]{}, fmt.Errorf("reporting get statement: %w", err)
+		}
+
+		report := Report[dto.Statement]{
+			Result: dto.Statement{
+				UserId:         statement.User.Id,
+				TelegramUN:     statement.User.TelegramUN,
+				To:             statement.Query.To,
+				OpeningBalance: statement.OpeningBalance,
+				Lines:          []dto.StatementLine{},
+				ClosingBalance: statement.ClosingBalance,
+			},
+			Format:   format,
+			Filename: "statement_" + statement.User.Id,
+			Header:   []string{"date", "billing_id", "kind", "description", "debit", "credit", "balance"},
+			Rows: [][]string{
+				{"", "", "", "opening balance", "", "", FormatAmount(statement.OpeningBalance)},
+			},
+		}
+		if !statement.Query.From.IsZero() {
+			report.Result.From = &statement.Query.From
+		}
+		for _, line := range statement.Lines {
+			report.Result.Lines = append(report.Result.Lines, dto.StatementLine{
+				Date:        line.Date,
+				BillingId:   line.BillingId,
+				Kind:        string(line.Kind),
+				Description: line.Description,
+				Debit:       line.Debit,
+				Credit:      line.Credit,
+				Balance:     line.Balance,
+			})
+			report.Rows = append(report.Rows, []string{
+				line.Date.Format(time.RFC3339),
+				line.BillingId,
+				string(line.Kind),
+				line.Description,
+				FormatAmount(line.Debit),
+				FormatAmount(line.Credit),
+				FormatAmount(line.Balance),
+			})
+		}
+		report.Rows = append(report.Rows, []string{"", "", "", "closing balance", "", "", FormatAmount(statement.ClosingBalance)})
+		return report, nil
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
+	"github.com/ThePositree/billing_manager/internal/usecase/staff_managing"
+	"github.com/gorilla/mux"
+)
+
+func GetAllStaff(staffManaging staff_managing.StaffManaging) Handler[NoBody, []dto.Staff] {
+	return func(r *http.Request, _ NoBody) ([]dto.Staff, error) {
+		staffMembers, err := staffManaging.GetAll(r.Context())
+		if err != nil {
+			return nil, fmt.Errorf("staff managing get all: %w", err)
+		}
+
+		var result []dto.Staff
+		for _, staff := range staffMembers {
+			result = append(result, dto.NewStaffDTOFromModel(staff))
+		}
+		return result, nil
+	}
+}
+
+func PostStaff(staffManaging staff_managing.StaffManaging) Handler[dto.CreateStaffInfo, dto.Staff] {
+	return func(r *http.Request, staffInfo dto.CreateStaffInfo) (dto.Staff, error) {
+		if staffInfo.Name == "" {
+			return dto.Staff{}, ValidationProblem(FieldError{Field: "name", Code: FieldRequired, Message: "name cannot be empty"})
+		}
+
+		staff, err := staffManaging.Create(r.Context(), staffInfo.Name)
+		if err != nil {
+			return dto.Staff{}, fmt.Errorf("staff managing create: %w", err)
+		}
+		return dto.NewStaffDTOFromModel(staff), nil
+	}
+}
+
+func DeleteStaff(staffManaging staff_managing.StaffManaging) Handler[NoBody, dto.Staff] {
+	return func(r *http.Request, _ NoBody) (dto.Staff, error) {
+		staff, err := staffManaging.Delete(r.Context(), mux.Vars(r)["id"])
+		if err != nil {
+			return dto.Staff{}, fmt.Errorf("staff managing delete: %w", err)
+		}
+		return dto.NewStaffDTOFromModel(staff), nil
+	}
+}
+
+func GetStaffWorkloads(staffManaging staff_managing.StaffManaging) Handler[NoBody, []dto.Workload] {
+	return func(r *http.Request, _ NoBody) ([]dto.Workload, error) {
+		workloads, err := staffManaging.GetWorkloads(r.Context())
+		if err != nil {
+			return nil, fmt.Errorf("staff managing get workloads: %w", err)
+		}
+
+		result := []dto.Workload{}
+		for _, workload := range workloads {
+			byState := map[string]int{}
+			for state, count := range workload.ByState {
+				byState[state.String()] = count
+			}
+			result = append(result, dto.Workload{
+				Staff:   dto.NewStaffDTOFromModel(workload.Staff),
+				ByState: byState,
+				Total:   workload.Total,
+			})
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/dto"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing"
+	"github.com/gorilla/mux"
+)
+
+func PostBillingTimeEntry(billingManaging billing_managing.BillingManaging) Handler[dto.TimeEntryInfo, dto.Billing] {
+	return func(r *http.Request, timeEntryInfo dto.TimeEntryInfo) (dto.Billing, error) {
+		if timeEntryInfo.StaffId == "" {
+			return dto.Billing{}, ValidationProblem(FieldError{Field: "staff_id", Code: FieldRequired, Message: "staff_id cannot be empty"})
+		}
+
+		stage, err := model_billing.ParseState(timeEntryInfo.Stage)
+		if err != nil {
+			return dto.Billing{}, ValidationProblem(FieldError{Field: "stage", Code: FieldInvalid, Message: err.Error()})
+		}
+
+		var startedAt, endedAt time.Time
+		if timeEntryInfo.EndedAt != nil {
+			endedAt = *timeEntryInfo.EndedAt
+		}
+		if timeEntryInfo.StartedAt != nil {
+			startedAt = *timeEntryInfo.StartedAt
+		}
+		if timeEntryInfo.Duration != nil {
+			if timeEntryInfo.StartedAt != nil && timeEntryInfo.EndedAt != nil {
+				return dto.Billing{}, ValidationProblem(FieldError{Field: "duration", Code: FieldInvalid, Message: "duration cannot be combined with both started_at and ended_at"})
+			}
+			switch {
+			case timeEntryInfo.StartedAt != nil:
+				endedAt = startedAt.Add(time.Duration(*timeEntryInfo.Duration))
+			case timeEntryInfo.EndedAt != nil:
+				startedAt = endedAt.Add(-time.Duration(*timeEntryInfo.Duration))
+			default:
+				endedAt = time.Now()
+				startedAt = endedAt.Add(-time.Duration(*timeEntryInfo.Duration))
+			}
+		}
+
+		billing, err := billingManaging.LogTime(r.Context(), mux.Vars(r)["id"], timeEntryInfo.StaffId, stage, startedAt, endedAt, timeEntryInfo.Note)
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing log time: %w", err)
+		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
+
+func PostBillingTimerStart(billingManaging billing_managing.BillingManaging) Handler[dto.TimerInfo, dto.Billing] {
+	return func(r *http.Request, timerInfo dto.TimerInfo) (dto.Billing, error) {
+		if timerInfo.StaffId == "" {
+			return dto.Billing{}, ValidationProblem(FieldError{Field: "staff_id", Code: FieldRequired, Message: "staff_id cannot be empty"})
+		}
+
+		billing, err := billingManaging.StartTimer(r.Context(), mux.Vars(r)["id"], timerInfo.StaffId, timerInfo.Note)
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing start timer: %w", err)
+		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
+
+func PostBillingTimerStop(billingManaging billing_managing.BillingManaging) Handler[dto.TimerInfo, dto.Billing] {
+	return func(r *http.Request, timerInfo dto.TimerInfo) (dto.Billing, error) {
+		if timerInfo.StaffId == "" {
+			return dto.Billing{}, ValidationProblem(FieldError{Field: "staff_id", Code: FieldRequired, Message: "staff_id cannot be empty"})
+		}
+
+		billing, err := billingManaging.StopTimer(r.Context(), mux.Vars(r)["id"], timerInfo.StaffId)
+		if err != nil {
+			return dto.Billing{}, fmt.Errorf("billing managing stop timer: %w", err)
+		}
+		return dto.NewBillingDTOFromModel(billing), nil
+	}
+}
@@ -0,0 +1,234 @@
+package http_controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"mime"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/handlers"
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const (
+	requestIdHeader = "X-Request-Id"
+	// maxBodyBytes limits request bodies, they are small JSON objects.
+	maxBodyBytes = 1 << 20
+	// adminName is recorded as the actor of admin changes. There is one
+	// shared password, so the basic auth username proves nothing.
+	adminName = "admin"
+	// maxRequestIdLength limits request ids taken from clients, they end up
+	// in logs and the audit log.
+	maxRequestIdLength = 128
+)
+
+type middleware func(next http.Handler) http.Handler
+
+// chain wraps handler so that the first middleware runs first.
+func chain(handler http.Handler, middlewares ...middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+type requestIdKey struct{}
+
+// validRequestId accepts ids of letters, digits and the punctuation of
+// common id formats, up to maxRequestIdLength long.
+func validRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	for _, char := range requestId {
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z', char >= '0' && char <= '9':
+		case strings.ContainsRune("-_.:", char):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// requestID takes a valid request id from the request or generates one,
+// sends it back in the response and puts a logger with it into the context.
+func requestID(logger zerolog.Logger) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestId := r.Header.Get(requestIdHeader)
+			if !validRequestId(requestId) {
+				requestId = uuid.NewString()
+			}
+			w.Header().Set(requestIdHeader, requestId)
+
+			ctx := context.WithValue(r.Context(), requestIdKey{}, requestId)
+			ctx = logger.With().Str("RequestId", requestId).Logger().WithContext(ctx)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(bytes []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(bytes)
+	s.bytes += n
+	return n, err
+}
+
+func accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startedAt := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		zerolog.Ctx(r.Context()).Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.Path).
+			Int("Status", recorder.status).
+			Int("Bytes", recorder.bytes).
+			Dur("Duration", time.Since(startedAt)).
+			Str("IP", clientIP(r)).
+			Msg("Http request")
+	})
+}
+
+// recoverPanic turns a panic in a handler into an internal server error
+// instead of a dropped connection.
+func recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			zerolog.Ctx(r.Context()).Error().Interface("Panic", recovered).Bytes("Stack", debug.Stack()).Msg("Http handler panic")
+			if recorder, ok := w.(*statusRecorder); ok && recorder.status != 0 {
+				return
+			}
+			handlers.WriteProblem(w, r, handlers.NewProblem(http.StatusInternalServerError, handlers.CodeInternal, "internal server error"))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeLogger adds the route to the logger in the context.
+func routeLogger(path string) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := zerolog.Ctx(r.Context()).With().Str("Handler", strings.TrimPrefix(path, "/")).Str("Method", r.Method).Logger()
+			next.ServeHTTP(w, r.WithContext(logger.WithContext(r.Context())))
+		})
+	}
+}
+
+// passwordsEqual compares digests in constant time, so neither the
+// content nor the length of the password leaks through timing.
+func passwordsEqual(given string, want string) bool {
+	givenSum, wantSum := sha256.Sum256([]byte(given)), sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(givenSum[:], wantSum[:]) == 1
+}
+
+// adminAuth checks the basic auth password and puts the admin into the
+// context as the actor, so the usecases record who made a change.
+func adminAuth(password *handlers.AdminPassword) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, userPassword, ok := r.BasicAuth()
+			if !ok || !passwordsEqual(userPassword, password.Get()) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+				handlers.WriteProblem(w, r, handlers.NewProblem(http.StatusUnauthorized, handlers.CodeUnauthorized, "you are unauthorized"))
+				return
+			}
+
+			requestId, _ := r.Context().Value(requestIdKey{}).(string)
+			ctx := auditing.WithActor(r.Context(), audit.Actor{
+				Name:      adminName,
+				RequestId: requestId,
+				IP:        clientIP(r),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// negotiateJSON rejects requests that do not accept JSON responses or
+// send a body that is not JSON. A body without Content-Type is read as JSON.
+func negotiateJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsJSON(r.Header.Get("Accept")) {
+			handlers.WriteProblem(w, r, handlers.NewProblem(http.StatusNotAcceptable, handlers.CodeNotAcceptable, "responses are application/json"))
+			return
+		}
+		if contentType := r.Header.Get("Content-Type"); contentType != "" && r.ContentLength != 0 {
+			mediaType, _, err := mime.ParseMediaType(contentType)
+			if err != nil || mediaType != "application/json" {
+				handlers.WriteProblem(w, r, handlers.NewProblem(http.StatusUnsupportedMediaType, handlers.CodeUnsupportedMediaType, "body must be application/json"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func acceptsJSON(accept string) bool {
+	if accept == "" {
+		return true
+	}
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		if quality, err := strconv.ParseFloat(params["q"], 64); err == nil && quality == 0 {
+			continue
+		}
+		switch mediaType {
+		case "*/*", "application/*", "application/json", "application/problem+json":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package http_controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/handlers"
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestId string
+		wantKept  bool
+	}{
+		{name: "uuid", requestId: "0b6c7a9e-3f4d-4c1e-9a55-2f1d8f1f6c11", wantKept: true},
+		{name: "trace id", requestId: "trace:abc_123.4", wantKept: true},
+		{name: "longest", requestId: strings.Repeat("a", maxRequestIdLength), wantKept: true},
+		{name: "missing", requestId: ""},
+		{name: "too long", requestId: strings.Repeat("a", maxRequestIdLength+1)},
+		{name: "spaces", requestId: "a b"},
+		{name: "log injection", requestId: "abc\n{\"level\":\"error\"}"},
+		{name: "non ascii", requestId: "запрос"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var seen string
+			handler := requestID(zerolog.Nop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen, _ = r.Context().Value(requestIdKey{}).(string)
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			request.Header[requestIdHeader] = []string{test.requestId}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			require.Equal(t, seen, recorder.Header().Get(requestIdHeader))
+			if test.wantKept {
+				require.Equal(t, test.requestId, seen)
+				return
+			}
+			require.NotEqual(t, test.requestId, seen)
+			require.True(t, validRequestId(seen), seen)
+		})
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		noAuth     bool
+		wantStatus int
+	}{
+		{name: "password", username: "admin", password: "secret", wantStatus: http.StatusOK},
+		{name: "any username", username: "mallory", password: "secret", wantStatus: http.StatusOK},
+		{name: "empty username", username: "", password: "secret", wantStatus: http.StatusOK},
+		{name: "wrong password", username: "admin", password: "secreT", wantStatus: http.StatusUnauthorized},
+		{name: "longer password", username: "admin", password: "secret2", wantStatus: http.StatusUnauthorized},
+		{name: "empty password", username: "admin", password: "", wantStatus: http.StatusUnauthorized},
+		{name: "no credentials", noAuth: true, wantStatus: http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				actor    audit.Actor
+				hasActor bool
+			)
+			handler := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				actor, hasActor = auditing.ActorFromContext(r.Context())
+			}), requestID(zerolog.Nop()), adminAuth(handlers.NewAdminPassword("secret")))
+
+			request := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+			request.RemoteAddr = "192.0.2.1:4321"
+			request.Header.Set(requestIdHeader, "request-1")
+			if !test.noAuth {
+				request.SetBasicAuth(test.username, test.password)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			require.Equal(t, test.wantStatus, recorder.Code)
+			if test.wantStatus != http.StatusOK {
+				require.False(t, hasActor)
+				require.Equal(t, `Basic realm="Restricted"`, recorder.Header().Get("WWW-Authenticate"))
+				require.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+				return
+			}
+			// The username is not verified, so it is never recorded.
+			require.Equal(t, audit.Actor{Name: adminName, RequestId: "request-1", IP: "192.0.2.1"}, actor)
+		})
+	}
+}
+
+type nameRequest struct {
+	Name string `json:"name"`
+}
+
+// serveChain runs handler behind the server and JSON route middlewares, in
+// the order Start and the router wire them.
+func serveChain(handler http.Handler, request *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	chain(handler,
+		requestID(zerolog.Nop()),
+		accessLog,
+		recoverPanic,
+		limitBody,
+		negotiateJSON,
+	).ServeHTTP(recorder, request)
+	return recorder
+}
+
+func requireProblem(t *testing.T, recorder *httptest.ResponseRecorder, wantStatus int, wantCode string) {
+	t.Helper()
+	require.Equal(t, wantStatus, recorder.Code)
+	require.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	var problem handlers.Problem
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+	require.Equal(t, wantStatus, problem.Status)
+	require.Equal(t, wantCode, problem.Code)
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	echo := handlers.Adapt(func(r *http.Request, req nameRequest) (nameRequest, error) {
+		return req, nil
+	})
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		accept      string
+		wantStatus  int
+		wantCode    string
+	}{
+		{name: "json", body: `{"name":"alice"}`, contentType: "application/json", wantStatus: http.StatusOK},
+		{name: "charset", body: `{"name":"alice"}`, contentType: "application/json; charset=utf-8", accept: "application/json", wantStatus: http.StatusOK},
+		{name: "no content type", body: `{"name":"alice"}`, accept: "text/html, */*;q=0.1", wantStatus: http.StatusOK},
+		{name: "wrong accept", body: `{"name":"alice"}`, accept: "text/html", wantStatus: http.StatusNotAcceptable, wantCode: handlers.CodeNotAcceptable},
+		{name: "refused json", body: `{"name":"alice"}`, accept: "application/json;q=0, text/html", wantStatus: http.StatusNotAcceptable, wantCode: handlers.CodeNotAcceptable},
+		{name: "wrong content type", body: "name=alice", contentType: "application/x-www-form-urlencoded", wantStatus: http.StatusUnsupportedMediaType, wantCode: handlers.CodeUnsupportedMediaType},
+		{name: "malformed", body: `{"name":`, contentType: "application/json", wantStatus: http.StatusBadRequest, wantCode: handlers.CodeMalformedBody},
+		{name: "too large", body: `{"name":"` + strings.Repeat("a", maxBodyBytes) + `"}`, contentType: "application/json", wantStatus: http.StatusRequestEntityTooLarge, wantCode: handlers.CodeBodyTooLarge},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(test.body))
+			if test.contentType != "" {
+				request.Header.Set("Content-Type", test.contentType)
+			}
+			if test.accept != "" {
+				request.Header.Set("Accept", test.accept)
+			}
+			recorder := serveChain(echo, request)
+
+			require.NotEmpty(t, recorder.Header().Get(requestIdHeader))
+			if test.wantCode != "" {
+				requireProblem(t, recorder, test.wantStatus, test.wantCode)
+				return
+			}
+			require.Equal(t, test.wantStatus, recorder.Code)
+			require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			require.JSONEq(t, `{"name":"alice"}`, recorder.Body.String())
+		})
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	recorder := serveChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	requireProblem(t, recorder, http.StatusInternalServerError, handlers.CodeInternal)
+	require.NotContains(t, recorder.Body.String(), "boom")
+
+	// A started response is not followed by a problem.
+	recorder = serveChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("a,b\n"))
+		panic("boom")
+	}), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	require.Equal(t, "a,b\n", recorder.Body.String())
+
+	// The server aborts the connection itself.
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		serveChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	})
+}
+
+func TestAcceptsJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{accept: "", want: true},
+		{accept: "*/*", want: true},
+		{accept: "application/*", want: true},
+		{accept: "application/json", want: true},
+		{accept: "application/problem+json", want: true},
+		{accept: "text/html, application/json;q=0.9", want: true},
+		{accept: "text/html", want: false},
+		{accept: "application/json;q=0", want: false},
+		{accept: "*/*;q=0", want: false},
+		{accept: "not a media type", want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.accept, func(t *testing.T) {
+			require.Equal(t, test.want, acceptsJSON(test.accept))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package http_controller
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/controller/http/handlers"
+	"golang.org/x/time/rate"
+)
+
+// clientIdleTimeout is how long a client limiter is kept after its last request.
+const clientIdleTimeout = 10 * time.Minute
+
+// RateLimit allows RequestsPerSecond with bursts of Burst requests per client IP,
+// zero RequestsPerSecond disables limiting.
+type RateLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type rateLimiter struct {
+	mutex     sync.Mutex
+	limit     RateLimit
+	clients   map[string]*clientLimiter
+	lastPrune time.Time
+}
+
+// Set replaces the limit, clients start over with a full burst.
+func (l *rateLimiter) Set(limit RateLimit) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.limit = limit
+	l.clients = map[string]*clientLimiter{}
+}
+
+func (l *rateLimiter) allow(client string, at time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.limit.RequestsPerSecond <= 0 {
+		return true
+	}
+
+	if at.Sub(l.lastPrune) > clientIdleTimeout {
+		for key, clientLimiter := range l.clients {
+			if at.Sub(clientLimiter.lastSeen) > clientIdleTimeout {
+				delete(l.clients, key)
+			}
+		}
+		l.lastPrune = at
+	}
+
+	limiter, ok := l.clients[client]
+	if !ok {
+		limiter = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(l.limit.RequestsPerSecond), l.limit.Burst)}
+		l.clients[client] = limiter
+	}
+	limiter.lastSeen = at
+	return limiter.limiter.AllowN(at, 1)
+}
+
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+		if !l.allow(client, time.Now()) {
+			w.Header().Set("Retry-After", "1")
+			handlers.WriteProblem(w, r, handlers.NewProblem(http.StatusTooManyRequests, handlers.CodeTooManyRequests, "too many requests"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	limiter := &rateLimiter{}
+	limiter.Set(limit)
+	return limiter
+}
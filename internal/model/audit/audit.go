@@ -0,0 +1,185 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ErrInvalidEntryId struct {
+	EntryId string
+}
+
+func (e ErrInvalidEntryId) Error() string {
+	return fmt.Sprintf("%s is invalid audit entry id", e.EntryId)
+}
+
+// Actions are the values of Entry.Action, the part before the dot names
+// the type of the target.
+const (
+	ActionBillingNextState     = "billing.next_state"
+	ActionBillingPrevState     = "billing.prev_state"
+	ActionBillingBriefInfo     = "billing.brief_info"
+	ActionBillingRevision      = "billing.revision"
+	ActionBillingRevisionLimit = "billing.revision_limit"
+	ActionBillingDeadlines     = "billing.deadlines"
+	ActionBillingPriority      = "billing.priority"
+	ActionBillingAssign        = "billing.assign"
+	ActionBillingLogTime       = "billing.log_time"
+	ActionBillingStartTimer    = "billing.start_timer"
+	ActionBillingStopTimer     = "billing.stop_timer"
+	ActionBillingPrice         = "billing.price"
+	ActionBillingInvoice       = "billing.invoice"
+	ActionBillingPayment       = "billing.payment"
+	ActionBillingDelete        = "billing.delete"
+	ActionBillingRestore       = "billing.restore"
+	ActionUserDelete           = "user.delete"
+	ActionUserRestore          = "user.restore"
+	ActionUserErase            = "user.erase"
+	ActionStaffCreate          = "staff.create"
+	ActionStaffDelete          = "staff.delete"
+)
+
+const (
+	TargetBilling = "billing"
+	TargetUser    = "user"
+	TargetStaff   = "staff"
+)
+
+// Actor is who made a change and where the request came from.
+type Actor struct {
+	Name      string
+	RequestId string
+	IP        string
+}
+
+type Target struct {
+	Type string
+	Id   string
+}
+
+// Change is a field of the target changed by an action, an empty Before
+// means the field was set and an empty After that it was cleared.
+type Change struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// Entry is a record of the audit log, entries are never changed or removed.
+type Entry struct {
+	Id         string
+	Actor      Actor
+	Action     string
+	Target     Target
+	Changes    []Change
+	OccurredAt time.Time
+}
+
+var now = time.Now
+
+func New(actor Actor, action string, target Target, changes []Change) Entry {
+	return Entry{
+		Id:         uuid.NewString(),
+		Actor:      actor,
+		Action:     action,
+		Target:     target,
+		Changes:    changes,
+		OccurredAt: now(),
+	}
+}
+
+// Diff compares the fields of a target before and after an action, a nil
+// map stands for a target that did not exist. Changes are sorted by field.
+func Diff(before map[string]string, after map[string]string) []Change {
+	fields := map[string]struct{}{}
+	for field := range before {
+		fields[field] = struct{}{}
+	}
+	for field := range after {
+		fields[field] = struct{}{}
+	}
+
+	var changes []Change
+	for field := range fields {
+		if before[field] == after[field] {
+			continue
+		}
+		changes = append(changes, Change{Field: field, Before: before[field], After: after[field]})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// Filter selects entries, zero fields match every entry. Entries are
+// returned newest first, at most Limit of them.
+type Filter struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetId   string
+	// From is inclusive and To is exclusive.
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+func ValidateEntryId(entryId string) error {
+	if _, err := uuid.Parse(entryId); err != nil {
+		return ErrInvalidEntryId{EntryId: entryId}
+	}
+	return nil
+}
+
+type ChangeDTO interface {
+	GetField() string
+	GetBefore() string
+	GetAfter() string
+}
+
+type DTO interface {
+	GetId() string
+	GetActor() string
+	GetRequestId() string
+	GetIP() string
+	GetAction() string
+	GetTargetType() string
+	GetTargetId() string
+	GetChanges() []ChangeDTO
+	GetOccurredAt() time.Time
+}
+
+func ToModelFromDTO(dto DTO) (Entry, error) {
+	id := dto.GetId()
+	if err := ValidateEntryId(id); err != nil {
+		return Entry{}, err
+	}
+
+	var changes []Change
+	for _, change := range dto.GetChanges() {
+		changes = append(changes, Change{
+			Field:  change.GetField(),
+			Before: change.GetBefore(),
+			After:  change.GetAfter(),
+		})
+	}
+	return Entry{
+		Id: id,
+		Actor: Actor{
+			Name:      dto.GetActor(),
+			RequestId: dto.GetRequestId(),
+			IP:        dto.GetIP(),
+		},
+		Action: dto.GetAction(),
+		Target: Target{
+			Type: dto.GetTargetType(),
+			Id:   dto.GetTargetId(),
+		},
+		Changes:    changes,
+		OccurredAt: dto.GetOccurredAt(),
+	}, nil
+}
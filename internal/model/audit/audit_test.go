@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type mockChangeDTO struct {
+	Field  string
+	Before string
+	After  string
+}
+
+func (m mockChangeDTO) GetField() string {
+	return m.Field
+}
+
+func (m mockChangeDTO) GetBefore() string {
+	return m.Before
+}
+
+func (m mockChangeDTO) GetAfter() string {
+	return m.After
+}
+
+type mockDTO struct {
+	Id         string
+	Actor      string
+	RequestId  string
+	IP         string
+	Action     string
+	TargetType string
+	TargetId   string
+	Changes    []mockChangeDTO
+	OccurredAt time.Time
+}
+
+func (m mockDTO) GetId() string {
+	return m.Id
+}
+
+func (m mockDTO) GetActor() string {
+	return m.Actor
+}
+
+func (m mockDTO) GetRequestId() string {
+	return m.RequestId
+}
+
+func (m mockDTO) GetIP() string {
+	return m.IP
+}
+
+func (m mockDTO) GetAction() string {
+	return m.Action
+}
+
+func (m mockDTO) GetTargetType() string {
+	return m.TargetType
+}
+
+func (m mockDTO) GetTargetId() string {
+	return m.TargetId
+}
+
+func (m mockDTO) GetChanges() []ChangeDTO {
+	var changes []ChangeDTO
+	for _, change := range m.Changes {
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func (m mockDTO) GetOccurredAt() time.Time {
+	return m.OccurredAt
+}
+
+func TestDiff(t *testing.T) {
+	changes := Diff(
+		map[string]string{"state": "pending", "price": "100", "priority": "normal"},
+		map[string]string{"state": "design", "price": "100", "deadline": "2024-06-01T00:00:00Z"},
+	)
+	require.Equal(t, []Change{
+		{Field: "deadline", Before: "", After: "2024-06-01T00:00:00Z"},
+		{Field: "priority", Before: "normal", After: ""},
+		{Field: "state", Before: "pending", After: "design"},
+	}, changes)
+
+	require.Empty(t, Diff(map[string]string{"state": "design"}, map[string]string{"state": "design"}))
+	require.Equal(t, []Change{{Field: "name", Before: "", After: "Anna"}}, Diff(nil, map[string]string{"name": "Anna"}))
+}
+
+func TestToModelFromDTO(t *testing.T) {
+	occurredAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return occurredAt }
+	defer func() { now = time.Now }()
+
+	targetId := uuid.NewString()
+	entry := New(
+		Actor{Name: "root", RequestId: "request", IP: "127.0.0.1"},
+		ActionBillingNextState,
+		Target{Type: TargetBilling, Id: targetId},
+		[]Change{{Field: "state", Before: "pending", After: "design"}},
+	)
+	require.Equal(t, occurredAt, entry.OccurredAt)
+
+	restored, err := ToModelFromDTO(mockDTO{
+		Id:         entry.Id,
+		Actor:      "root",
+		RequestId:  "request",
+		IP:         "127.0.0.1",
+		Action:     ActionBillingNextState,
+		TargetType: TargetBilling,
+		TargetId:   targetId,
+		Changes:    []mockChangeDTO{{Field: "state", Before: "pending", After: "design"}},
+		OccurredAt: occurredAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, entry, restored)
+
+	_, err = ToModelFromDTO(mockDTO{Id: "test"})
+	require.Error(t, err)
+}
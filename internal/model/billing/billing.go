@@ -2,7 +2,9 @@ package billing
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/ThePositree/billing_manager/internal/model/staff"
 	"github.com/ThePositree/billing_manager/internal/model/user"
 	"github.com/google/uuid"
 )
@@ -19,40 +21,245 @@ func (e ErrPrevPendingState) Error() string {
 	return "impossible to prev the state from pending state"
 }
 
+type ErrRevisionInvalidState struct {
+	State State
+}
+
+func (e ErrRevisionInvalidState) Error() string {
+	return fmt.Sprintf("impossible to request a revision in %s state", e.State)
+}
+
+type ErrRevisionLimitExceeded struct {
+	Stage    State
+	Included int
+}
+
+func (e ErrRevisionLimitExceeded) Error() string {
+	return fmt.Sprintf("included revisions limit %d for %s stage is exceeded", e.Included, e.Stage)
+}
+
+type ErrInvalidRevisionLimit struct {
+	Reason string
+}
+
+func (e ErrInvalidRevisionLimit) Error() string {
+	return fmt.Sprintf("invalid revision limit: %s", e.Reason)
+}
+
+type ErrInvalidStageTarget struct {
+	Stage State
+}
+
+func (e ErrInvalidStageTarget) Error() string {
+	return fmt.Sprintf("invalid target duration for %s stage", e.Stage)
+}
+
+type ErrAssignInvalidStage struct {
+	Stage State
+}
+
+func (e ErrAssignInvalidStage) Error() string {
+	return fmt.Sprintf("impossible to assign staff to %s stage", e.Stage)
+}
+
+type ErrTimeEntryInvalidStage struct {
+	Stage State
+}
+
+func (e ErrTimeEntryInvalidStage) Error() string {
+	return fmt.Sprintf("impossible to log time against %s stage", e.Stage)
+}
+
+type ErrInvalidTimeEntry struct {
+	Reason string
+}
+
+func (e ErrInvalidTimeEntry) Error() string {
+	return fmt.Sprintf("invalid time entry: %s", e.Reason)
+}
+
+type ErrTimerAlreadyRunning struct {
+	StaffId string
+}
+
+func (e ErrTimerAlreadyRunning) Error() string {
+	return fmt.Sprintf("timer of %s staff is already running", e.StaffId)
+}
+
+type ErrTimerNotRunning struct {
+	StaffId string
+}
+
+func (e ErrTimerNotRunning) Error() string {
+	return fmt.Sprintf("timer of %s staff is not running", e.StaffId)
+}
+
+type ErrInvalidAmount struct {
+	Amount int64
+}
+
+func (e ErrInvalidAmount) Error() string {
+	return fmt.Sprintf("%d is invalid amount", e.Amount)
+}
+
+type ErrAlreadyInvoiced struct{}
+
+func (e ErrAlreadyInvoiced) Error() string {
+	return "billing is already invoiced"
+}
+
+type ErrNothingToInvoice struct{}
+
+func (e ErrNothingToInvoice) Error() string {
+	return "impossible to invoice billing with zero total"
+}
+
+type ErrNotInvoiced struct{}
+
+func (e ErrNotInvoiced) Error() string {
+	return "billing is not invoiced yet"
+}
+
 type BriefInfo struct {
 	Username string
 }
 
-type Billing struct {
+// Revision is a single round of changes requested by the client
+// in the stage the billing was in at that moment.
+type Revision struct {
+	Stage       State
+	RequestedBy string
+	Note        string
+	CreatedAt   time.Time
+}
+
+// RevisionLimit is the number of revisions included into the price of
+// every revisable stage and what happens when a client asks for more.
+type RevisionLimit struct {
+	Included  int
+	Policy    RevisionPolicy
+	Surcharge int64
+}
+
+func (l RevisionLimit) Validate() error {
+	if l.Included < 0 {
+		return ErrInvalidRevisionLimit{Reason: "included revisions cannot be negative"}
+	}
+	if !l.Policy.IsValid() {
+		return ErrInvalidRevisionLimit{Reason: fmt.Sprintf("%s is %s", l.Policy, ErrInvalidRevisionPolicy)}
+	}
+	if l.Surcharge < 0 {
+		return ErrInvalidRevisionLimit{Reason: "surcharge cannot be negative"}
+	}
+	if l.Policy == RevisionPolicySurcharge && l.Surcharge == 0 {
+		return ErrInvalidRevisionLimit{Reason: "surcharge policy requires positive surcharge"}
+	}
+	return nil
+}
+
+// LineItem is an extra charge added to the billing on top of the base price.
+// Amount is stored in minor currency units.
+type LineItem struct {
+	Description string
+	Amount      int64
+}
+
+// Payment is money received from the client against the billing invoice.
+// Amount is stored in minor currency units.
+type Payment struct {
+	Id     string
+	Amount int64
+	PaidAt time.Time
+	Note   string
+}
+
+// TimeEntry is time a staff member spent on a stage of the billing.
+// Zero EndedAt means the timer of the entry is still running.
+type TimeEntry struct {
 	Id        string
-	UserId    string
-	_state    State
-	_username string
+	StaffId   string
+	Stage     State
+	StartedAt time.Time
+	EndedAt   time.Time
+	Note      string
+}
+
+func (e TimeEntry) IsRunning() bool {
+	return e.EndedAt.IsZero()
 }
 
+func (e TimeEntry) Duration() time.Duration {
+	if e.IsRunning() {
+		return 0
+	}
+	return e.EndedAt.Sub(e.StartedAt)
+}
+
+// StateChange records the moment the billing entered a state.
+type StateChange struct {
+	State     State
+	EnteredAt time.Time
+}
+
+type Billing struct {
+	Id             string
+	UserId         string
+	_state         State
+	_username      string
+	_revisions     []Revision
+	_revisionLimit RevisionLimit
+	_lineItems     []LineItem
+	_createdAt     time.Time
+	_stateChanges  []StateChange
+	_deadline      time.Time
+	_stageTargets  map[State]time.Duration
+	_priority      Priority
+	_assignees     map[State]string
+	_timeEntries   []TimeEntry
+	_price         int64
+	_invoicedAt    time.Time
+	_payments      []Payment
+	_deletedAt     time.Time
+	_deletedBy     string
+	_version       int
+	_changes       []Event
+}
+
+var now = time.Now
+
 func New(userId string) (Billing, error) {
 	err := user.ValidateUserId(userId)
 	if err != nil {
 		return Billing{}, err
 	}
 
-	return Billing{
-		Id:     uuid.New().String(),
-		_state: StatePending,
-		UserId: userId,
-	}, nil
+	var billing Billing
+	billing.record(Event{
+		Type:      EventTypeCreated,
+		BillingId: uuid.New().String(),
+		UserId:    userId,
+	})
+	return billing, nil
+}
+
+func (b *Billing) advanceState(state State) {
+	b.record(Event{Type: EventTypeStateAdvanced, State: state})
+}
+
+func (b *Billing) revertState(state State) {
+	b.record(Event{Type: EventTypeStateReverted, State: state})
 }
 
 func (b *Billing) NextState() error {
 	switch b._state {
 	case StatePending:
-		b._state = StateDesign
+		b.advanceState(StateDesign)
 		return nil
 	case StateDesign:
-		b._state = StateLayout
+		b.advanceState(StateLayout)
 		return nil
 	case StateLayout:
-		b._state = StateCompleted
+		b.advanceState(StateCompleted)
 		return nil
 	case StateCompleted:
 		return ErrNextCompletedState{}
@@ -65,18 +272,371 @@ func (b *Billing) PrevState() error {
 	case StatePending:
 		return ErrPrevPendingState{}
 	case StateDesign:
-		b._state = StatePending
+		b.revertState(StatePending)
 		return nil
 	case StateLayout:
-		b._state = StateDesign
+		b.revertState(StateDesign)
 		return nil
 	case StateCompleted:
-		b._state = StateLayout
+		b.revertState(StateLayout)
 		return nil
 	}
 	return fmt.Errorf("%s is %w", b._state, ErrInvalidState)
 }
 
+func (b *Billing) GetCreatedAt() time.Time {
+	return b._createdAt
+}
+
+func (b *Billing) GetStateChanges() []StateChange {
+	return append([]StateChange(nil), b._stateChanges...)
+}
+
+// GetStateEnteredAt returns the moment the billing entered its current state.
+// Zero time means the billing was stored before state changes were tracked.
+func (b *Billing) GetStateEnteredAt() time.Time {
+	if len(b._stateChanges) == 0 {
+		return time.Time{}
+	}
+	return b._stateChanges[len(b._stateChanges)-1].EnteredAt
+}
+
+// GetDeadline returns the promised delivery time, zero time means no deadline.
+func (b *Billing) GetDeadline() time.Time {
+	return b._deadline
+}
+
+func (b *Billing) SetDeadline(deadline time.Time) {
+	if !deadline.IsZero() {
+		deadline = deadline.UTC()
+	}
+	b.record(Event{Type: EventTypeDeadlineSet, Deadline: deadline})
+}
+
+func ValidateStageTargets(targets map[State]time.Duration) error {
+	for stage, target := range targets {
+		if !stage.IsValid() || stage == StateCompleted || target <= 0 {
+			return ErrInvalidStageTarget{Stage: stage}
+		}
+	}
+	return nil
+}
+
+func (b *Billing) GetStageTargets() map[State]time.Duration {
+	result := make(map[State]time.Duration, len(b._stageTargets))
+	for stage, target := range b._stageTargets {
+		result[stage] = target
+	}
+	return result
+}
+
+func (b *Billing) SetStageTargets(targets map[State]time.Duration) error {
+	if err := ValidateStageTargets(targets); err != nil {
+		return err
+	}
+	stageTargets := make(map[State]time.Duration, len(targets))
+	for stage, target := range targets {
+		stageTargets[stage] = target
+	}
+	b.record(Event{Type: EventTypeStageTargetsSet, StageTargets: stageTargets})
+	return nil
+}
+
+// NextDueAt returns the earliest of the overall deadline and the target
+// end of the current stage. Completed billings are never due.
+func (b *Billing) NextDueAt() (time.Time, bool) {
+	if b._state == StateCompleted {
+		return time.Time{}, false
+	}
+	dueAt := b._deadline
+	enteredAt := b.GetStateEnteredAt()
+	if target, ok := b._stageTargets[b._state]; ok && !enteredAt.IsZero() {
+		stageDueAt := enteredAt.Add(target)
+		if dueAt.IsZero() || stageDueAt.Before(dueAt) {
+			dueAt = stageDueAt
+		}
+	}
+	return dueAt, !dueAt.IsZero()
+}
+
+// GetDeadlineStatus reports whether the billing is overdue at the given moment
+// or will become overdue within the warning period.
+func (b *Billing) GetDeadlineStatus(at time.Time, warning time.Duration) DeadlineStatus {
+	dueAt, ok := b.NextDueAt()
+	if !ok {
+		return DeadlineStatusOnTrack
+	}
+	if at.After(dueAt) {
+		return DeadlineStatusOverdue
+	}
+	if dueAt.Sub(at) <= warning {
+		return DeadlineStatusApproaching
+	}
+	return DeadlineStatusOnTrack
+}
+
+func (b *Billing) IsOverdue(at time.Time) bool {
+	return b.GetDeadlineStatus(at, 0) == DeadlineStatusOverdue
+}
+
+func (b *Billing) GetPriority() Priority {
+	return b._priority
+}
+
+func (b *Billing) SetPriority(priority Priority) error {
+	if !priority.IsValid() {
+		return fmt.Errorf("%s is %w", priority, ErrInvalidPriority)
+	}
+	b.record(Event{Type: EventTypePrioritySet, Priority: priority})
+	return nil
+}
+
+// Assign sets the staff member responsible for the stage, an empty
+// staff id removes the assignment.
+func (b *Billing) Assign(stage State, staffId string) error {
+	if !stage.IsValid() || stage == StateCompleted {
+		return ErrAssignInvalidStage{Stage: stage}
+	}
+	if staffId != "" {
+		if err := staff.ValidateStaffId(staffId); err != nil {
+			return err
+		}
+	}
+	b.record(Event{Type: EventTypeAssigned, Stage: stage, StaffId: staffId})
+	return nil
+}
+
+func (b *Billing) GetAssignees() map[State]string {
+	result := make(map[State]string, len(b._assignees))
+	for stage, staffId := range b._assignees {
+		result[stage] = staffId
+	}
+	return result
+}
+
+// GetCurrentAssignee returns the staff member responsible for the current state.
+func (b *Billing) GetCurrentAssignee() (string, bool) {
+	staffId, ok := b._assignees[b._state]
+	return staffId, ok
+}
+
+var stateOrder = map[State]int{
+	StatePending:   0,
+	StateDesign:    1,
+	StateLayout:    2,
+	StateCompleted: 3,
+}
+
+// validateTimeEntryStage allows logging time only against the current stage
+// or a stage the billing has already passed.
+func (b *Billing) validateTimeEntryStage(stage State) error {
+	if !stage.IsValid() || stage == StateCompleted {
+		return ErrTimeEntryInvalidStage{Stage: stage}
+	}
+	if stateOrder[stage] <= stateOrder[b._state] {
+		return nil
+	}
+	for _, stateChange := range b._stateChanges {
+		if stateChange.State == stage {
+			return nil
+		}
+	}
+	return ErrTimeEntryInvalidStage{Stage: stage}
+}
+
+func (b *Billing) LogTime(staffId string, stage State, startedAt time.Time, endedAt time.Time, note string) (TimeEntry, error) {
+	if err := staff.ValidateStaffId(staffId); err != nil {
+		return TimeEntry{}, err
+	}
+	if err := b.validateTimeEntryStage(stage); err != nil {
+		return TimeEntry{}, err
+	}
+	if startedAt.IsZero() || endedAt.IsZero() {
+		return TimeEntry{}, ErrInvalidTimeEntry{Reason: "start and end are required"}
+	}
+	if !endedAt.After(startedAt) {
+		return TimeEntry{}, ErrInvalidTimeEntry{Reason: "end must be after start"}
+	}
+
+	entry := TimeEntry{
+		Id:        uuid.NewString(),
+		StaffId:   staffId,
+		Stage:     stage,
+		StartedAt: startedAt.UTC(),
+		EndedAt:   endedAt.UTC(),
+		Note:      note,
+	}
+	b.record(Event{Type: EventTypeTimeEntryAdded, TimeEntry: entry})
+	return entry, nil
+}
+
+// StartTimer starts a running time entry of the staff member for the current stage.
+func (b *Billing) StartTimer(staffId string, note string) (TimeEntry, error) {
+	if err := staff.ValidateStaffId(staffId); err != nil {
+		return TimeEntry{}, err
+	}
+	if err := b.validateTimeEntryStage(b._state); err != nil {
+		return TimeEntry{}, err
+	}
+	for _, entry := range b._timeEntries {
+		if entry.StaffId == staffId && entry.IsRunning() {
+			return TimeEntry{}, ErrTimerAlreadyRunning{StaffId: staffId}
+		}
+	}
+
+	entry := TimeEntry{
+		Id:        uuid.NewString(),
+		StaffId:   staffId,
+		Stage:     b._state,
+		StartedAt: now().UTC(),
+		Note:      note,
+	}
+	b.record(Event{Type: EventTypeTimeEntryAdded, TimeEntry: entry, OccurredAt: entry.StartedAt})
+	return entry, nil
+}
+
+func (b *Billing) StopTimer(staffId string) (TimeEntry, error) {
+	for i, entry := range b._timeEntries {
+		if entry.StaffId == staffId && entry.IsRunning() {
+			b.record(Event{Type: EventTypeTimerStopped, TimeEntry: TimeEntry{Id: entry.Id}})
+			return b._timeEntries[i], nil
+		}
+	}
+	return TimeEntry{}, ErrTimerNotRunning{StaffId: staffId}
+}
+
+func (b *Billing) GetTimeEntries() []TimeEntry {
+	return append([]TimeEntry(nil), b._timeEntries...)
+}
+
+// GetTimeTotals sums finished time entries per stage.
+func (b *Billing) GetTimeTotals() map[State]time.Duration {
+	result := map[State]time.Duration{}
+	for _, entry := range b._timeEntries {
+		if entry.IsRunning() {
+			continue
+		}
+		result[entry.Stage] += entry.Duration()
+	}
+	return result
+}
+
+// GetPrice returns the base price of the billing in minor currency units.
+func (b *Billing) GetPrice() int64 {
+	return b._price
+}
+
+func (b *Billing) SetPrice(price int64) error {
+	if price < 0 {
+		return ErrInvalidAmount{Amount: price}
+	}
+	if !b._invoicedAt.IsZero() {
+		return ErrAlreadyInvoiced{}
+	}
+	b.record(Event{Type: EventTypePriceSet, Price: price})
+	return nil
+}
+
+// GetTotal returns the base price together with all line items.
+func (b *Billing) GetTotal() int64 {
+	total := b._price
+	for _, lineItem := range b._lineItems {
+		total += lineItem.Amount
+	}
+	return total
+}
+
+// GetInvoicedAt returns the moment the invoice was issued, zero time means not invoiced.
+func (b *Billing) GetInvoicedAt() time.Time {
+	return b._invoicedAt
+}
+
+func (b *Billing) Invoice() error {
+	if !b._invoicedAt.IsZero() {
+		return ErrAlreadyInvoiced{}
+	}
+	if b.GetTotal() <= 0 {
+		return ErrNothingToInvoice{}
+	}
+	b.record(Event{Type: EventTypeInvoiced})
+	return nil
+}
+
+// MarkDeleted hides the billing from normal reads until it is restored
+// or purged after the retention period.
+func (b *Billing) MarkDeleted(deletedBy string) {
+	b.record(Event{Type: EventTypeDeleted, DeletedBy: deletedBy})
+}
+
+func (b *Billing) Restore() {
+	b.record(Event{Type: EventTypeRestored})
+}
+
+// Anonymize removes the personal data of the client: the brief and who
+// asked for revisions and why. Prices, line items and payments are kept
+// for accounting.
+func (b *Billing) Anonymize() {
+	b.record(Event{Type: EventTypeAnonymized})
+}
+
+func (b *Billing) IsDeleted() bool {
+	return !b._deletedAt.IsZero()
+}
+
+// GetDeletedAt returns the moment the billing was soft deleted, zero time means not deleted.
+func (b *Billing) GetDeletedAt() time.Time {
+	return b._deletedAt
+}
+
+func (b *Billing) GetDeletedBy() string {
+	return b._deletedBy
+}
+
+func (b *Billing) AddPayment(amount int64, paidAt time.Time, note string) (Payment, error) {
+	if b._invoicedAt.IsZero() {
+		return Payment{}, ErrNotInvoiced{}
+	}
+	if amount <= 0 {
+		return Payment{}, ErrInvalidAmount{Amount: amount}
+	}
+	if paidAt.IsZero() {
+		paidAt = now()
+	}
+	payment := Payment{
+		Id:     uuid.NewString(),
+		Amount: amount,
+		PaidAt: paidAt.UTC(),
+		Note:   note,
+	}
+	b.record(Event{Type: EventTypePaymentAdded, Payment: payment})
+	return payment, nil
+}
+
+func (b *Billing) GetPayments() []Payment {
+	return append([]Payment(nil), b._payments...)
+}
+
+func (b *Billing) GetPaid() int64 {
+	var paid int64
+	for _, payment := range b._payments {
+		paid += payment.Amount
+	}
+	return paid
+}
+
+// GetOutstanding returns the invoiced amount not paid yet, overpayments give a negative value.
+func (b *Billing) GetOutstanding() int64 {
+	if b._invoicedAt.IsZero() {
+		return 0
+	}
+	return b.GetTotal() - b.GetPaid()
+}
+
+// IsActionable reports whether somebody in the studio has to work on the billing.
+func (b *Billing) IsActionable() bool {
+	return b._state != StateCompleted
+}
+
 func (b *Billing) GetState() State {
 	return b._state
 }
@@ -88,10 +648,76 @@ func (b *Billing) GetBriefInfo() BriefInfo {
 }
 
 func (b *Billing) SetBriefInfo(username string) (BriefInfo, error) {
-	b._username = username
+	b.record(Event{Type: EventTypeBriefSet, Username: username})
 	return BriefInfo{Username: b._username}, nil
 }
 
+// RequestRevision records a new revision round for the current stage.
+// Revisions above the included limit are either rejected or charged
+// according to the revision policy of the billing. A surcharge would change
+// the total of an issued invoice, so charged revisions are refused once the
+// billing is invoiced.
+func (b *Billing) RequestRevision(requestedBy string, note string) (Revision, error) {
+	if b._state != StateDesign && b._state != StateLayout {
+		return Revision{}, ErrRevisionInvalidState{State: b._state}
+	}
+
+	count := b.RevisionCount(b._state)
+	if b._revisionLimit.Policy != RevisionPolicyUnlimited && count >= b._revisionLimit.Included {
+		switch b._revisionLimit.Policy {
+		case RevisionPolicyBlock:
+			return Revision{}, ErrRevisionLimitExceeded{Stage: b._state, Included: b._revisionLimit.Included}
+		case RevisionPolicySurcharge:
+			if !b._invoicedAt.IsZero() {
+				return Revision{}, ErrAlreadyInvoiced{}
+			}
+			b.record(Event{Type: EventTypeLineItemAdded, LineItem: LineItem{
+				Description: fmt.Sprintf("extra %s revision #%d", b._state, count+1),
+				Amount:      b._revisionLimit.Surcharge,
+			}})
+		}
+	}
+
+	revision := Revision{
+		Stage:       b._state,
+		RequestedBy: requestedBy,
+		Note:        note,
+		CreatedAt:   now().UTC(),
+	}
+	b.record(Event{Type: EventTypeRevisionRequested, Revision: revision, OccurredAt: revision.CreatedAt})
+	return revision, nil
+}
+
+func (b *Billing) RevisionCount(stage State) int {
+	count := 0
+	for _, revision := range b._revisions {
+		if revision.Stage == stage {
+			count++
+		}
+	}
+	return count
+}
+
+func (b *Billing) GetRevisions() []Revision {
+	return append([]Revision(nil), b._revisions...)
+}
+
+func (b *Billing) GetRevisionLimit() RevisionLimit {
+	return b._revisionLimit
+}
+
+func (b *Billing) SetRevisionLimit(limit RevisionLimit) error {
+	if err := limit.Validate(); err != nil {
+		return err
+	}
+	b.record(Event{Type: EventTypeRevisionLimitSet, RevisionLimit: limit})
+	return nil
+}
+
+func (b *Billing) GetLineItems() []LineItem {
+	return append([]LineItem(nil), b._lineItems...)
+}
+
 // ENUM(
 // pending
 // design
@@ -100,6 +726,43 @@ func (b *Billing) SetBriefInfo(username string) (BriefInfo, error) {
 // )
 type State string
 
+// ENUM(
+// unlimited
+// block
+// surcharge
+// )
+type RevisionPolicy string
+
+// ENUM(
+// on_track
+// approaching
+// overdue
+// )
+type DeadlineStatus string
+
+// ENUM(
+// low
+// normal
+// high
+// urgent
+// )
+type Priority string
+
+// Rank orders priorities from low to urgent, unknown priorities rank lowest.
+func (x Priority) Rank() int {
+	switch x {
+	case PriorityLow:
+		return 1
+	case PriorityNormal:
+		return 2
+	case PriorityHigh:
+		return 3
+	case PriorityUrgent:
+		return 4
+	}
+	return 0
+}
+
 type ErrInvalidBillingId struct {
 	BillingId string
 }
@@ -115,11 +778,61 @@ func ValidateBillingId(billingId string) error {
 	return nil
 }
 
+type RevisionDTO interface {
+	GetStage() string
+	GetRequestedBy() string
+	GetNote() string
+	GetCreatedAt() time.Time
+}
+
+type LineItemDTO interface {
+	GetDescription() string
+	GetAmount() int64
+}
+
+type StateChangeDTO interface {
+	GetState() string
+	GetEnteredAt() time.Time
+}
+
+type TimeEntryDTO interface {
+	GetId() string
+	GetStaffId() string
+	GetStage() string
+	GetStartedAt() time.Time
+	GetEndedAt() time.Time
+	GetNote() string
+}
+
+type PaymentDTO interface {
+	GetId() string
+	GetAmount() int64
+	GetPaidAt() time.Time
+	GetNote() string
+}
+
 type DTO interface {
 	GetId() string
 	GetUserId() string
 	GetState() string
 	GetUsername() string
+	GetRevisions() []RevisionDTO
+	GetIncludedRevisions() int
+	GetRevisionPolicy() string
+	GetRevisionSurcharge() int64
+	GetLineItems() []LineItemDTO
+	GetCreatedAt() time.Time
+	GetStateChanges() []StateChangeDTO
+	GetDeadline() time.Time
+	GetStageTargets() map[string]time.Duration
+	GetPriority() string
+	GetAssignees() map[string]string
+	GetTimeEntries() []TimeEntryDTO
+	GetPrice() int64
+	GetInvoicedAt() time.Time
+	GetPayments() []PaymentDTO
+	GetDeletedAt() time.Time
+	GetDeletedBy() string
 }
 
 func ToModelFromDTO(dto DTO) (Billing, error) {
@@ -138,10 +851,150 @@ func ToModelFromDTO(dto DTO) (Billing, error) {
 	if err != nil {
 		return Billing{}, err
 	}
+
+	var revisions []Revision
+	for _, revisionDTO := range dto.GetRevisions() {
+		dtoStage := revisionDTO.GetStage()
+		stage, err := ParseState(dtoStage)
+		if err != nil {
+			return Billing{}, fmt.Errorf("%s is %w", dtoStage, ErrInvalidState)
+		}
+		revisions = append(revisions, Revision{
+			Stage:       stage,
+			RequestedBy: revisionDTO.GetRequestedBy(),
+			Note:        revisionDTO.GetNote(),
+			CreatedAt:   revisionDTO.GetCreatedAt(),
+		})
+	}
+
+	// Billings stored before revision limits were introduced have no policy.
+	policy := RevisionPolicyUnlimited
+	if dtoPolicy := dto.GetRevisionPolicy(); dtoPolicy != "" {
+		policy, err = ParseRevisionPolicy(dtoPolicy)
+		if err != nil {
+			return Billing{}, err
+		}
+	}
+	revisionLimit := RevisionLimit{
+		Included:  dto.GetIncludedRevisions(),
+		Policy:    policy,
+		Surcharge: dto.GetRevisionSurcharge(),
+	}
+	if err = revisionLimit.Validate(); err != nil {
+		return Billing{}, err
+	}
+
+	var lineItems []LineItem
+	for _, lineItemDTO := range dto.GetLineItems() {
+		lineItems = append(lineItems, LineItem{
+			Description: lineItemDTO.GetDescription(),
+			Amount:      lineItemDTO.GetAmount(),
+		})
+	}
+
+	var stateChanges []StateChange
+	for _, stateChangeDTO := range dto.GetStateChanges() {
+		dtoChangeState := stateChangeDTO.GetState()
+		changeState, err := ParseState(dtoChangeState)
+		if err != nil {
+			return Billing{}, fmt.Errorf("%s is %w", dtoChangeState, ErrInvalidState)
+		}
+		stateChanges = append(stateChanges, StateChange{
+			State:     changeState,
+			EnteredAt: stateChangeDTO.GetEnteredAt(),
+		})
+	}
+
+	var stageTargets map[State]time.Duration
+	for dtoStage, target := range dto.GetStageTargets() {
+		stage, err := ParseState(dtoStage)
+		if err != nil {
+			return Billing{}, fmt.Errorf("%s is %w", dtoStage, ErrInvalidState)
+		}
+		if stageTargets == nil {
+			stageTargets = map[State]time.Duration{}
+		}
+		stageTargets[stage] = target
+	}
+	if err = ValidateStageTargets(stageTargets); err != nil {
+		return Billing{}, err
+	}
+
+	// Billings stored before priorities were introduced have no priority.
+	priority := PriorityNormal
+	if dtoPriority := dto.GetPriority(); dtoPriority != "" {
+		priority, err = ParsePriority(dtoPriority)
+		if err != nil {
+			return Billing{}, err
+		}
+	}
+
+	var assignees map[State]string
+	for dtoStage, staffId := range dto.GetAssignees() {
+		stage, err := ParseState(dtoStage)
+		if err != nil {
+			return Billing{}, fmt.Errorf("%s is %w", dtoStage, ErrInvalidState)
+		}
+		if err = staff.ValidateStaffId(staffId); err != nil {
+			return Billing{}, err
+		}
+		if assignees == nil {
+			assignees = map[State]string{}
+		}
+		assignees[stage] = staffId
+	}
+
+	var timeEntries []TimeEntry
+	for _, timeEntryDTO := range dto.GetTimeEntries() {
+		dtoStage := timeEntryDTO.GetStage()
+		stage, err := ParseState(dtoStage)
+		if err != nil {
+			return Billing{}, fmt.Errorf("%s is %w", dtoStage, ErrInvalidState)
+		}
+		timeEntries = append(timeEntries, TimeEntry{
+			Id:        timeEntryDTO.GetId(),
+			StaffId:   timeEntryDTO.GetStaffId(),
+			Stage:     stage,
+			StartedAt: timeEntryDTO.GetStartedAt(),
+			EndedAt:   timeEntryDTO.GetEndedAt(),
+			Note:      timeEntryDTO.GetNote(),
+		})
+	}
+
+	price := dto.GetPrice()
+	if price < 0 {
+		return Billing{}, ErrInvalidAmount{Amount: price}
+	}
+
+	var payments []Payment
+	for _, paymentDTO := range dto.GetPayments() {
+		payments = append(payments, Payment{
+			Id:     paymentDTO.GetId(),
+			Amount: paymentDTO.GetAmount(),
+			PaidAt: paymentDTO.GetPaidAt(),
+			Note:   paymentDTO.GetNote(),
+		})
+	}
+
 	return Billing{
-		Id:        id,
-		UserId:    userId,
-		_state:    state,
-		_username: dto.GetUsername(),
+		Id:             id,
+		UserId:         userId,
+		_state:         state,
+		_username:      dto.GetUsername(),
+		_revisions:     revisions,
+		_revisionLimit: revisionLimit,
+		_lineItems:     lineItems,
+		_createdAt:     dto.GetCreatedAt(),
+		_stateChanges:  stateChanges,
+		_deadline:      dto.GetDeadline(),
+		_stageTargets:  stageTargets,
+		_priority:      priority,
+		_assignees:     assignees,
+		_timeEntries:   timeEntries,
+		_price:         price,
+		_invoicedAt:    dto.GetInvoicedAt(),
+		_payments:      payments,
+		_deletedAt:     dto.GetDeletedAt(),
+		_deletedBy:     dto.GetDeletedBy(),
 	}, nil
 }
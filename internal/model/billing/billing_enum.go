@@ -50,3 +50,117 @@ func ParseState(name string) (State, error) {
 	}
 	return State(""), fmt.Errorf("%s is %w", name, ErrInvalidState)
 }
+
+const (
+	// RevisionPolicyUnlimited is a RevisionPolicy of type unlimited.
+	RevisionPolicyUnlimited RevisionPolicy = "unlimited"
+	// RevisionPolicyBlock is a RevisionPolicy of type block.
+	RevisionPolicyBlock RevisionPolicy = "block"
+	// RevisionPolicySurcharge is a RevisionPolicy of type surcharge.
+	RevisionPolicySurcharge RevisionPolicy = "surcharge"
+)
+
+var ErrInvalidRevisionPolicy = errors.New("not a valid RevisionPolicy")
+
+// String implements the Stringer interface.
+func (x RevisionPolicy) String() string {
+	return string(x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x RevisionPolicy) IsValid() bool {
+	_, err := ParseRevisionPolicy(string(x))
+	return err == nil
+}
+
+var _RevisionPolicyValue = map[string]RevisionPolicy{
+	"unlimited": RevisionPolicyUnlimited,
+	"block":     RevisionPolicyBlock,
+	"surcharge": RevisionPolicySurcharge,
+}
+
+// ParseRevisionPolicy attempts to convert a string to a RevisionPolicy.
+func ParseRevisionPolicy(name string) (RevisionPolicy, error) {
+	if x, ok := _RevisionPolicyValue[name]; ok {
+		return x, nil
+	}
+	return RevisionPolicy(""), fmt.Errorf("%s is %w", name, ErrInvalidRevisionPolicy)
+}
+
+const (
+	// DeadlineStatusOnTrack is a DeadlineStatus of type on_track.
+	DeadlineStatusOnTrack DeadlineStatus = "on_track"
+	// DeadlineStatusApproaching is a DeadlineStatus of type approaching.
+	DeadlineStatusApproaching DeadlineStatus = "approaching"
+	// DeadlineStatusOverdue is a DeadlineStatus of type overdue.
+	DeadlineStatusOverdue DeadlineStatus = "overdue"
+)
+
+var ErrInvalidDeadlineStatus = errors.New("not a valid DeadlineStatus")
+
+// String implements the Stringer interface.
+func (x DeadlineStatus) String() string {
+	return string(x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x DeadlineStatus) IsValid() bool {
+	_, err := ParseDeadlineStatus(string(x))
+	return err == nil
+}
+
+var _DeadlineStatusValue = map[string]DeadlineStatus{
+	"on_track":    DeadlineStatusOnTrack,
+	"approaching": DeadlineStatusApproaching,
+	"overdue":     DeadlineStatusOverdue,
+}
+
+// ParseDeadlineStatus attempts to convert a string to a DeadlineStatus.
+func ParseDeadlineStatus(name string) (DeadlineStatus, error) {
+	if x, ok := _DeadlineStatusValue[name]; ok {
+		return x, nil
+	}
+	return DeadlineStatus(""), fmt.Errorf("%s is %w", name, ErrInvalidDeadlineStatus)
+}
+
+const (
+	// PriorityLow is a Priority of type low.
+	PriorityLow Priority = "low"
+	// PriorityNormal is a Priority of type normal.
+	PriorityNormal Priority = "normal"
+	// PriorityHigh is a Priority of type high.
+	PriorityHigh Priority = "high"
+	// PriorityUrgent is a Priority of type urgent.
+	PriorityUrgent Priority = "urgent"
+)
+
+var ErrInvalidPriority = errors.New("not a valid Priority")
+
+// String implements the Stringer interface.
+func (x Priority) String() string {
+	return string(x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x Priority) IsValid() bool {
+	_, err := ParsePriority(string(x))
+	return err == nil
+}
+
+var _PriorityValue = map[string]Priority{
+	"low":    PriorityLow,
+	"normal": PriorityNormal,
+	"high":   PriorityHigh,
+	"urgent": PriorityUrgent,
+}
+
+// ParsePriority attempts to convert a string to a Priority.
+func ParsePriority(name string) (Priority, error) {
+	if x, ok := _PriorityValue[name]; ok {
+		return x, nil
+	}
+	return Priority(""), fmt.Errorf("%s is %w", name, ErrInvalidPriority)
+}
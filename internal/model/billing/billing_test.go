@@ -2,6 +2,7 @@ package billing
 
 import (
 	"testing"
+	"time"
 
 	"github.com/ThePositree/billing_manager/internal/model/user"
 	"github.com/stretchr/testify/assert"
@@ -41,3 +42,300 @@ func TestBilling(t *testing.T) {
 	state = billing.GetState()
 	assert.Equal(t, StateCompleted, state)
 }
+
+func TestRequestRevision(t *testing.T) {
+	billing, err := New("123e4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+
+	_, err = billing.RequestRevision("client", "")
+	assert.EqualError(t, err, (ErrRevisionInvalidState{State: StatePending}).Error())
+
+	err = billing.SetRevisionLimit(RevisionLimit{Included: 1, Policy: RevisionPolicySurcharge})
+	assert.Error(t, err)
+
+	err = billing.SetRevisionLimit(RevisionLimit{Included: 1, Policy: RevisionPolicyBlock})
+	assert.NoError(t, err)
+
+	err = billing.NextState()
+	assert.NoError(t, err)
+
+	revision, err := billing.RequestRevision("client", "bigger logo")
+	assert.NoError(t, err)
+	assert.Equal(t, StateDesign, revision.Stage)
+
+	_, err = billing.RequestRevision("client", "smaller logo")
+	assert.EqualError(t, err, (ErrRevisionLimitExceeded{Stage: StateDesign, Included: 1}).Error())
+
+	err = billing.SetRevisionLimit(RevisionLimit{Included: 1, Policy: RevisionPolicySurcharge, Surcharge: 500})
+	assert.NoError(t, err)
+
+	_, err = billing.RequestRevision("client", "smaller logo")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, billing.RevisionCount(StateDesign))
+	assert.Equal(t, []LineItem{{Description: "extra design revision #2", Amount: 500}}, billing.GetLineItems())
+
+	err = billing.NextState()
+	assert.NoError(t, err)
+
+	_, err = billing.RequestRevision("client", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, billing.RevisionCount(StateLayout))
+	assert.Len(t, billing.GetLineItems(), 1)
+}
+
+func TestDeadlineStatus(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	billing, err := New("123e4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+
+	assert.Equal(t, DeadlineStatusOnTrack, billing.GetDeadlineStatus(start.Add(time.Hour*1000), time.Hour))
+
+	err = billing.SetStageTargets(map[State]time.Duration{StateCompleted: time.Hour})
+	assert.EqualError(t, err, (ErrInvalidStageTarget{Stage: StateCompleted}).Error())
+
+	err = billing.SetStageTargets(map[State]time.Duration{StateDesign: time.Hour * 48})
+	assert.NoError(t, err)
+	billing.SetDeadline(start.Add(time.Hour * 72))
+
+	err = billing.NextState()
+	assert.NoError(t, err)
+
+	dueAt, ok := billing.NextDueAt()
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(time.Hour*48), dueAt)
+
+	assert.Equal(t, DeadlineStatusOnTrack, billing.GetDeadlineStatus(start.Add(time.Hour*24), time.Hour))
+	assert.Equal(t, DeadlineStatusApproaching, billing.GetDeadlineStatus(start.Add(time.Hour*47), time.Hour))
+	assert.Equal(t, DeadlineStatusOverdue, billing.GetDeadlineStatus(start.Add(time.Hour*49), time.Hour))
+	assert.True(t, billing.IsOverdue(start.Add(time.Hour*49)))
+
+	now = func() time.Time { return start.Add(time.Hour * 24) }
+	err = billing.NextState()
+	assert.NoError(t, err)
+
+	dueAt, ok = billing.NextDueAt()
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(time.Hour*72), dueAt)
+
+	err = billing.NextState()
+	assert.NoError(t, err)
+	assert.False(t, billing.IsOverdue(start.Add(time.Hour*100)))
+}
+
+func TestPriority(t *testing.T) {
+	billing, err := New("123e4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+	assert.Equal(t, PriorityNormal, billing.GetPriority())
+
+	err = billing.SetPriority(Priority("asap"))
+	assert.ErrorIs(t, err, ErrInvalidPriority)
+
+	err = billing.SetPriority(PriorityUrgent)
+	assert.NoError(t, err)
+	assert.Equal(t, PriorityUrgent, billing.GetPriority())
+	assert.Greater(t, PriorityUrgent.Rank(), PriorityHigh.Rank())
+	assert.Greater(t, PriorityNormal.Rank(), PriorityLow.Rank())
+}
+
+func TestAssign(t *testing.T) {
+	billing, err := New("123e4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+
+	designerId := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	layouterId := "6ba7b811-9dad-11d1-80b4-00c04fd430c8"
+
+	err = billing.Assign(StateCompleted, designerId)
+	assert.EqualError(t, err, (ErrAssignInvalidStage{Stage: StateCompleted}).Error())
+
+	err = billing.Assign(StateDesign, "blablabla")
+	assert.Error(t, err)
+
+	err = billing.Assign(StateDesign, designerId)
+	assert.NoError(t, err)
+	err = billing.Assign(StateLayout, layouterId)
+	assert.NoError(t, err)
+
+	_, ok := billing.GetCurrentAssignee()
+	assert.False(t, ok)
+
+	err = billing.NextState()
+	assert.NoError(t, err)
+
+	staffId, ok := billing.GetCurrentAssignee()
+	assert.True(t, ok)
+	assert.Equal(t, designerId, staffId)
+
+	err = billing.Assign(StateDesign, "")
+	assert.NoError(t, err)
+	_, ok = billing.GetCurrentAssignee()
+	assert.False(t, ok)
+	assert.Equal(t, map[State]string{StateLayout: layouterId}, billing.GetAssignees())
+}
+
+func TestTimeTracking(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	staffId := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+	billing, err := New("123e4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+
+	_, err = billing.LogTime(staffId, StateDesign, start, start.Add(time.Hour), "")
+	assert.EqualError(t, err, (ErrTimeEntryInvalidStage{Stage: StateDesign}).Error())
+
+	err = billing.NextState()
+	assert.NoError(t, err)
+
+	_, err = billing.LogTime(staffId, StateDesign, start, start, "")
+	assert.Error(t, err)
+
+	_, err = billing.LogTime(staffId, StateDesign, start, start.Add(time.Hour), "sketches")
+	assert.NoError(t, err)
+
+	_, err = billing.StopTimer(staffId)
+	assert.EqualError(t, err, (ErrTimerNotRunning{StaffId: staffId}).Error())
+
+	_, err = billing.StartTimer(staffId, "")
+	assert.NoError(t, err)
+
+	_, err = billing.StartTimer(staffId, "")
+	assert.EqualError(t, err, (ErrTimerAlreadyRunning{StaffId: staffId}).Error())
+
+	now = func() time.Time { return start.Add(time.Minute * 30) }
+	entry, err := billing.StopTimer(staffId)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute*30, entry.Duration())
+
+	err = billing.NextState()
+	assert.NoError(t, err)
+	err = billing.PrevState()
+	assert.NoError(t, err)
+
+	_, err = billing.LogTime(staffId, StateLayout, start, start.Add(time.Hour*2), "")
+	assert.NoError(t, err)
+
+	_, err = billing.LogTime(staffId, StateCompleted, start, start.Add(time.Hour), "")
+	assert.EqualError(t, err, (ErrTimeEntryInvalidStage{Stage: StateCompleted}).Error())
+
+	assert.Equal(t, map[State]time.Duration{
+		StateDesign: time.Hour + time.Minute*30,
+		StateLayout: time.Hour * 2,
+	}, billing.GetTimeTotals())
+}
+
+func TestRequestRevisionAfterInvoice(t *testing.T) {
+	billing, err := New("123e4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+
+	err = billing.SetRevisionLimit(RevisionLimit{Included: 1, Policy: RevisionPolicySurcharge, Surcharge: 500})
+	assert.NoError(t, err)
+	err = billing.NextState()
+	assert.NoError(t, err)
+	err = billing.SetPrice(50000)
+	assert.NoError(t, err)
+	err = billing.Invoice()
+	assert.NoError(t, err)
+
+	// Included revisions are free, so they do not change the invoice.
+	_, err = billing.RequestRevision("client", "bigger logo")
+	assert.NoError(t, err)
+
+	changes := len(billing.GetChanges())
+	_, err = billing.RequestRevision("client", "smaller logo")
+	assert.EqualError(t, err, (ErrAlreadyInvoiced{}).Error())
+	assert.Len(t, billing.GetChanges(), changes)
+	assert.Equal(t, 1, billing.RevisionCount(StateDesign))
+	assert.Empty(t, billing.GetLineItems())
+	assert.Equal(t, int64(50000), billing.GetTotal())
+}
+
+func TestInvoiceAndPayments(t *testing.T) {
+	invoicedAt := time.Date(2024, time.April, 1, 9, 0, 0, 0, time.UTC)
+	now = func() time.Time { return invoicedAt }
+	defer func() { now = time.Now }()
+
+	billing, err := New("123e4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+
+	err = billing.SetPrice(-1)
+	assert.EqualError(t, err, (ErrInvalidAmount{Amount: -1}).Error())
+
+	err = billing.Invoice()
+	assert.EqualError(t, err, (ErrNothingToInvoice{}).Error())
+
+	_, err = billing.AddPayment(100, time.Time{}, "")
+	assert.EqualError(t, err, (ErrNotInvoiced{}).Error())
+
+	err = billing.SetPrice(50000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), billing.GetOutstanding())
+
+	err = billing.Invoice()
+	assert.NoError(t, err)
+	assert.Equal(t, invoicedAt, billing.GetInvoicedAt())
+
+	err = billing.SetPrice(60000)
+	assert.EqualError(t, err, (ErrAlreadyInvoiced{}).Error())
+
+	payment, err := billing.AddPayment(20000, time.Time{}, "prepayment")
+	assert.NoError(t, err)
+	assert.Equal(t, invoicedAt, payment.PaidAt)
+	assert.Equal(t, int64(20000), billing.GetPaid())
+	assert.Equal(t, int64(30000), billing.GetOutstanding())
+}
+
+func TestSoftDelete(t *testing.T) {
+	deletedAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return deletedAt }
+	defer func() { now = time.Now }()
+
+	billing, err := New("123e4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+	assert.False(t, billing.IsDeleted())
+
+	billing.MarkDeleted("admin")
+	assert.True(t, billing.IsDeleted())
+	assert.Equal(t, deletedAt, billing.GetDeletedAt())
+	assert.Equal(t, "admin", billing.GetDeletedBy())
+
+	billing.Restore()
+	assert.False(t, billing.IsDeleted())
+	assert.Empty(t, billing.GetDeletedBy())
+
+	rebuilt, err := FromEvents(billing.GetChanges())
+	assert.NoError(t, err)
+	assert.False(t, rebuilt.IsDeleted())
+	assert.Len(t, rebuilt.GetChanges(), 0)
+}
+
+func TestAnonymize(t *testing.T) {
+	billing, err := New("123e4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+
+	_, err = billing.SetBriefInfo("client")
+	assert.NoError(t, err)
+	err = billing.SetPrice(50000)
+	assert.NoError(t, err)
+	err = billing.NextState()
+	assert.NoError(t, err)
+	_, err = billing.RequestRevision("client", "bigger logo")
+	assert.NoError(t, err)
+
+	billing.Anonymize()
+	assert.Empty(t, billing.GetBriefInfo().Username)
+	assert.Len(t, billing.GetRevisions(), 1)
+	assert.Empty(t, billing.GetRevisions()[0].RequestedBy)
+	assert.Empty(t, billing.GetRevisions()[0].Note)
+	assert.Equal(t, StateDesign, billing.GetRevisions()[0].Stage)
+	assert.Equal(t, int64(50000), billing.GetPrice())
+
+	rebuilt, err := FromEvents(billing.GetChanges())
+	assert.NoError(t, err)
+	assert.Equal(t, billing.GetBriefInfo(), rebuilt.GetBriefInfo())
+	assert.Equal(t, billing.GetRevisions(), rebuilt.GetRevisions())
+}
@@ -0,0 +1,375 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/staff"
+	"github.com/ThePositree/billing_manager/internal/model/user"
+)
+
+// ENUM(
+// created
+// state_advanced
+// state_reverted
+// brief_set
+// revision_requested
+// line_item_added
+// revision_limit_set
+// deadline_set
+// stage_targets_set
+// priority_set
+// assigned
+// time_entry_added
+// timer_stopped
+// price_set
+// invoiced
+// payment_added
+// deleted
+// restored
+// anonymized
+// )
+type EventType string
+
+var ErrEmptyStream = errors.New("event stream does not start with a created event")
+
+// Event is a single change of a billing. Which of the payload fields are
+// set depends on Type, the rest keep their zero values.
+type Event struct {
+	Type       EventType
+	BillingId  string
+	OccurredAt time.Time
+
+	// UserId is set by created.
+	UserId string
+	// State is the entered state of state_advanced and state_reverted.
+	State State
+	// Username is set by brief_set.
+	Username string
+	// Revision is set by revision_requested, its creation time is OccurredAt.
+	Revision Revision
+	// LineItem is set by line_item_added.
+	LineItem LineItem
+	// RevisionLimit is set by revision_limit_set.
+	RevisionLimit RevisionLimit
+	// Deadline is set by deadline_set, zero time removes the deadline.
+	Deadline time.Time
+	// StageTargets is set by stage_targets_set and replaces all targets.
+	StageTargets map[State]time.Duration
+	// Priority is set by priority_set.
+	Priority Priority
+	// Stage and StaffId are set by assigned, an empty staff id removes the assignment.
+	Stage   State
+	StaffId string
+	// TimeEntry is set by time_entry_added, timer_stopped only sets its Id
+	// and ends the entry at OccurredAt.
+	TimeEntry TimeEntry
+	// Price is set by price_set.
+	Price int64
+	// Payment is set by payment_added.
+	Payment Payment
+	// DeletedBy is set by deleted, the deletion time is OccurredAt.
+	DeletedBy string
+}
+
+// record applies a new change to the billing and keeps it until the
+// changes are stored, see GetChanges.
+func (b *Billing) record(event Event) {
+	if event.BillingId == "" {
+		event.BillingId = b.Id
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = now().UTC()
+	}
+	// Events recorded by the billing itself are always known.
+	_ = b.apply(event)
+	b._changes = append(b._changes, event)
+}
+
+func (b *Billing) apply(event Event) error {
+	switch event.Type {
+	case EventTypeCreated:
+		b.Id = event.BillingId
+		b.UserId = event.UserId
+		b._state = StatePending
+		b._revisionLimit = RevisionLimit{Policy: RevisionPolicyUnlimited}
+		b._createdAt = event.OccurredAt
+		b._stateChanges = []StateChange{{State: StatePending, EnteredAt: event.OccurredAt}}
+		b._priority = PriorityNormal
+	case EventTypeStateAdvanced, EventTypeStateReverted:
+		b._state = event.State
+		b._stateChanges = append(b._stateChanges, StateChange{State: event.State, EnteredAt: event.OccurredAt})
+	case EventTypeBriefSet:
+		b._username = event.Username
+	case EventTypeRevisionRequested:
+		revision := event.Revision
+		revision.CreatedAt = event.OccurredAt
+		b._revisions = append(b._revisions, revision)
+	case EventTypeLineItemAdded:
+		b._lineItems = append(b._lineItems, event.LineItem)
+	case EventTypeRevisionLimitSet:
+		b._revisionLimit = event.RevisionLimit
+	case EventTypeDeadlineSet:
+		b._deadline = event.Deadline
+	case EventTypeStageTargetsSet:
+		b._stageTargets = make(map[State]time.Duration, len(event.StageTargets))
+		for stage, target := range event.StageTargets {
+			b._stageTargets[stage] = target
+		}
+	case EventTypePrioritySet:
+		b._priority = event.Priority
+	case EventTypeAssigned:
+		if event.StaffId == "" {
+			delete(b._assignees, event.Stage)
+			break
+		}
+		if b._assignees == nil {
+			b._assignees = map[State]string{}
+		}
+		b._assignees[event.Stage] = event.StaffId
+	case EventTypeTimeEntryAdded:
+		b._timeEntries = append(b._timeEntries, event.TimeEntry)
+	case EventTypeTimerStopped:
+		for i, entry := range b._timeEntries {
+			if entry.Id == event.TimeEntry.Id {
+				b._timeEntries[i].EndedAt = event.OccurredAt
+			}
+		}
+	case EventTypePriceSet:
+		b._price = event.Price
+	case EventTypeInvoiced:
+		b._invoicedAt = event.OccurredAt
+	case EventTypePaymentAdded:
+		b._payments = append(b._payments, event.Payment)
+	case EventTypeDeleted:
+		b._deletedAt = event.OccurredAt
+		b._deletedBy = event.DeletedBy
+	case EventTypeRestored:
+		b._deletedAt = time.Time{}
+		b._deletedBy = ""
+	case EventTypeAnonymized:
+		b._username = ""
+		for i := range b._revisions {
+			b._revisions[i].RequestedBy = ""
+			b._revisions[i].Note = ""
+		}
+	default:
+		return fmt.Errorf("%s is %w", event.Type, ErrInvalidEventType)
+	}
+	return nil
+}
+
+// GetVersion returns the number of stored events the billing is built from,
+// changes that are not stored yet are not counted.
+func (b *Billing) GetVersion() int {
+	return b._version
+}
+
+// GetChanges returns the events recorded since the billing was loaded.
+func (b *Billing) GetChanges() []Event {
+	return append([]Event(nil), b._changes...)
+}
+
+// CommitChanges marks the recorded changes as stored.
+func (b *Billing) CommitChanges() {
+	b._version += len(b._changes)
+	b._changes = nil
+}
+
+// Replay applies stored events on top of the billing, they have to
+// continue right after its version.
+func (b *Billing) Replay(events []Event) error {
+	for _, event := range events {
+		if err := b.apply(event); err != nil {
+			return err
+		}
+		b._version++
+	}
+	return nil
+}
+
+// FromEvents rebuilds a billing from its whole event stream.
+func FromEvents(events []Event) (Billing, error) {
+	if len(events) == 0 || events[0].Type != EventTypeCreated {
+		return Billing{}, ErrEmptyStream
+	}
+	var billing Billing
+	if err := billing.Replay(events); err != nil {
+		return Billing{}, err
+	}
+	return billing, nil
+}
+
+// ToModelFromSnapshot restores a billing stored at the given version,
+// newer events are added with Replay.
+func ToModelFromSnapshot(dto DTO, version int) (Billing, error) {
+	billing, err := ToModelFromDTO(dto)
+	if err != nil {
+		return Billing{}, err
+	}
+	billing._version = version
+	return billing, nil
+}
+
+type EventDTO interface {
+	GetType() string
+	GetBillingId() string
+	GetOccurredAt() time.Time
+	GetUserId() string
+	GetState() string
+	GetUsername() string
+	// GetRevision, GetLineItem, GetTimeEntry and GetPayment return nil
+	// for events without them.
+	GetRevision() RevisionDTO
+	GetLineItem() LineItemDTO
+	GetIncludedRevisions() int
+	GetRevisionPolicy() string
+	GetRevisionSurcharge() int64
+	GetDeadline() time.Time
+	GetStageTargets() map[string]time.Duration
+	GetPriority() string
+	GetStage() string
+	GetStaffId() string
+	GetTimeEntry() TimeEntryDTO
+	GetPrice() int64
+	GetPayment() PaymentDTO
+	GetDeletedBy() string
+}
+
+func ToEventFromDTO(dto EventDTO) (Event, error) {
+	eventType, err := ParseEventType(dto.GetType())
+	if err != nil {
+		return Event{}, err
+	}
+	billingId := dto.GetBillingId()
+	if err = ValidateBillingId(billingId); err != nil {
+		return Event{}, err
+	}
+	event := Event{
+		Type:       eventType,
+		BillingId:  billingId,
+		OccurredAt: dto.GetOccurredAt(),
+	}
+
+	switch eventType {
+	case EventTypeCreated:
+		event.UserId = dto.GetUserId()
+		if err = user.ValidateUserId(event.UserId); err != nil {
+			return Event{}, err
+		}
+	case EventTypeStateAdvanced, EventTypeStateReverted:
+		if event.State, err = ParseState(dto.GetState()); err != nil {
+			return Event{}, err
+		}
+	case EventTypeBriefSet:
+		event.Username = dto.GetUsername()
+	case EventTypeRevisionRequested:
+		revisionDTO := dto.GetRevision()
+		if revisionDTO == nil {
+			return Event{}, fmt.Errorf("%s event without revision", eventType)
+		}
+		stage, err := ParseState(revisionDTO.GetStage())
+		if err != nil {
+			return Event{}, err
+		}
+		event.Revision = Revision{
+			Stage:       stage,
+			RequestedBy: revisionDTO.GetRequestedBy(),
+			Note:        revisionDTO.GetNote(),
+			CreatedAt:   revisionDTO.GetCreatedAt(),
+		}
+	case EventTypeLineItemAdded:
+		lineItemDTO := dto.GetLineItem()
+		if lineItemDTO == nil {
+			return Event{}, fmt.Errorf("%s event without line item", eventType)
+		}
+		event.LineItem = LineItem{
+			Description: lineItemDTO.GetDescription(),
+			Amount:      lineItemDTO.GetAmount(),
+		}
+	case EventTypeRevisionLimitSet:
+		policy, err := ParseRevisionPolicy(dto.GetRevisionPolicy())
+		if err != nil {
+			return Event{}, err
+		}
+		event.RevisionLimit = RevisionLimit{
+			Included:  dto.GetIncludedRevisions(),
+			Policy:    policy,
+			Surcharge: dto.GetRevisionSurcharge(),
+		}
+		if err = event.RevisionLimit.Validate(); err != nil {
+			return Event{}, err
+		}
+	case EventTypeDeadlineSet:
+		event.Deadline = dto.GetDeadline()
+	case EventTypeStageTargetsSet:
+		event.StageTargets = map[State]time.Duration{}
+		for dtoStage, target := range dto.GetStageTargets() {
+			stage, err := ParseState(dtoStage)
+			if err != nil {
+				return Event{}, err
+			}
+			event.StageTargets[stage] = target
+		}
+		if err = ValidateStageTargets(event.StageTargets); err != nil {
+			return Event{}, err
+		}
+	case EventTypePrioritySet:
+		if event.Priority, err = ParsePriority(dto.GetPriority()); err != nil {
+			return Event{}, err
+		}
+	case EventTypeAssigned:
+		if event.Stage, err = ParseState(dto.GetStage()); err != nil {
+			return Event{}, err
+		}
+		event.StaffId = dto.GetStaffId()
+		if event.StaffId != "" {
+			if err = staff.ValidateStaffId(event.StaffId); err != nil {
+				return Event{}, err
+			}
+		}
+	case EventTypeTimeEntryAdded, EventTypeTimerStopped:
+		timeEntryDTO := dto.GetTimeEntry()
+		if timeEntryDTO == nil {
+			return Event{}, fmt.Errorf("%s event without time entry", eventType)
+		}
+		event.TimeEntry = TimeEntry{Id: timeEntryDTO.GetId()}
+		if eventType == EventTypeTimeEntryAdded {
+			stage, err := ParseState(timeEntryDTO.GetStage())
+			if err != nil {
+				return Event{}, err
+			}
+			event.TimeEntry = TimeEntry{
+				Id:        timeEntryDTO.GetId(),
+				StaffId:   timeEntryDTO.GetStaffId(),
+				Stage:     stage,
+				StartedAt: timeEntryDTO.GetStartedAt(),
+				EndedAt:   timeEntryDTO.GetEndedAt(),
+				Note:      timeEntryDTO.GetNote(),
+			}
+		}
+	case EventTypePriceSet:
+		event.Price = dto.GetPrice()
+		if event.Price < 0 {
+			return Event{}, ErrInvalidAmount{Amount: event.Price}
+		}
+	case EventTypeInvoiced:
+	case EventTypePaymentAdded:
+		paymentDTO := dto.GetPayment()
+		if paymentDTO == nil {
+			return Event{}, fmt.Errorf("%s event without payment", eventType)
+		}
+		event.Payment = Payment{
+			Id:     paymentDTO.GetId(),
+			Amount: paymentDTO.GetAmount(),
+			PaidAt: paymentDTO.GetPaidAt(),
+			Note:   paymentDTO.GetNote(),
+		}
+	case EventTypeDeleted:
+		event.DeletedBy = dto.GetDeletedBy()
+	case EventTypeRestored, EventTypeAnonymized:
+	}
+
+	return event, nil
+}
@@ -0,0 +1,63 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayEvents(t *testing.T) {
+	billing, err := New("123e4567-e89b-12d3-a456-426614174000")
+	assert.NoError(t, err)
+
+	err = billing.SetRevisionLimit(RevisionLimit{Included: 0, Policy: RevisionPolicySurcharge, Surcharge: 1000})
+	assert.NoError(t, err)
+	billing.SetDeadline(time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC))
+	err = billing.SetStageTargets(map[State]time.Duration{StateDesign: time.Hour})
+	assert.NoError(t, err)
+	err = billing.NextState()
+	assert.NoError(t, err)
+	_, err = billing.RequestRevision("client", "bigger logo")
+	assert.NoError(t, err)
+	err = billing.Assign(StateDesign, "123e4567-e89b-12d3-a456-426614174001")
+	assert.NoError(t, err)
+	_, err = billing.StartTimer("123e4567-e89b-12d3-a456-426614174001", "")
+	assert.NoError(t, err)
+	_, err = billing.StopTimer("123e4567-e89b-12d3-a456-426614174001")
+	assert.NoError(t, err)
+	err = billing.PrevState()
+	assert.NoError(t, err)
+	err = billing.SetPrice(50000)
+	assert.NoError(t, err)
+	err = billing.Invoice()
+	assert.NoError(t, err)
+	_, err = billing.AddPayment(20000, time.Time{}, "")
+	assert.NoError(t, err)
+
+	changes := billing.GetChanges()
+	assert.Len(t, changes, 14)
+	assert.Equal(t, EventTypeCreated, changes[0].Type)
+	assert.Equal(t, EventTypeLineItemAdded, changes[5].Type)
+	assert.Equal(t, 0, billing.GetVersion())
+
+	rebuilt, err := FromEvents(changes)
+	assert.NoError(t, err)
+	billing.CommitChanges()
+	assert.Equal(t, 14, billing.GetVersion())
+	assert.Empty(t, billing.GetChanges())
+	assert.Equal(t, billing, rebuilt)
+
+	err = rebuilt.SetPriority(PriorityUrgent)
+	assert.NoError(t, err)
+	err = billing.Replay(rebuilt.GetChanges())
+	assert.NoError(t, err)
+	assert.Equal(t, 15, billing.GetVersion())
+	assert.Equal(t, PriorityUrgent, billing.GetPriority())
+
+	_, err = FromEvents(changes[1:])
+	assert.ErrorIs(t, err, ErrEmptyStream)
+
+	err = billing.Replay([]Event{{Type: "unknown"}})
+	assert.ErrorIs(t, err, ErrInvalidEventType)
+}
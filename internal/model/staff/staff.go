@@ -0,0 +1,53 @@
+package staff
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type ErrInvalidStaffId struct {
+	StaffId string
+}
+
+func (e ErrInvalidStaffId) Error() string {
+	return fmt.Sprintf("%s is invalid staff id", e.StaffId)
+}
+
+// Staff is a studio member working on billings, unlike users
+// who are Telegram clients ordering them.
+type Staff struct {
+	Id   string
+	Name string
+}
+
+func New(name string) Staff {
+	return Staff{
+		Id:   uuid.NewString(),
+		Name: name,
+	}
+}
+
+func ValidateStaffId(staffId string) error {
+	if _, err := uuid.Parse(staffId); err != nil {
+		return ErrInvalidStaffId{StaffId: staffId}
+	}
+	return nil
+}
+
+type DTO interface {
+	GetId() string
+	GetName() string
+}
+
+func ToModelFromDTO(dto DTO) (Staff, error) {
+	id := dto.GetId()
+	err := ValidateStaffId(id)
+	if err != nil {
+		return Staff{}, err
+	}
+	return Staff{
+		Id:   id,
+		Name: dto.GetName(),
+	}, nil
+}
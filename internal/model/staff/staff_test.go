@@ -0,0 +1,39 @@
+package staff
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDTO struct {
+	Id   string
+	Name string
+}
+
+func (m mockDTO) GetId() string {
+	return m.Id
+}
+
+func (m mockDTO) GetName() string {
+	return m.Name
+}
+
+func TestToModelFromDTO(t *testing.T) {
+	staffId := uuid.NewString()
+
+	staff, err := ToModelFromDTO(mockDTO{
+		Id:   staffId,
+		Name: "test",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, Staff{
+		Id:   staffId,
+		Name: "test",
+	}, staff)
+
+	_, err = ToModelFromDTO(mockDTO{Id: "test"})
+	require.Error(t, err)
+}
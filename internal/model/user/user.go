@@ -2,6 +2,7 @@ package user
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,11 +15,19 @@ func (e ErrInvalidUserId) Error() string {
 	return fmt.Sprintf("%s is invalid user id", e.UserId)
 }
 
+// erasedTelegramUNPrefix starts the username of an anonymised user, the id
+// after it keeps the username unique.
+const erasedTelegramUNPrefix = "erased-"
+
 type User struct {
 	Id         string
 	TelegramUN string
+	_deletedAt time.Time
+	_deletedBy string
 }
 
+var now = time.Now
+
 func New(telegramUN string) User {
 	return User{
 		Id:         uuid.NewString(),
@@ -33,9 +42,42 @@ func ValidateUserId(userId string) error {
 	return nil
 }
 
+// MarkDeleted hides the user from normal reads until it is restored
+// or purged after the retention period.
+func (u *User) MarkDeleted(deletedBy string) {
+	u._deletedAt = now().UTC()
+	u._deletedBy = deletedBy
+}
+
+func (u *User) Restore() {
+	u._deletedAt = time.Time{}
+	u._deletedBy = ""
+}
+
+func (u *User) IsDeleted() bool {
+	return !u._deletedAt.IsZero()
+}
+
+// GetDeletedAt returns the moment the user was soft deleted, zero time means not deleted.
+func (u *User) GetDeletedAt() time.Time {
+	return u._deletedAt
+}
+
+func (u *User) GetDeletedBy() string {
+	return u._deletedBy
+}
+
+// Anonymize replaces the personal data of the user, the id stays so that
+// billings kept for accounting still refer to the user.
+func (u *User) Anonymize() {
+	u.TelegramUN = erasedTelegramUNPrefix + u.Id
+}
+
 type DTO interface {
 	GetId() string
 	GetTelegramUN() string
+	GetDeletedAt() time.Time
+	GetDeletedBy() string
 }
 
 func ToModelFromDTO(dto DTO) (User, error) {
@@ -47,5 +89,7 @@ func ToModelFromDTO(dto DTO) (User, error) {
 	return User{
 		Id:         id,
 		TelegramUN: dto.GetTelegramUN(),
+		_deletedAt: dto.GetDeletedAt(),
+		_deletedBy: dto.GetDeletedBy(),
 	}, nil
 }
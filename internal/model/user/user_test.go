@@ -2,6 +2,7 @@ package user
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -10,6 +11,8 @@ import (
 type mockDTO struct {
 	Id         string
 	TelegramUN string
+	DeletedAt  time.Time
+	DeletedBy  string
 }
 
 func (m mockDTO) GetId() string {
@@ -20,6 +23,14 @@ func (m mockDTO) GetTelegramUN() string {
 	return m.TelegramUN
 }
 
+func (m mockDTO) GetDeletedAt() time.Time {
+	return m.DeletedAt
+}
+
+func (m mockDTO) GetDeletedBy() string {
+	return m.DeletedBy
+}
+
 func TestToModelFromDTO(t *testing.T) {
 	userId := uuid.NewString()
 
@@ -37,6 +48,36 @@ func TestToModelFromDTO(t *testing.T) {
 	}, user)
 }
 
+func TestMarkDeleted(t *testing.T) {
+	user := New("test")
+	require.False(t, user.IsDeleted())
+
+	user.MarkDeleted("admin")
+	require.True(t, user.IsDeleted())
+	require.False(t, user.GetDeletedAt().IsZero())
+	require.Equal(t, "admin", user.GetDeletedBy())
+
+	restored, err := ToModelFromDTO(mockDTO{
+		Id:         user.Id,
+		TelegramUN: user.TelegramUN,
+		DeletedAt:  user.GetDeletedAt(),
+		DeletedBy:  user.GetDeletedBy(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user, restored)
+
+	user.Restore()
+	require.False(t, user.IsDeleted())
+	require.Empty(t, user.GetDeletedBy())
+}
+
+func TestAnonymize(t *testing.T) {
+	user := New("test")
+	user.Anonymize()
+	require.Equal(t, "erased-"+user.Id, user.TelegramUN)
+	require.NoError(t, ValidateUserId(user.Id))
+}
+
 func TestValidateUserId(t *testing.T) {
 	err := ValidateUserId("test")
 	require.Error(t, err)
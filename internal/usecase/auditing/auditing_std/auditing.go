@@ -0,0 +1,55 @@
+package auditing_std
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
+)
+
+var _ auditing.Auditing = auditingStd{}
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type auditingStd struct {
+	auditRepo usecase.AuditRepository
+}
+
+func (a auditingStd) Record(ctx context.Context, action string, target audit.Target, before map[string]string, after map[string]string) error {
+	actor, ok := auditing.ActorFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	entry := audit.New(actor, action, target, audit.Diff(before, after))
+	if _, err := a.auditRepo.Append(ctx, entry); err != nil {
+		return fmt.Errorf("appending audit entry to repository: %w", err)
+	}
+	return nil
+}
+
+func (a auditingStd) Find(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxLimit {
+		return []audit.Entry{}, auditing.ErrInvalidLimit
+	}
+
+	entries, err := a.auditRepo.Find(ctx, filter)
+	if err != nil {
+		return []audit.Entry{}, fmt.Errorf("finding audit entries in repository: %w", err)
+	}
+	return entries, nil
+}
+
+func New(auditRepo usecase.AuditRepository) auditingStd {
+	return auditingStd{
+		auditRepo: auditRepo,
+	}
+}
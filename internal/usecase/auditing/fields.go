@@ -0,0 +1,64 @@
+package auditing
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_staff "github.com/ThePositree/billing_manager/internal/model/staff"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+)
+
+// The audit log outlives erasure of personal data, so telegram usernames,
+// briefs and revision notes are never among the audited fields.
+
+func BillingFields(billing model_billing.Billing) map[string]string {
+	revisionLimit := billing.GetRevisionLimit()
+	var timeTotal time.Duration
+	for _, total := range billing.GetTimeTotals() {
+		timeTotal += total
+	}
+	fields := map[string]string{
+		"state":          billing.GetState().String(),
+		"revision_limit": fmt.Sprintf("%d %s %d", revisionLimit.Included, revisionLimit.Policy, revisionLimit.Surcharge),
+		"revisions":      strconv.Itoa(len(billing.GetRevisions())),
+		"deadline":       formatTime(billing.GetDeadline()),
+		"priority":       billing.GetPriority().String(),
+		"time_entries":   strconv.Itoa(len(billing.GetTimeEntries())),
+		"time_total":     timeTotal.String(),
+		"price":          strconv.FormatInt(billing.GetPrice(), 10),
+		"invoiced_at":    formatTime(billing.GetInvoicedAt()),
+		"payments":       strconv.Itoa(len(billing.GetPayments())),
+		"paid":           strconv.FormatInt(billing.GetPaid(), 10),
+		"deleted_at":     formatTime(billing.GetDeletedAt()),
+		"deleted_by":     billing.GetDeletedBy(),
+	}
+	for stage, target := range billing.GetStageTargets() {
+		fields["stage_target."+stage.String()] = target.String()
+	}
+	for stage, staffId := range billing.GetAssignees() {
+		fields["assignee."+stage.String()] = staffId
+	}
+	return fields
+}
+
+func UserFields(user model_user.User) map[string]string {
+	return map[string]string{
+		"deleted_at": formatTime(user.GetDeletedAt()),
+		"deleted_by": user.GetDeletedBy(),
+	}
+}
+
+func StaffFields(staff model_staff.Staff) map[string]string {
+	return map[string]string{
+		"name": staff.Name,
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,32 @@
+package auditing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+)
+
+var ErrInvalidLimit = errors.New("limit must be between 1 and 1000")
+
+type actorKey struct{}
+
+// WithActor marks the changes made with ctx as administrative actions of actor.
+func WithActor(ctx context.Context, actor audit.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) (audit.Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(audit.Actor)
+	return actor, ok
+}
+
+type Auditing interface {
+	// Record appends an entry with the changes between the fields of the
+	// target before and after the action. Changes made without an actor in
+	// ctx are not administrative and are not recorded. Call it with the ctx
+	// of the unit of work so that the entry is stored together with the change.
+	Record(ctx context.Context, action string, target audit.Target, before map[string]string, after map[string]string) error
+	// Find uses a limit of 100 when the filter has none.
+	Find(ctx context.Context, filter audit.Filter) ([]audit.Entry, error)
+}
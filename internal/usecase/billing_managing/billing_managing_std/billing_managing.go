@@ -4,48 +4,109 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/ThePositree/billing_manager/internal/model/audit"
 	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
 	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
 	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing"
 )
 
 var _ billing_managing.BillingManaging = billingManaging{}
 
+type Config struct {
+	DefaultRevisionLimit model_billing.RevisionLimit
+	// DefaultDeadline is added to the creation time of a new billing, zero means no deadline.
+	DefaultDeadline     time.Duration
+	DefaultStageTargets map[model_billing.State]time.Duration
+}
+
+func (cfg Config) Validate() error {
+	if err := cfg.DefaultRevisionLimit.Validate(); err != nil {
+		return fmt.Errorf("default revision limit: %w", err)
+	}
+	if cfg.DefaultDeadline < 0 {
+		return fmt.Errorf("default deadline cannot be negative")
+	}
+	if err := model_billing.ValidateStageTargets(cfg.DefaultStageTargets); err != nil {
+		return fmt.Errorf("default stage targets: %w", err)
+	}
+	return nil
+}
+
 type billingManaging struct {
 	userRepo    usecase.UserRepository
 	billingRepo usecase.BillingRepository
+	staffRepo   usecase.StaffRepository
+	transactor  usecase.Transactor
+	auditing    auditing.Auditing
+	cfg         Config
 }
 
 func (b billingManaging) Create(ctx context.Context, userId string) (model_billing.Billing, error) {
-	user, err := b.userRepo.Get(ctx, userId)
-	if errors.Is(b.userRepo.GetNoDataError(), err) {
-		return model_billing.Billing{}, billing_managing.ErrUserNotFound
-	}
-	if err != nil {
-		return model_billing.Billing{}, fmt.Errorf("getting user by id from repository: %w", err)
-	}
+	var result model_billing.Billing
+	err := b.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := b.userRepo.Get(ctx, userId)
+		if errors.Is(b.userRepo.GetNoDataError(), err) {
+			return billing_managing.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("getting user by id from repository: %w", err)
+		}
 
-	billing, err := model_billing.New(user.Id)
-	if err != nil {
-		return model_billing.Billing{}, fmt.Errorf("creating new billing from model: %w", err)
-	}
+		billing, err := model_billing.New(user.Id)
+		if err != nil {
+			return fmt.Errorf("creating new billing from model: %w", err)
+		}
+
+		if err = billing.SetRevisionLimit(b.cfg.DefaultRevisionLimit); err != nil {
+			return fmt.Errorf("set default revision limit: %w", err)
+		}
+
+		if b.cfg.DefaultDeadline != 0 {
+			billing.SetDeadline(billing.GetCreatedAt().Add(b.cfg.DefaultDeadline))
+		}
+		if err = billing.SetStageTargets(b.cfg.DefaultStageTargets); err != nil {
+			return fmt.Errorf("set default stage targets: %w", err)
+		}
 
-	billing, err = b.billingRepo.Create(ctx, billing)
+		result, err = b.billingRepo.Create(ctx, billing)
+		if err != nil {
+			return fmt.Errorf("creating new billing from repository: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return model_billing.Billing{}, fmt.Errorf("creating new billing from repository: %w", err)
+		return model_billing.Billing{}, err
 	}
 
-	return billing, nil
+	return result, nil
 }
 
-func (b billingManaging) GetAll(ctx context.Context) ([]model_billing.Billing, error) {
+func (b billingManaging) GetAll(ctx context.Context, filter billing_managing.Filter) ([]model_billing.Billing, error) {
 	billings, err := b.billingRepo.GetAll(ctx)
 	if err != nil {
 		return []model_billing.Billing{}, fmt.Errorf("getting all billings from repository: %w", err)
 	}
 
-	return billings, nil
+	now := time.Now()
+	var result []model_billing.Billing
+	for _, billing := range billings {
+		if filter.Overdue != nil && *filter.Overdue != billing.IsOverdue(now) {
+			continue
+		}
+		if filter.AssigneeId != "" {
+			staffId, ok := billing.GetCurrentAssignee()
+			if !ok || staffId != filter.AssigneeId {
+				continue
+			}
+		}
+		result = append(result, billing)
+	}
+
+	return result, nil
 }
 
 func (b billingManaging) GetAllByUserId(ctx context.Context, userId string) ([]model_billing.Billing, error) {
@@ -77,72 +138,323 @@ func (b billingManaging) GetById(ctx context.Context, id string) (model_billing.
 }
 
 func (b billingManaging) NextState(ctx context.Context, id string) (model_billing.Billing, error) {
-	billing, err := b.billingRepo.Get(ctx, id)
-	if errors.Is(b.billingRepo.GetNoDataError(), err) {
-		return model_billing.Billing{}, billing_managing.ErrBillingNotFound
-	}
+	return b.update(ctx, audit.ActionBillingNextState, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if err := billing.NextState(); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) PrevState(ctx context.Context, id string) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingPrevState, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if err := billing.PrevState(); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) SetBriefInfo(ctx context.Context, id string, username string) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingBriefInfo, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		_, err := billing.SetBriefInfo(username)
+		if err != nil {
+			return fmt.Errorf("set brief info: %w", err)
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) RequestRevision(ctx context.Context, id string, requestedBy string, note string) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingRevision, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if _, err := billing.RequestRevision(requestedBy, note); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) SetRevisionLimit(ctx context.Context, id string, limit model_billing.RevisionLimit) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingRevisionLimit, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if err := billing.SetRevisionLimit(limit); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) SetDeadlines(ctx context.Context, id string, deadline time.Time, stageTargets map[model_billing.State]time.Duration) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingDeadlines, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if err := billing.SetStageTargets(stageTargets); err != nil {
+			return err
+		}
+		billing.SetDeadline(deadline)
+		return nil
+	})
+}
+
+func (b billingManaging) SetPriority(ctx context.Context, id string, priority model_billing.Priority) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingPriority, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if err := billing.SetPriority(priority); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) GetQueue(ctx context.Context) ([]billing_managing.QueueGroup, error) {
+	billings, err := b.billingRepo.GetAll(ctx)
 	if err != nil {
-		return model_billing.Billing{}, fmt.Errorf("getting billing by id from repository: %w", err)
+		return []billing_managing.QueueGroup{}, fmt.Errorf("getting all billings from repository: %w", err)
 	}
 
-	if err = billing.NextState(); err != nil {
-		return model_billing.Billing{}, err
+	byState := map[model_billing.State][]model_billing.Billing{}
+	for _, billing := range billings {
+		if !billing.IsActionable() {
+			continue
+		}
+		byState[billing.GetState()] = append(byState[billing.GetState()], billing)
 	}
 
-	billing, err = b.billingRepo.Update(ctx, billing)
-	if err != nil {
-		return model_billing.Billing{}, fmt.Errorf("updating billing in repository: %w", err)
+	result := []billing_managing.QueueGroup{}
+	for _, state := range []model_billing.State{
+		model_billing.StatePending,
+		model_billing.StateDesign,
+		model_billing.StateLayout,
+	} {
+		group := byState[state]
+		sort.SliceStable(group, func(i, j int) bool {
+			if group[i].GetPriority().Rank() != group[j].GetPriority().Rank() {
+				return group[i].GetPriority().Rank() > group[j].GetPriority().Rank()
+			}
+			if !group[i].GetCreatedAt().Equal(group[j].GetCreatedAt()) {
+				return group[i].GetCreatedAt().Before(group[j].GetCreatedAt())
+			}
+			return group[i].Id < group[j].Id
+		})
+		result = append(result, billing_managing.QueueGroup{
+			State:    state,
+			Billings: group,
+		})
 	}
 
-	return billing, nil
+	return result, nil
 }
 
-func (b billingManaging) PrevState(ctx context.Context, id string) (model_billing.Billing, error) {
-	billing, err := b.billingRepo.Get(ctx, id)
-	if errors.Is(b.billingRepo.GetNoDataError(), err) {
-		return model_billing.Billing{}, billing_managing.ErrBillingNotFound
-	}
-	if err != nil {
-		return model_billing.Billing{}, fmt.Errorf("getting billing by id from repository: %w", err)
-	}
+func (b billingManaging) Assign(ctx context.Context, id string, stage model_billing.State, staffId string) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingAssign, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if staffId != "" {
+			if err := b.checkStaff(ctx, staffId); err != nil {
+				return err
+			}
+		}
+
+		if err := billing.Assign(stage, staffId); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) LogTime(
+	ctx context.Context,
+	id string,
+	staffId string,
+	stage model_billing.State,
+	startedAt time.Time,
+	endedAt time.Time,
+	note string,
+) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingLogTime, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if err := b.checkStaff(ctx, staffId); err != nil {
+			return err
+		}
+
+		if _, err := billing.LogTime(staffId, stage, startedAt, endedAt, note); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) StartTimer(ctx context.Context, id string, staffId string, note string) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingStartTimer, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if err := b.checkStaff(ctx, staffId); err != nil {
+			return err
+		}
+
+		if _, err := billing.StartTimer(staffId, note); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) StopTimer(ctx context.Context, id string, staffId string) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingStopTimer, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if _, err := billing.StopTimer(staffId); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) SetPrice(ctx context.Context, id string, price int64) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingPrice, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if err := billing.SetPrice(price); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) Invoice(ctx context.Context, id string) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingInvoice, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if err := billing.Invoice(); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) AddPayment(ctx context.Context, id string, amount int64, paidAt time.Time, note string) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingPayment, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		if _, err := billing.AddPayment(amount, paidAt, note); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (b billingManaging) Delete(ctx context.Context, id string, deletedBy string) (model_billing.Billing, error) {
+	return b.update(ctx, audit.ActionBillingDelete, id, func(ctx context.Context, billing *model_billing.Billing) error {
+		billing.MarkDeleted(deletedBy)
+		return nil
+	})
+}
+
+func (b billingManaging) Restore(ctx context.Context, id string) (model_billing.Billing, error) {
+	var result model_billing.Billing
+	err := b.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		billing, err := b.billingRepo.GetDeleted(ctx, id)
+		if errors.Is(b.billingRepo.GetNoDataError(), err) {
+			return billing_managing.ErrBillingNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("getting deleted billing by id from repository: %w", err)
+		}
 
-	if err = billing.PrevState(); err != nil {
+		_, err = b.userRepo.Get(ctx, billing.UserId)
+		if errors.Is(b.userRepo.GetNoDataError(), err) {
+			return billing_managing.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("getting user by id from repository: %w", err)
+		}
+
+		before := auditing.BillingFields(billing)
+		billing.Restore()
+		result, err = b.billingRepo.Update(ctx, billing)
+		if errors.Is(b.billingRepo.GetConflictError(), err) {
+			return billing_managing.ErrConcurrentUpdate
+		}
+		if err != nil {
+			return fmt.Errorf("updating billing in repository: %w", err)
+		}
+		return b.record(ctx, audit.ActionBillingRestore, result, before)
+	})
+	if err != nil {
 		return model_billing.Billing{}, err
 	}
 
-	billing, err = b.billingRepo.Update(ctx, billing)
+	return result, nil
+}
+
+func (b billingManaging) GetAllDeleted(ctx context.Context) ([]model_billing.Billing, error) {
+	billings, err := b.billingRepo.GetAllDeleted(ctx)
 	if err != nil {
-		return model_billing.Billing{}, fmt.Errorf("updating billing in repository: %w", err)
+		return []model_billing.Billing{}, fmt.Errorf("getting deleted billings from repository: %w", err)
 	}
 
-	return billing, nil
+	return billings, nil
 }
 
-func (b billingManaging) SetBriefInfo(ctx context.Context, id string, username string) (model_billing.Billing, error) {
-	billing, err := b.billingRepo.Get(ctx, id)
-	if errors.Is(b.billingRepo.GetNoDataError(), err) {
-		return model_billing.Billing{}, billing_managing.ErrBillingNotFound
-	}
+// update loads the billing, applies change to it and stores the result as one unit of work,
+// so concurrent changes of the same billing cannot overwrite each other. The change is
+// recorded in the audit log under action within the same unit of work.
+func (b billingManaging) update(
+	ctx context.Context,
+	action string,
+	id string,
+	change func(ctx context.Context, billing *model_billing.Billing) error,
+) (model_billing.Billing, error) {
+	var result model_billing.Billing
+	err := b.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		billing, err := b.billingRepo.Get(ctx, id)
+		if errors.Is(b.billingRepo.GetNoDataError(), err) {
+			return billing_managing.ErrBillingNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("getting billing by id from repository: %w", err)
+		}
+
+		before := auditing.BillingFields(billing)
+		if err = change(ctx, &billing); err != nil {
+			return err
+		}
+
+		result, err = b.billingRepo.Update(ctx, billing)
+		if errors.Is(b.billingRepo.GetConflictError(), err) {
+			return billing_managing.ErrConcurrentUpdate
+		}
+		if err != nil {
+			return fmt.Errorf("updating billing in repository: %w", err)
+		}
+		return b.record(ctx, action, result, before)
+	})
 	if err != nil {
-		return model_billing.Billing{}, fmt.Errorf("getting billing by id from repository: %w", err)
+		return model_billing.Billing{}, err
 	}
 
-	_, err = billing.SetBriefInfo(username)
-	if err != nil {
-		return model_billing.Billing{}, fmt.Errorf("set brief info: %w", err)
+	return result, nil
+}
+
+func (b billingManaging) record(ctx context.Context, action string, billing model_billing.Billing, before map[string]string) error {
+	target := audit.Target{Type: audit.TargetBilling, Id: billing.Id}
+	if err := b.auditing.Record(ctx, action, target, before, auditing.BillingFields(billing)); err != nil {
+		return fmt.Errorf("recording audit entry: %w", err)
 	}
+	return nil
+}
 
-	billing, err = b.billingRepo.Update(ctx, billing)
+func (b billingManaging) checkStaff(ctx context.Context, staffId string) error {
+	_, err := b.staffRepo.Get(ctx, staffId)
+	if errors.Is(b.staffRepo.GetNoDataError(), err) {
+		return billing_managing.ErrStaffNotFound
+	}
 	if err != nil {
-		return model_billing.Billing{}, fmt.Errorf("updating billing in repository: %w", err)
+		return fmt.Errorf("getting staff by id from repository: %w", err)
 	}
-
-	return billing, nil
+	return nil
 }
 
-func New(userRepo usecase.UserRepository, billingRepo usecase.BillingRepository) billingManaging {
+func New(
+	userRepo usecase.UserRepository,
+	billingRepo usecase.BillingRepository,
+	staffRepo usecase.StaffRepository,
+	transactor usecase.Transactor,
+	auditing auditing.Auditing,
+	cfg Config,
+) (billingManaging, error) {
+	if err := cfg.Validate(); err != nil {
+		return billingManaging{}, fmt.Errorf("config validate: %w", err)
+	}
 	return billingManaging{
 		userRepo:    userRepo,
 		billingRepo: billingRepo,
-	}
+		staffRepo:   staffRepo,
+		transactor:  transactor,
+		auditing:    auditing,
+		cfg:         cfg,
+	}, nil
 }
@@ -0,0 +1,48 @@
+package billing_managing_std
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+	"github.com/ThePositree/billing_manager/internal/usecase/billing_managing"
+	"github.com/ThePositree/billing_manager/internal/usecase/memory_repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAllOverdue(t *testing.T) {
+	ctx := context.Background()
+	billingRepo := &memory_repository.BillingRepository{}
+	createBilling := func(deadline time.Time) model_billing.Billing {
+		billing, err := model_billing.New(model_user.New("alice").Id)
+		require.NoError(t, err)
+		billing.SetDeadline(deadline)
+		billing, err = billingRepo.Create(ctx, billing)
+		require.NoError(t, err)
+		return billing
+	}
+	overdue := createBilling(time.Now().Add(-time.Hour))
+	onTime := createBilling(time.Now().Add(time.Hour))
+	noDeadline := createBilling(time.Time{})
+
+	b, err := New(&memory_repository.UserRepository{}, billingRepo, nil, memory_repository.Transactor{}, nil, Config{
+		DefaultRevisionLimit: model_billing.RevisionLimit{Policy: model_billing.RevisionPolicyUnlimited},
+	})
+	require.NoError(t, err)
+	ids := func(overdue *bool) []string {
+		billings, err := b.GetAll(ctx, billing_managing.Filter{Overdue: overdue})
+		require.NoError(t, err)
+		var ids []string
+		for _, billing := range billings {
+			ids = append(ids, billing.Id)
+		}
+		return ids
+	}
+	yes, no := true, false
+
+	require.ElementsMatch(t, []string{overdue.Id, onTime.Id, noDeadline.Id}, ids(nil))
+	require.ElementsMatch(t, []string{overdue.Id}, ids(&yes))
+	require.ElementsMatch(t, []string{onTime.Id, noDeadline.Id}, ids(&no))
+}
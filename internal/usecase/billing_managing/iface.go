@@ -3,6 +3,7 @@ package billing_managing
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/ThePositree/billing_manager/internal/model/billing"
 )
@@ -10,14 +11,49 @@ import (
 var (
 	ErrUserNotFound    = errors.New("user not found")
 	ErrBillingNotFound = errors.New("billing not found")
+	ErrStaffNotFound   = errors.New("staff not found")
+	// ErrConcurrentUpdate means the billing was changed by someone else
+	// meanwhile, the change can be retried on the latest version.
+	ErrConcurrentUpdate = errors.New("billing was changed concurrently, reload it and try again")
 )
 
+type Filter struct {
+	// Overdue keeps only billings that missed their deadline or current stage
+	// target when true and only the others when false, nil keeps both.
+	Overdue *bool
+	// AssigneeId keeps only billings whose current stage is assigned to the staff member.
+	AssigneeId string
+}
+
+// QueueGroup holds actionable billings in one state ordered by priority then age.
+type QueueGroup struct {
+	State    billing.State
+	Billings []billing.Billing
+}
+
 type BillingManaging interface {
 	GetAllByUserId(ctx context.Context, userId string) ([]billing.Billing, error)
 	GetById(ctx context.Context, id string) (billing.Billing, error)
-	GetAll(ctx context.Context) ([]billing.Billing, error)
+	GetAll(ctx context.Context, filter Filter) ([]billing.Billing, error)
 	Create(ctx context.Context, userId string) (billing.Billing, error)
 	NextState(ctx context.Context, id string) (billing.Billing, error)
 	PrevState(ctx context.Context, id string) (billing.Billing, error)
 	SetBriefInfo(ctx context.Context, id string, username string) (billing.Billing, error)
+	RequestRevision(ctx context.Context, id string, requestedBy string, note string) (billing.Billing, error)
+	SetRevisionLimit(ctx context.Context, id string, limit billing.RevisionLimit) (billing.Billing, error)
+	SetDeadlines(ctx context.Context, id string, deadline time.Time, stageTargets map[billing.State]time.Duration) (billing.Billing, error)
+	SetPriority(ctx context.Context, id string, priority billing.Priority) (billing.Billing, error)
+	GetQueue(ctx context.Context) ([]QueueGroup, error)
+	Assign(ctx context.Context, id string, stage billing.State, staffId string) (billing.Billing, error)
+	LogTime(ctx context.Context, id string, staffId string, stage billing.State, startedAt time.Time, endedAt time.Time, note string) (billing.Billing, error)
+	StartTimer(ctx context.Context, id string, staffId string, note string) (billing.Billing, error)
+	StopTimer(ctx context.Context, id string, staffId string) (billing.Billing, error)
+	SetPrice(ctx context.Context, id string, price int64) (billing.Billing, error)
+	Invoice(ctx context.Context, id string) (billing.Billing, error)
+	AddPayment(ctx context.Context, id string, amount int64, paidAt time.Time, note string) (billing.Billing, error)
+	// Delete soft deletes the billing, it can be restored until it is purged.
+	Delete(ctx context.Context, id string, deletedBy string) (billing.Billing, error)
+	// Restore fails with ErrUserNotFound while the user of the billing is deleted.
+	Restore(ctx context.Context, id string) (billing.Billing, error)
+	GetAllDeleted(ctx context.Context) ([]billing.Billing, error)
 }
@@ -0,0 +1,208 @@
+package data_transferring_std
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/data_transferring"
+)
+
+var _ data_transferring.DataTransferring = dataTransferring{}
+
+type dataTransferring struct {
+	userRepo    usecase.UserRepository
+	billingRepo usecase.BillingRepository
+}
+
+func (d dataTransferring) Export(ctx context.Context, writer data_transferring.RecordWriter) (data_transferring.ExportResult, error) {
+	result := data_transferring.ExportResult{}
+
+	users, err := d.userRepo.GetAll(ctx)
+	if err != nil {
+		return result, fmt.Errorf("getting all users from repository: %w", err)
+	}
+	for _, user := range users {
+		if err := writer.Write(data_transferring.Record{Kind: data_transferring.RecordUser, User: user}); err != nil {
+			return result, fmt.Errorf("writing user %s: %w", user.Id, err)
+		}
+		result.Users++
+	}
+
+	billings, err := d.billingRepo.GetAll(ctx)
+	if err != nil {
+		return result, fmt.Errorf("getting all billings from repository: %w", err)
+	}
+	for _, billing := range billings {
+		if err := writer.Write(data_transferring.Record{Kind: data_transferring.RecordBilling, Billing: billing}); err != nil {
+			return result, fmt.Errorf("writing billing %s: %w", billing.Id, err)
+		}
+		result.Billings++
+	}
+
+	return result, nil
+}
+
+// Import stops at the first invalid record or conflict under the fail
+// policy, records imported before it stay in the repositories.
+func (d dataTransferring) Import(ctx context.Context, reader data_transferring.RecordReader, options data_transferring.ImportOptions) (data_transferring.ImportResult, error) {
+	result := data_transferring.ImportResult{}
+	if _, err := data_transferring.ParseConflictPolicy(string(options.Conflict)); err != nil {
+		return result, err
+	}
+
+	// Users of the input that are stored under their id, billings may refer
+	// to them even on a dry run when nothing reaches the repository.
+	knownUsers := map[string]struct{}{}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return result, fmt.Errorf("reading record: %w", err)
+		}
+
+		switch record.Kind {
+		case data_transferring.RecordUser:
+			var stored bool
+			stored, err = d.importUser(ctx, record.User, options, &result.Users)
+			if stored {
+				knownUsers[record.User.Id] = struct{}{}
+			}
+		case data_transferring.RecordBilling:
+			err = d.importBilling(ctx, record.Billing, knownUsers, options, &result.Billings)
+		default:
+			err = data_transferring.ErrUnknownRecord
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+}
+
+// importUser reports whether the user is stored under its id after the
+// import, a user skipped for the telegram username of another one is not.
+func (d dataTransferring) importUser(ctx context.Context, user model_user.User, options data_transferring.ImportOptions, stats *data_transferring.ImportStats) (bool, error) {
+	_, err := d.userRepo.Get(ctx, user.Id)
+	// A soft deleted user with the same id is a conflict too, overwriting restores it.
+	if errors.Is(d.userRepo.GetNoDataError(), err) {
+		_, err = d.userRepo.GetDeleted(ctx, user.Id)
+	}
+	if err != nil && !errors.Is(d.userRepo.GetNoDataError(), err) {
+		return false, fmt.Errorf("getting user by id from repository: %w", err)
+	}
+	exists := err == nil
+
+	if !exists {
+		// Telegram usernames identify clients, another user with the same
+		// username is a conflict that cannot be overwritten by id.
+		other, err := d.userRepo.GetByTelegramUN(ctx, user.TelegramUN)
+		if err != nil && !errors.Is(d.userRepo.GetNoDataError(), err) {
+			return false, fmt.Errorf("getting user by telegram username from repository: %w", err)
+		}
+		if err == nil {
+			if options.Conflict == data_transferring.ConflictSkip {
+				stats.Skipped++
+				return false, nil
+			}
+			return false, data_transferring.ErrConflict{Kind: data_transferring.RecordUser, Id: other.Id}
+		}
+
+		if !options.DryRun {
+			_, err := d.userRepo.Create(ctx, user)
+			// Someone registered the same user between the lookups and the insert.
+			if errors.Is(d.userRepo.GetAlreadyExistsError(), err) {
+				if options.Conflict == data_transferring.ConflictSkip {
+					stats.Skipped++
+					return false, nil
+				}
+				return false, data_transferring.ErrConflict{Kind: data_transferring.RecordUser, Id: user.Id}
+			}
+			if err != nil {
+				return false, fmt.Errorf("creating user in repository: %w", err)
+			}
+		}
+		stats.Created++
+		return true, nil
+	}
+
+	switch options.Conflict {
+	case data_transferring.ConflictSkip:
+		stats.Skipped++
+		return true, nil
+	case data_transferring.ConflictFail:
+		return false, data_transferring.ErrConflict{Kind: data_transferring.RecordUser, Id: user.Id}
+	}
+
+	if !options.DryRun {
+		_, err := d.userRepo.Update(ctx, user)
+		if errors.Is(d.userRepo.GetAlreadyExistsError(), err) {
+			return false, data_transferring.ErrConflict{Kind: data_transferring.RecordUser, Id: user.Id}
+		}
+		if err != nil {
+			return false, fmt.Errorf("updating user in repository: %w", err)
+		}
+	}
+	stats.Overwritten++
+	return true, nil
+}
+
+func (d dataTransferring) importBilling(ctx context.Context, billing model_billing.Billing, knownUsers map[string]struct{}, options data_transferring.ImportOptions, stats *data_transferring.ImportStats) error {
+	if _, ok := knownUsers[billing.UserId]; !ok {
+		_, err := d.userRepo.Get(ctx, billing.UserId)
+		if errors.Is(d.userRepo.GetNoDataError(), err) {
+			return data_transferring.ErrMissingUser{BillingId: billing.Id, UserId: billing.UserId}
+		}
+		if err != nil {
+			return fmt.Errorf("getting user by id from repository: %w", err)
+		}
+		knownUsers[billing.UserId] = struct{}{}
+	}
+
+	_, err := d.billingRepo.Get(ctx, billing.Id)
+	if errors.Is(d.billingRepo.GetNoDataError(), err) {
+		_, err = d.billingRepo.GetDeleted(ctx, billing.Id)
+	}
+	if err != nil && !errors.Is(d.billingRepo.GetNoDataError(), err) {
+		return fmt.Errorf("getting billing by id from repository: %w", err)
+	}
+
+	if err != nil {
+		if !options.DryRun {
+			if _, err := d.billingRepo.Create(ctx, billing); err != nil {
+				return fmt.Errorf("creating billing in repository: %w", err)
+			}
+		}
+		stats.Created++
+		return nil
+	}
+
+	switch options.Conflict {
+	case data_transferring.ConflictSkip:
+		stats.Skipped++
+		return nil
+	case data_transferring.ConflictFail:
+		return data_transferring.ErrConflict{Kind: data_transferring.RecordBilling, Id: billing.Id}
+	}
+
+	if !options.DryRun {
+		if _, err := d.billingRepo.Update(ctx, billing); err != nil {
+			return fmt.Errorf("updating billing in repository: %w", err)
+		}
+	}
+	stats.Overwritten++
+	return nil
+}
+
+func New(userRepo usecase.UserRepository, billingRepo usecase.BillingRepository) dataTransferring {
+	return dataTransferring{
+		userRepo:    userRepo,
+		billingRepo: billingRepo,
+	}
+}
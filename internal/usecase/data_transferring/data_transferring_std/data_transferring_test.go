@@ -0,0 +1,257 @@
+package data_transferring_std
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+	"github.com/ThePositree/billing_manager/internal/usecase/data_transferring"
+	"github.com/ThePositree/billing_manager/internal/usecase/memory_repository"
+	"github.com/stretchr/testify/require"
+)
+
+type records []data_transferring.Record
+
+func (r *records) Read() (data_transferring.Record, error) {
+	if len(*r) == 0 {
+		return data_transferring.Record{}, io.EOF
+	}
+	record := (*r)[0]
+	*r = (*r)[1:]
+	return record, nil
+}
+
+func (r *records) Write(record data_transferring.Record) error {
+	*r = append(*r, record)
+	return nil
+}
+
+func userRecord(user model_user.User) data_transferring.Record {
+	return data_transferring.Record{Kind: data_transferring.RecordUser, User: user}
+}
+
+func billingRecord(billing model_billing.Billing) data_transferring.Record {
+	return data_transferring.Record{Kind: data_transferring.RecordBilling, Billing: billing}
+}
+
+func newBilling(t *testing.T, userId string) model_billing.Billing {
+	t.Helper()
+	billing, err := model_billing.New(userId)
+	require.NoError(t, err)
+	return billing
+}
+
+type fixture struct {
+	userRepo    *memory_repository.UserRepository
+	billingRepo *memory_repository.BillingRepository
+	transfer    dataTransferring
+	// alice and her billing are stored before every import.
+	alice        model_user.User
+	aliceBilling model_billing.Billing
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	f := fixture{
+		userRepo:    &memory_repository.UserRepository{},
+		billingRepo: &memory_repository.BillingRepository{},
+		alice:       model_user.New("alice"),
+	}
+	f.transfer = New(f.userRepo, f.billingRepo)
+	f.aliceBilling = newBilling(t, f.alice.Id)
+
+	ctx := context.Background()
+	_, err := f.userRepo.Create(ctx, f.alice)
+	require.NoError(t, err)
+	_, err = f.billingRepo.Create(ctx, f.aliceBilling)
+	require.NoError(t, err)
+	return f
+}
+
+func (f fixture) importRecords(options data_transferring.ImportOptions, input ...data_transferring.Record) (data_transferring.ImportResult, error) {
+	reader := records(input)
+	return f.transfer.Import(context.Background(), &reader, options)
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	source := newFixture(t)
+	bob := model_user.New("bob")
+	_, err := source.userRepo.Create(ctx, bob)
+	require.NoError(t, err)
+
+	var exported records
+	exportResult, err := source.transfer.Export(ctx, &exported)
+	require.NoError(t, err)
+	require.Equal(t, data_transferring.ExportResult{Users: 2, Billings: 1}, exportResult)
+	require.Equal(t, data_transferring.RecordUser, exported[0].Kind)
+	require.Equal(t, data_transferring.RecordBilling, exported[2].Kind)
+
+	target := fixture{
+		userRepo:    &memory_repository.UserRepository{},
+		billingRepo: &memory_repository.BillingRepository{},
+	}
+	target.transfer = New(target.userRepo, target.billingRepo)
+	result, err := target.importRecords(data_transferring.ImportOptions{Conflict: data_transferring.ConflictFail}, exported...)
+	require.NoError(t, err)
+	require.Equal(t, data_transferring.ImportResult{
+		Users:    data_transferring.ImportStats{Created: 2},
+		Billings: data_transferring.ImportStats{Created: 1},
+	}, result)
+
+	billing, err := target.billingRepo.Get(ctx, source.aliceBilling.Id)
+	require.NoError(t, err)
+	require.Equal(t, source.alice.Id, billing.UserId)
+	_, err = target.userRepo.Get(ctx, bob.Id)
+	require.NoError(t, err)
+}
+
+func TestImportSkip(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	renamed := f.alice
+	renamed.TelegramUN = "alice_renamed"
+	// Another user with the telegram username of alice cannot be stored.
+	impostor := model_user.New("alice")
+	carol := model_user.New("carol")
+
+	result, err := f.importRecords(data_transferring.ImportOptions{Conflict: data_transferring.ConflictSkip},
+		userRecord(renamed),
+		userRecord(impostor),
+		userRecord(carol),
+		billingRecord(f.aliceBilling),
+		billingRecord(newBilling(t, f.alice.Id)),
+		billingRecord(newBilling(t, carol.Id)),
+	)
+	require.NoError(t, err)
+	require.Equal(t, data_transferring.ImportResult{
+		Users:    data_transferring.ImportStats{Created: 1, Skipped: 2},
+		Billings: data_transferring.ImportStats{Created: 2, Skipped: 1},
+	}, result)
+
+	stored, err := f.userRepo.Get(ctx, f.alice.Id)
+	require.NoError(t, err)
+	require.Equal(t, "alice", stored.TelegramUN)
+	_, err = f.userRepo.Get(ctx, impostor.Id)
+	require.ErrorIs(t, err, memory_repository.ErrNoData)
+}
+
+func TestImportSkippedUserBilling(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	impostor := model_user.New("alice")
+	billing := newBilling(t, impostor.Id)
+
+	for _, dryRun := range []bool{false, true} {
+		_, err := f.importRecords(data_transferring.ImportOptions{Conflict: data_transferring.ConflictSkip, DryRun: dryRun},
+			userRecord(impostor),
+			billingRecord(billing),
+		)
+		// The billing would point at a user that was never stored.
+		require.Equal(t, data_transferring.ErrMissingUser{BillingId: billing.Id, UserId: impostor.Id}, err)
+		_, err = f.billingRepo.Get(ctx, billing.Id)
+		require.ErrorIs(t, err, memory_repository.ErrNoData)
+	}
+}
+
+func TestImportOverwrite(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	deleted := model_user.New("dave")
+	deleted.MarkDeleted("admin")
+	_, err := f.userRepo.Create(ctx, deleted)
+	require.NoError(t, err)
+
+	renamed := f.alice
+	renamed.TelegramUN = "alice_renamed"
+	restored := deleted
+	restored.Restore()
+	billing := f.aliceBilling
+	require.NoError(t, billing.SetPrice(50000))
+
+	result, err := f.importRecords(data_transferring.ImportOptions{Conflict: data_transferring.ConflictOverwrite},
+		userRecord(renamed),
+		userRecord(restored),
+		billingRecord(billing),
+	)
+	require.NoError(t, err)
+	require.Equal(t, data_transferring.ImportResult{
+		Users:    data_transferring.ImportStats{Overwritten: 2},
+		Billings: data_transferring.ImportStats{Overwritten: 1},
+	}, result)
+
+	stored, err := f.userRepo.Get(ctx, f.alice.Id)
+	require.NoError(t, err)
+	require.Equal(t, "alice_renamed", stored.TelegramUN)
+	_, err = f.userRepo.Get(ctx, deleted.Id)
+	require.NoError(t, err)
+	storedBilling, err := f.billingRepo.Get(ctx, billing.Id)
+	require.NoError(t, err)
+	require.Equal(t, int64(50000), storedBilling.GetPrice())
+
+	// A username taken by another user is still a conflict.
+	impostor := model_user.New("alice_renamed")
+	_, err = f.importRecords(data_transferring.ImportOptions{Conflict: data_transferring.ConflictOverwrite}, userRecord(impostor))
+	require.Equal(t, data_transferring.ErrConflict{Kind: data_transferring.RecordUser, Id: f.alice.Id}, err)
+}
+
+func TestImportFail(t *testing.T) {
+	f := newFixture(t)
+	options := data_transferring.ImportOptions{Conflict: data_transferring.ConflictFail}
+
+	carol := model_user.New("carol")
+	result, err := f.importRecords(options, userRecord(carol), userRecord(f.alice))
+	require.Equal(t, data_transferring.ErrConflict{Kind: data_transferring.RecordUser, Id: f.alice.Id}, err)
+	// Records before the conflict stay imported.
+	require.Equal(t, data_transferring.ImportStats{Created: 1}, result.Users)
+	_, err = f.userRepo.Get(context.Background(), carol.Id)
+	require.NoError(t, err)
+
+	_, err = f.importRecords(options, userRecord(model_user.New("alice")))
+	require.Equal(t, data_transferring.ErrConflict{Kind: data_transferring.RecordUser, Id: f.alice.Id}, err)
+
+	_, err = f.importRecords(options, billingRecord(f.aliceBilling))
+	require.Equal(t, data_transferring.ErrConflict{Kind: data_transferring.RecordBilling, Id: f.aliceBilling.Id}, err)
+
+	unknown := newBilling(t, model_user.New("nobody").Id)
+	_, err = f.importRecords(options, billingRecord(unknown))
+	require.Equal(t, data_transferring.ErrMissingUser{BillingId: unknown.Id, UserId: unknown.UserId}, err)
+
+	_, err = f.importRecords(options, data_transferring.Record{Kind: "staff"})
+	require.ErrorIs(t, err, data_transferring.ErrUnknownRecord)
+
+	_, err = f.importRecords(data_transferring.ImportOptions{Conflict: "replace"})
+	require.ErrorIs(t, err, data_transferring.ErrInvalidConflictPolicy)
+}
+
+func TestImportDryRun(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	renamed := f.alice
+	renamed.TelegramUN = "alice_renamed"
+	carol := model_user.New("carol")
+	carolBilling := newBilling(t, carol.Id)
+
+	result, err := f.importRecords(data_transferring.ImportOptions{Conflict: data_transferring.ConflictOverwrite, DryRun: true},
+		userRecord(renamed),
+		userRecord(carol),
+		billingRecord(f.aliceBilling),
+		// Billings may refer to users imported before them.
+		billingRecord(carolBilling),
+	)
+	require.NoError(t, err)
+	require.Equal(t, data_transferring.ImportResult{
+		Users:    data_transferring.ImportStats{Created: 1, Overwritten: 1},
+		Billings: data_transferring.ImportStats{Created: 1, Overwritten: 1},
+	}, result)
+
+	stored, err := f.userRepo.Get(ctx, f.alice.Id)
+	require.NoError(t, err)
+	require.Equal(t, "alice", stored.TelegramUN)
+	_, err = f.userRepo.Get(ctx, carol.Id)
+	require.ErrorIs(t, err, memory_repository.ErrNoData)
+	_, err = f.billingRepo.Get(ctx, carolBilling.Id)
+	require.ErrorIs(t, err, memory_repository.ErrNoData)
+}
@@ -0,0 +1,104 @@
+package data_transferring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/model/user"
+)
+
+var (
+	ErrInvalidConflictPolicy = errors.New("conflict policy must be one of skip, overwrite, fail")
+	ErrUnknownRecord         = errors.New("record is neither a user nor a billing")
+)
+
+// ErrConflict is returned when an imported record collides with stored data
+// and the conflict policy is fail.
+type ErrConflict struct {
+	Kind RecordKind
+	Id   string
+}
+
+func (e ErrConflict) Error() string {
+	return fmt.Sprintf("%s %s already exists", e.Kind, e.Id)
+}
+
+// ErrMissingUser is returned when an imported billing belongs to a user
+// that is neither stored nor imported before it.
+type ErrMissingUser struct {
+	BillingId string
+	UserId    string
+}
+
+func (e ErrMissingUser) Error() string {
+	return fmt.Sprintf("billing %s belongs to unknown user %s", e.BillingId, e.UserId)
+}
+
+type ConflictPolicy string
+
+const (
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictFail      ConflictPolicy = "fail"
+)
+
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(value); policy {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+		return policy, nil
+	}
+	return "", ErrInvalidConflictPolicy
+}
+
+type RecordKind string
+
+const (
+	RecordUser    RecordKind = "user"
+	RecordBilling RecordKind = "billing"
+)
+
+// Record is one exported entity, only the field matching Kind is set.
+type Record struct {
+	Kind    RecordKind
+	User    user.User
+	Billing model_billing.Billing
+}
+
+type RecordWriter interface {
+	Write(record Record) error
+}
+
+// RecordReader returns io.EOF after the last record.
+type RecordReader interface {
+	Read() (Record, error)
+}
+
+type ImportOptions struct {
+	DryRun   bool
+	Conflict ConflictPolicy
+}
+
+type ImportStats struct {
+	Created     int
+	Overwritten int
+	Skipped     int
+}
+
+type ImportResult struct {
+	Users    ImportStats
+	Billings ImportStats
+}
+
+type ExportResult struct {
+	Users    int
+	Billings int
+}
+
+type DataTransferring interface {
+	// Export writes all users before all billings so that the output
+	// can be imported in a single pass.
+	Export(ctx context.Context, writer RecordWriter) (ExportResult, error)
+	Import(ctx context.Context, reader RecordReader, options ImportOptions) (ImportResult, error)
+}
@@ -0,0 +1,124 @@
+package deadline_checking_std
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/deadline_checking"
+	"github.com/rs/zerolog"
+)
+
+var _ deadline_checking.DeadlineChecking = &deadlineChecking{}
+
+type Config struct {
+	Interval time.Duration
+	// Warning is how long before the due time an approaching event is emitted.
+	Warning time.Duration
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if cfg.Warning < 0 {
+		return fmt.Errorf("warning cannot be negative")
+	}
+	return nil
+}
+
+type notifiedEvent struct {
+	status model_billing.DeadlineStatus
+	dueAt  time.Time
+}
+
+type deadlineChecking struct {
+	logger      zerolog.Logger
+	billingRepo usecase.BillingRepository
+	notifier    deadline_checking.Notifier
+	cfg         Config
+	mutex       sync.Mutex
+	notified    map[string]notifiedEvent
+}
+
+// Check emits an event for every billing whose deadline status became
+// approaching or overdue since the previous check.
+func (d *deadlineChecking) Check(ctx context.Context, at time.Time) ([]deadline_checking.Event, error) {
+	billings, err := d.billingRepo.GetAll(ctx)
+	if err != nil {
+		return []deadline_checking.Event{}, fmt.Errorf("getting all billings from repository: %w", err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var events []deadline_checking.Event
+	seen := map[string]struct{}{}
+	for _, billing := range billings {
+		seen[billing.Id] = struct{}{}
+
+		status := billing.GetDeadlineStatus(at, d.cfg.Warning)
+		if status == model_billing.DeadlineStatusOnTrack {
+			delete(d.notified, billing.Id)
+			continue
+		}
+
+		dueAt, _ := billing.NextDueAt()
+		current := notifiedEvent{status: status, dueAt: dueAt}
+		if previous, ok := d.notified[billing.Id]; ok && previous == current {
+			continue
+		}
+
+		event := deadline_checking.Event{
+			BillingId: billing.Id,
+			UserId:    billing.UserId,
+			State:     billing.GetState(),
+			Status:    status,
+			DueAt:     dueAt,
+		}
+		if err := d.notifier.Notify(ctx, event); err != nil {
+			return events, fmt.Errorf("notify about billing %s: %w", billing.Id, err)
+		}
+		d.notified[billing.Id] = current
+		events = append(events, event)
+	}
+
+	for billingId := range d.notified {
+		if _, ok := seen[billingId]; !ok {
+			delete(d.notified, billingId)
+		}
+	}
+
+	return events, nil
+}
+
+func (d *deadlineChecking) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.Check(ctx, time.Now()); err != nil {
+			d.logger.Error().Err(err).Msg("Deadline check")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func New(logger zerolog.Logger, billingRepo usecase.BillingRepository, notifier deadline_checking.Notifier, cfg Config) (*deadlineChecking, error) {
+	if err := cfg.Validate(); err != nil {
+		return &deadlineChecking{}, fmt.Errorf("config validate: %w", err)
+	}
+	return &deadlineChecking{
+		logger:      logger,
+		billingRepo: billingRepo,
+		notifier:    notifier,
+		cfg:         cfg,
+		notified:    map[string]notifiedEvent{},
+	}, nil
+}
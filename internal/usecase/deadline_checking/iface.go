@@ -0,0 +1,25 @@
+package deadline_checking
+
+import (
+	"context"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/billing"
+)
+
+type Event struct {
+	BillingId string
+	UserId    string
+	State     billing.State
+	Status    billing.DeadlineStatus
+	DueAt     time.Time
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+type DeadlineChecking interface {
+	Check(ctx context.Context, at time.Time) ([]Event, error)
+	Run(ctx context.Context)
+}
@@ -2,26 +2,71 @@ package usecase
 
 import (
 	"context"
+	"time"
 
+	"github.com/ThePositree/billing_manager/internal/model/audit"
 	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_staff "github.com/ThePositree/billing_manager/internal/model/staff"
 	"github.com/ThePositree/billing_manager/internal/model/user"
 )
 
+// UserRepository skips soft deleted users in every read except GetDeleted
+// and GetAllDeleted.
 type UserRepository interface {
 	GetAll(ctx context.Context) ([]user.User, error)
 	GetByTelegramUN(ctx context.Context, telegramUN string) (user.User, error)
 	Get(ctx context.Context, id string) (user.User, error)
+	GetDeleted(ctx context.Context, id string) (user.User, error)
+	GetAllDeleted(ctx context.Context) ([]user.User, error)
 	Create(ctx context.Context, user user.User) (user.User, error)
-	Delete(ctx context.Context, id string) (user.User, error)
+	// Update stores deleted users as well, soft deletion and restoring are updates.
+	Update(ctx context.Context, user user.User) (user.User, error)
+	// Delete removes the user for good when it was soft deleted before
+	// deletedBefore, a live or more recently deleted user is no data.
+	Delete(ctx context.Context, id string, deletedBefore time.Time) (user.User, error)
 	GetNoDataError() error
+	// GetAlreadyExistsError is returned by Create and Update for a duplicate id or telegram username.
+	GetAlreadyExistsError() error
 }
 
+// BillingRepository skips soft deleted billings in every read except
+// GetDeleted and GetAllDeleted.
 type BillingRepository interface {
 	GetAll(ctx context.Context) ([]model_billing.Billing, error)
 	Get(ctx context.Context, id string) (model_billing.Billing, error)
 	GetByUserId(ctx context.Context, userId string) ([]model_billing.Billing, error)
+	GetDeleted(ctx context.Context, id string) (model_billing.Billing, error)
+	GetAllDeleted(ctx context.Context) ([]model_billing.Billing, error)
 	Create(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error)
+	// Update stores deleted billings as well, soft deletion and restoring are updates.
 	Update(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error)
-	Delete(ctx context.Context, id string) (model_billing.Billing, error)
+	// Delete removes the billing for good when it was soft deleted before
+	// deletedBefore, a live or more recently deleted billing is no data.
+	Delete(ctx context.Context, id string, deletedBefore time.Time) (model_billing.Billing, error)
 	GetNoDataError() error
+	// GetConflictError is returned by Update when the billing was changed after
+	// it was loaded, storages that overwrite the stored billing never return it.
+	GetConflictError() error
+}
+
+type StaffRepository interface {
+	GetAll(ctx context.Context) ([]model_staff.Staff, error)
+	Get(ctx context.Context, id string) (model_staff.Staff, error)
+	Create(ctx context.Context, staff model_staff.Staff) (model_staff.Staff, error)
+	Delete(ctx context.Context, id string) (model_staff.Staff, error)
+	GetNoDataError() error
+}
+
+// AuditRepository is append-only, entries are never updated or deleted.
+type AuditRepository interface {
+	Append(ctx context.Context, entry audit.Entry) (audit.Entry, error)
+	Find(ctx context.Context, filter audit.Filter) ([]audit.Entry, error)
+}
+
+// Transactor runs fn as a unit of work: repository calls made with the ctx passed to fn
+// are committed together, or rolled back when fn returns an error. fn may be run again
+// when the storage reports a transient conflict, so it must not have other side effects.
+// Nested calls join the outer unit of work.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
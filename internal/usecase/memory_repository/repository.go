@@ -0,0 +1,241 @@
+// Package memory_repository keeps users and billings in maps, the usecase
+// tests run against it instead of a database.
+package memory_repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+)
+
+var (
+	_ usecase.UserRepository    = &UserRepository{}
+	_ usecase.BillingRepository = &BillingRepository{}
+	_ usecase.Transactor        = Transactor{}
+)
+
+var (
+	ErrNoData        = errors.New("no data")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrConflict      = errors.New("conflict")
+)
+
+type UserRepository struct {
+	mu    sync.Mutex
+	users map[string]model_user.User
+}
+
+func (u *UserRepository) GetNoDataError() error {
+	return ErrNoData
+}
+
+func (u *UserRepository) GetAlreadyExistsError() error {
+	return ErrAlreadyExists
+}
+
+func (u *UserRepository) GetAll(ctx context.Context) ([]model_user.User, error) {
+	return u.filter(func(user model_user.User) bool { return !user.IsDeleted() }), nil
+}
+
+func (u *UserRepository) GetAllDeleted(ctx context.Context) ([]model_user.User, error) {
+	return u.filter(func(user model_user.User) bool { return user.IsDeleted() }), nil
+}
+
+func (u *UserRepository) GetByTelegramUN(ctx context.Context, telegramUN string) (model_user.User, error) {
+	users := u.filter(func(user model_user.User) bool { return !user.IsDeleted() && user.TelegramUN == telegramUN })
+	if len(users) == 0 {
+		return model_user.User{}, ErrNoData
+	}
+	return users[0], nil
+}
+
+func (u *UserRepository) Get(ctx context.Context, id string) (model_user.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	user, ok := u.users[id]
+	if !ok || user.IsDeleted() {
+		return model_user.User{}, ErrNoData
+	}
+	return user, nil
+}
+
+func (u *UserRepository) GetDeleted(ctx context.Context, id string) (model_user.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	user, ok := u.users[id]
+	if !ok || !user.IsDeleted() {
+		return model_user.User{}, ErrNoData
+	}
+	return user, nil
+}
+
+func (u *UserRepository) Create(ctx context.Context, user model_user.User) (model_user.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.users[user.Id]; ok || u.hasTelegramUN(user) {
+		return model_user.User{}, ErrAlreadyExists
+	}
+	if u.users == nil {
+		u.users = map[string]model_user.User{}
+	}
+	u.users[user.Id] = user
+	return user, nil
+}
+
+func (u *UserRepository) Update(ctx context.Context, user model_user.User) (model_user.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.users[user.Id]; !ok {
+		return model_user.User{}, ErrNoData
+	}
+	if u.hasTelegramUN(user) {
+		return model_user.User{}, ErrAlreadyExists
+	}
+	u.users[user.Id] = user
+	return user, nil
+}
+
+func (u *UserRepository) Delete(ctx context.Context, id string, deletedBefore time.Time) (model_user.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	user, ok := u.users[id]
+	if !ok || !user.IsDeleted() || !user.GetDeletedAt().Before(deletedBefore) {
+		return model_user.User{}, ErrNoData
+	}
+	delete(u.users, id)
+	return user, nil
+}
+
+// hasTelegramUN reports whether another live user has the telegram
+// username of the live user, deleted users do not keep their username.
+func (u *UserRepository) hasTelegramUN(user model_user.User) bool {
+	if user.IsDeleted() {
+		return false
+	}
+	for _, other := range u.users {
+		if other.Id != user.Id && !other.IsDeleted() && other.TelegramUN == user.TelegramUN {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *UserRepository) filter(keep func(user model_user.User) bool) []model_user.User {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var users []model_user.User
+	for _, user := range u.users {
+		if keep(user) {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b model_user.User) int { return strings.Compare(a.Id, b.Id) })
+	return users
+}
+
+type BillingRepository struct {
+	mu       sync.Mutex
+	billings map[string]model_billing.Billing
+}
+
+func (b *BillingRepository) GetNoDataError() error {
+	return ErrNoData
+}
+
+func (b *BillingRepository) GetConflictError() error {
+	return ErrConflict
+}
+
+func (b *BillingRepository) GetAll(ctx context.Context) ([]model_billing.Billing, error) {
+	return b.filter(func(billing model_billing.Billing) bool { return !billing.IsDeleted() }), nil
+}
+
+func (b *BillingRepository) GetAllDeleted(ctx context.Context) ([]model_billing.Billing, error) {
+	return b.filter(func(billing model_billing.Billing) bool { return billing.IsDeleted() }), nil
+}
+
+func (b *BillingRepository) GetByUserId(ctx context.Context, userId string) ([]model_billing.Billing, error) {
+	return b.filter(func(billing model_billing.Billing) bool { return !billing.IsDeleted() && billing.UserId == userId }), nil
+}
+
+func (b *BillingRepository) Get(ctx context.Context, id string) (model_billing.Billing, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	billing, ok := b.billings[id]
+	if !ok || billing.IsDeleted() {
+		return model_billing.Billing{}, ErrNoData
+	}
+	return billing, nil
+}
+
+func (b *BillingRepository) GetDeleted(ctx context.Context, id string) (model_billing.Billing, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	billing, ok := b.billings[id]
+	if !ok || !billing.IsDeleted() {
+		return model_billing.Billing{}, ErrNoData
+	}
+	return billing, nil
+}
+
+func (b *BillingRepository) Create(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.billings[billing.Id]; ok {
+		return model_billing.Billing{}, ErrAlreadyExists
+	}
+	if b.billings == nil {
+		b.billings = map[string]model_billing.Billing{}
+	}
+	b.billings[billing.Id] = billing
+	return billing, nil
+}
+
+func (b *BillingRepository) Update(ctx context.Context, billing model_billing.Billing) (model_billing.Billing, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.billings[billing.Id]; !ok {
+		return model_billing.Billing{}, ErrNoData
+	}
+	b.billings[billing.Id] = billing
+	return billing, nil
+}
+
+func (b *BillingRepository) Delete(ctx context.Context, id string, deletedBefore time.Time) (model_billing.Billing, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	billing, ok := b.billings[id]
+	if !ok || !billing.IsDeleted() || !billing.GetDeletedAt().Before(deletedBefore) {
+		return model_billing.Billing{}, ErrNoData
+	}
+	delete(b.billings, id)
+	return billing, nil
+}
+
+func (b *BillingRepository) filter(keep func(billing model_billing.Billing) bool) []model_billing.Billing {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var billings []model_billing.Billing
+	for _, billing := range b.billings {
+		if keep(billing) {
+			billings = append(billings, billing)
+		}
+	}
+	slices.SortFunc(billings, func(a, b model_billing.Billing) int { return strings.Compare(a.Id, b.Id) })
+	return billings
+}
+
+// Transactor runs the unit of work without isolation or rollback, like
+// a storage without transactions.
+type Transactor struct{}
+
+func (t Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,32 @@
+package personal_data
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/model/user"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// Export is everything stored about a user, soft deleted records included.
+type Export struct {
+	User       user.User
+	Billings   []model_billing.Billing
+	ExportedAt time.Time
+}
+
+type Archiver interface {
+	Write(out io.Writer, export Export) error
+}
+
+type PersonalData interface {
+	// Export writes an archive of the user and its billings to out.
+	Export(ctx context.Context, userId string, out io.Writer) error
+	// Erase anonymises the user and the personal fields of its billings,
+	// the billings themselves are kept as financial records.
+	Erase(ctx context.Context, userId string) (user.User, error)
+}
@@ -0,0 +1,148 @@
+package personal_data_std
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
+	"github.com/ThePositree/billing_manager/internal/usecase/personal_data"
+)
+
+var _ personal_data.PersonalData = personalData{}
+
+type personalData struct {
+	userRepo    usecase.UserRepository
+	billingRepo usecase.BillingRepository
+	transactor  usecase.Transactor
+	archiver    personal_data.Archiver
+	auditing    auditing.Auditing
+}
+
+func (p personalData) Export(ctx context.Context, userId string, out io.Writer) error {
+	user, err := p.getUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	billings, err := p.getBillings(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	export := personal_data.Export{
+		User:       user,
+		Billings:   billings,
+		ExportedAt: time.Now(),
+	}
+	if err := p.archiver.Write(out, export); err != nil {
+		return fmt.Errorf("writing personal data archive: %w", err)
+	}
+	return nil
+}
+
+func (p personalData) Erase(ctx context.Context, userId string) (model_user.User, error) {
+	var result model_user.User
+	err := p.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := p.getUser(ctx, userId)
+		if err != nil {
+			return err
+		}
+		billings, err := p.getBillings(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		for _, listed := range billings {
+			// Lists may come from a read model, the billing is loaded
+			// again to change its latest version.
+			get := p.billingRepo.Get
+			if listed.IsDeleted() {
+				get = p.billingRepo.GetDeleted
+			}
+			billing, err := get(ctx, listed.Id)
+			if err != nil {
+				return fmt.Errorf("getting billing %s from repository: %w", listed.Id, err)
+			}
+
+			billing.Anonymize()
+			if _, err := p.billingRepo.Update(ctx, billing); err != nil {
+				return fmt.Errorf("updating billing %s in repository: %w", billing.Id, err)
+			}
+		}
+
+		before := auditing.UserFields(user)
+		user.Anonymize()
+		result, err = p.userRepo.Update(ctx, user)
+		if err != nil {
+			return fmt.Errorf("updating user in repository: %w", err)
+		}
+
+		after := auditing.UserFields(result)
+		after["anonymized_billings"] = strconv.Itoa(len(billings))
+		target := audit.Target{Type: audit.TargetUser, Id: result.Id}
+		if err := p.auditing.Record(ctx, audit.ActionUserErase, target, before, after); err != nil {
+			return fmt.Errorf("recording audit entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return model_user.User{}, err
+	}
+	return result, nil
+}
+
+// getUser finds the user whether it is soft deleted or not.
+func (p personalData) getUser(ctx context.Context, userId string) (model_user.User, error) {
+	user, err := p.userRepo.Get(ctx, userId)
+	if errors.Is(p.userRepo.GetNoDataError(), err) {
+		user, err = p.userRepo.GetDeleted(ctx, userId)
+	}
+	if errors.Is(p.userRepo.GetNoDataError(), err) {
+		return model_user.User{}, personal_data.ErrUserNotFound
+	}
+	if err != nil {
+		return model_user.User{}, fmt.Errorf("getting user by id from repository: %w", err)
+	}
+	return user, nil
+}
+
+// getBillings returns the live and the soft deleted billings of the user.
+func (p personalData) getBillings(ctx context.Context, userId string) ([]model_billing.Billing, error) {
+	billings, err := p.billingRepo.GetByUserId(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("getting billings by user id from repository: %w", err)
+	}
+	deleted, err := p.billingRepo.GetAllDeleted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting deleted billings from repository: %w", err)
+	}
+	for _, billing := range deleted {
+		if billing.UserId == userId {
+			billings = append(billings, billing)
+		}
+	}
+	return billings, nil
+}
+
+func New(
+	userRepo usecase.UserRepository,
+	billingRepo usecase.BillingRepository,
+	transactor usecase.Transactor,
+	archiver personal_data.Archiver,
+	auditing auditing.Auditing,
+) personalData {
+	return personalData{
+		userRepo:    userRepo,
+		billingRepo: billingRepo,
+		transactor:  transactor,
+		archiver:    archiver,
+		auditing:    auditing,
+	}
+}
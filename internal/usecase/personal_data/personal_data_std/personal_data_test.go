@@ -0,0 +1,167 @@
+package personal_data_std
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+	"github.com/ThePositree/billing_manager/internal/usecase/memory_repository"
+	"github.com/ThePositree/billing_manager/internal/usecase/personal_data"
+	"github.com/stretchr/testify/require"
+)
+
+// archiver keeps the last export instead of writing it.
+type archiver struct {
+	export personal_data.Export
+}
+
+func (a *archiver) Write(out io.Writer, export personal_data.Export) error {
+	a.export = export
+	return nil
+}
+
+// recorder keeps the recorded actions in memory.
+type recorder struct {
+	actions []string
+	after   []map[string]string
+}
+
+func (a *recorder) Record(ctx context.Context, action string, target audit.Target, before map[string]string, after map[string]string) error {
+	a.actions = append(a.actions, action)
+	a.after = append(a.after, after)
+	return nil
+}
+
+func (a *recorder) Find(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	return nil, nil
+}
+
+type fixture struct {
+	userRepo    *memory_repository.UserRepository
+	billingRepo *memory_repository.BillingRepository
+	archiver    *archiver
+	auditing    *recorder
+	data        personalData
+}
+
+func newFixture() fixture {
+	f := fixture{
+		userRepo:    &memory_repository.UserRepository{},
+		billingRepo: &memory_repository.BillingRepository{},
+		archiver:    &archiver{},
+		auditing:    &recorder{},
+	}
+	f.data = New(f.userRepo, f.billingRepo, memory_repository.Transactor{}, f.archiver, f.auditing)
+	return f
+}
+
+func (f fixture) createUser(t *testing.T, telegramUN string, deleted bool) model_user.User {
+	t.Helper()
+	user := model_user.New(telegramUN)
+	if deleted {
+		user.MarkDeleted("admin")
+	}
+	user, err := f.userRepo.Create(context.Background(), user)
+	require.NoError(t, err)
+	return user
+}
+
+// createBilling stores an invoiced and partly paid billing with a charged
+// revision of the client.
+func (f fixture) createBilling(t *testing.T, userId string, deleted bool) model_billing.Billing {
+	t.Helper()
+	billing, err := model_billing.New(userId)
+	require.NoError(t, err)
+	_, err = billing.SetBriefInfo("client_brief")
+	require.NoError(t, err)
+	require.NoError(t, billing.SetRevisionLimit(model_billing.RevisionLimit{Policy: model_billing.RevisionPolicySurcharge, Surcharge: 500}))
+	require.NoError(t, billing.NextState())
+	require.NoError(t, billing.SetPrice(50000))
+	_, err = billing.RequestRevision("client", "bigger logo")
+	require.NoError(t, err)
+	require.NoError(t, billing.Invoice())
+	_, err = billing.AddPayment(20000, time.Now(), "first part")
+	require.NoError(t, err)
+	if deleted {
+		billing.MarkDeleted("admin")
+	}
+	billing, err = f.billingRepo.Create(context.Background(), billing)
+	require.NoError(t, err)
+	return billing
+}
+
+func billingIds(billings []model_billing.Billing) []string {
+	var ids []string
+	for _, billing := range billings {
+		ids = append(ids, billing.Id)
+	}
+	return ids
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+	user := f.createUser(t, "alice", true)
+	live := f.createBilling(t, user.Id, false)
+	deleted := f.createBilling(t, user.Id, true)
+	other := f.createUser(t, "bob", false)
+	f.createBilling(t, other.Id, true)
+
+	require.NoError(t, f.data.Export(ctx, user.Id, io.Discard))
+	require.Equal(t, user, f.archiver.export.User)
+	require.ElementsMatch(t, []string{live.Id, deleted.Id}, billingIds(f.archiver.export.Billings))
+	require.False(t, f.archiver.export.ExportedAt.IsZero())
+
+	err := f.data.Export(ctx, model_user.New("nobody").Id, io.Discard)
+	require.ErrorIs(t, err, personal_data.ErrUserNotFound)
+}
+
+func TestErase(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+	user := f.createUser(t, "alice", true)
+	live := f.createBilling(t, user.Id, false)
+	deleted := f.createBilling(t, user.Id, true)
+	other := f.createUser(t, "bob", false)
+	otherBilling := f.createBilling(t, other.Id, true)
+
+	erased, err := f.data.Erase(ctx, user.Id)
+	require.NoError(t, err)
+	require.Equal(t, "erased-"+user.Id, erased.TelegramUN)
+	// The user stays soft deleted.
+	stored, err := f.userRepo.GetDeleted(ctx, user.Id)
+	require.NoError(t, err)
+	require.Equal(t, erased, stored)
+	require.Equal(t, []string{audit.ActionUserErase}, f.auditing.actions)
+	require.Equal(t, "2", f.auditing.after[0]["anonymized_billings"])
+
+	for _, want := range []model_billing.Billing{live, deleted} {
+		get := f.billingRepo.Get
+		if want.IsDeleted() {
+			get = f.billingRepo.GetDeleted
+		}
+		got, err := get(ctx, want.Id)
+		require.NoError(t, err)
+		require.Empty(t, got.GetBriefInfo().Username)
+		require.Len(t, got.GetRevisions(), 1)
+		require.Empty(t, got.GetRevisions()[0].RequestedBy)
+		require.Empty(t, got.GetRevisions()[0].Note)
+		// The financial records survive the erasure.
+		require.Equal(t, int64(50000), got.GetPrice())
+		require.Equal(t, want.GetLineItems(), got.GetLineItems())
+		require.NotEmpty(t, got.GetLineItems())
+		require.Equal(t, want.GetPayments(), got.GetPayments())
+		require.Equal(t, int64(50500), got.GetTotal())
+	}
+
+	untouched, err := f.billingRepo.GetDeleted(ctx, otherBilling.Id)
+	require.NoError(t, err)
+	require.Equal(t, "client_brief", untouched.GetBriefInfo().Username)
+
+	_, err = f.data.Erase(ctx, model_user.New("nobody").Id)
+	require.ErrorIs(t, err, personal_data.ErrUserNotFound)
+}
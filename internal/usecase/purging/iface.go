@@ -0,0 +1,18 @@
+package purging
+
+import (
+	"context"
+	"time"
+)
+
+// Result counts the records removed for good by a purge.
+type Result struct {
+	Billings int
+	Users    int
+}
+
+type Purging interface {
+	// Purge removes the users and billings soft deleted before the moment.
+	Purge(ctx context.Context, before time.Time) (Result, error)
+	Run(ctx context.Context)
+}
@@ -0,0 +1,117 @@
+package purging_std
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/purging"
+	"github.com/rs/zerolog"
+)
+
+var _ purging.Purging = &purge{}
+
+type Config struct {
+	Interval time.Duration
+	// Retention is how long soft deleted records can be restored before
+	// they are removed for good.
+	Retention time.Duration
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if cfg.Retention < 0 {
+		return fmt.Errorf("retention cannot be negative")
+	}
+	return nil
+}
+
+type purge struct {
+	logger      zerolog.Logger
+	userRepo    usecase.UserRepository
+	billingRepo usecase.BillingRepository
+	cfg         Config
+}
+
+// Purge removes billings first, a user is kept while any of its deleted
+// billings is still retained. Records restored after they were listed are
+// not deleted, the repositories check the deletion time again.
+func (p *purge) Purge(ctx context.Context, before time.Time) (purging.Result, error) {
+	var result purging.Result
+
+	billings, err := p.billingRepo.GetAllDeleted(ctx)
+	if err != nil {
+		return result, fmt.Errorf("getting deleted billings from repository: %w", err)
+	}
+	retainedUsers := map[string]struct{}{}
+	for _, billing := range billings {
+		if !billing.GetDeletedAt().Before(before) {
+			retainedUsers[billing.UserId] = struct{}{}
+			continue
+		}
+		_, err := p.billingRepo.Delete(ctx, billing.Id, before)
+		if errors.Is(p.billingRepo.GetNoDataError(), err) {
+			retainedUsers[billing.UserId] = struct{}{}
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("deleting billing %s from repository: %w", billing.Id, err)
+		}
+		result.Billings++
+	}
+
+	users, err := p.userRepo.GetAllDeleted(ctx)
+	if err != nil {
+		return result, fmt.Errorf("getting deleted users from repository: %w", err)
+	}
+	for _, user := range users {
+		if _, ok := retainedUsers[user.Id]; ok || !user.GetDeletedAt().Before(before) {
+			continue
+		}
+		_, err := p.userRepo.Delete(ctx, user.Id, before)
+		if errors.Is(p.userRepo.GetNoDataError(), err) {
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("deleting user %s from repository: %w", user.Id, err)
+		}
+		result.Users++
+	}
+
+	return result, nil
+}
+
+func (p *purge) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		result, err := p.Purge(ctx, time.Now().Add(-p.cfg.Retention))
+		if err != nil {
+			p.logger.Error().Err(err).Msg("Purge deleted records")
+		}
+		if result.Billings != 0 || result.Users != 0 {
+			p.logger.Info().Int("Billings", result.Billings).Int("Users", result.Users).Msg("Deleted records purged")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func New(logger zerolog.Logger, userRepo usecase.UserRepository, billingRepo usecase.BillingRepository, cfg Config) (*purge, error) {
+	if err := cfg.Validate(); err != nil {
+		return &purge{}, fmt.Errorf("config validate: %w", err)
+	}
+	return &purge{
+		logger:      logger,
+		userRepo:    userRepo,
+		billingRepo: billingRepo,
+		cfg:         cfg,
+	}, nil
+}
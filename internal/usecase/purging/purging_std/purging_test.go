@@ -0,0 +1,115 @@
+package purging_std
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_user "github.com/ThePositree/billing_manager/internal/model/user"
+	"github.com/ThePositree/billing_manager/internal/usecase/memory_repository"
+	"github.com/ThePositree/billing_manager/internal/usecase/purging"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// restoringBillingRepository restores a billing right after the deleted
+// billings are listed, like an admin racing the purge.
+type restoringBillingRepository struct {
+	*memory_repository.BillingRepository
+	restore string
+}
+
+func (r restoringBillingRepository) GetAllDeleted(ctx context.Context) ([]model_billing.Billing, error) {
+	billings, err := r.BillingRepository.GetAllDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	billing, err := r.GetDeleted(ctx, r.restore)
+	if err != nil {
+		return nil, err
+	}
+	billing.Restore()
+	if _, err := r.Update(ctx, billing); err != nil {
+		return nil, err
+	}
+	return billings, nil
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &memory_repository.UserRepository{}
+	billingRepo := &memory_repository.BillingRepository{}
+
+	createUser := func(deleted bool) model_user.User {
+		user := model_user.New("user")
+		if deleted {
+			user.MarkDeleted("admin")
+		}
+		_, err := userRepo.Create(ctx, user)
+		require.NoError(t, err)
+		return user
+	}
+	createBilling := func(userId string) model_billing.Billing {
+		billing, err := model_billing.New(userId)
+		require.NoError(t, err)
+		billing.MarkDeleted("admin")
+		_, err = billingRepo.Create(ctx, billing)
+		require.NoError(t, err)
+		return billing
+	}
+
+	live := createUser(false)
+	purged := createUser(true)
+	// The user of a restored billing stays, the billing needs it.
+	keptForRestored := createUser(true)
+	purgedBilling := createBilling(purged.Id)
+	restoredBilling := createBilling(keptForRestored.Id)
+	liveUserBilling := createBilling(live.Id)
+
+	p, err := New(zerolog.Nop(), userRepo, restoringBillingRepository{BillingRepository: billingRepo, restore: restoredBilling.Id}, Config{Interval: time.Hour})
+	require.NoError(t, err)
+	result, err := p.Purge(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, purging.Result{Billings: 2, Users: 1}, result)
+
+	_, err = billingRepo.GetDeleted(ctx, purgedBilling.Id)
+	require.ErrorIs(t, err, memory_repository.ErrNoData)
+	_, err = billingRepo.GetDeleted(ctx, liveUserBilling.Id)
+	require.ErrorIs(t, err, memory_repository.ErrNoData)
+	_, err = billingRepo.Get(ctx, restoredBilling.Id)
+	require.NoError(t, err)
+	_, err = userRepo.GetDeleted(ctx, purged.Id)
+	require.ErrorIs(t, err, memory_repository.ErrNoData)
+	_, err = userRepo.GetDeleted(ctx, keptForRestored.Id)
+	require.NoError(t, err)
+	_, err = userRepo.Get(ctx, live.Id)
+	require.NoError(t, err)
+}
+
+func TestPurgeRetained(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &memory_repository.UserRepository{}
+	billingRepo := &memory_repository.BillingRepository{}
+
+	user := model_user.New("user")
+	user.MarkDeleted("admin")
+	_, err := userRepo.Create(ctx, user)
+	require.NoError(t, err)
+	billing, err := model_billing.New(user.Id)
+	require.NoError(t, err)
+	billing.MarkDeleted("admin")
+	_, err = billingRepo.Create(ctx, billing)
+	require.NoError(t, err)
+
+	p, err := New(zerolog.Nop(), userRepo, billingRepo, Config{Interval: time.Hour})
+	require.NoError(t, err)
+	// Records deleted at the moment itself are still retained.
+	result, err := p.Purge(ctx, billing.GetDeletedAt())
+	require.NoError(t, err)
+	require.Equal(t, purging.Result{}, result)
+	_, err = billingRepo.GetDeleted(ctx, billing.Id)
+	require.NoError(t, err)
+	_, err = userRepo.GetDeleted(ctx, user.Id)
+	require.NoError(t, err)
+}
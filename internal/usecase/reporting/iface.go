@@ -0,0 +1,161 @@
+package reporting
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/model/user"
+)
+
+var (
+	ErrInvalidPeriod = errors.New("not a valid period")
+	ErrInvalidRange  = errors.New("report range start must be before its end")
+	ErrUserNotFound  = errors.New("user not found")
+)
+
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+)
+
+func ParsePeriod(name string) (Period, error) {
+	switch Period(name) {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+		return Period(name), nil
+	}
+	return Period(""), ErrInvalidPeriod
+}
+
+// PeriodCount is the number of billings in a period labelled like
+// 2024-03-01 for days, 2024-W09 for ISO weeks and 2024-03 for months.
+type PeriodCount struct {
+	Period string
+	Count  int
+}
+
+// DurationStats describes how long billings stayed in a stage, only
+// stages that the billing has already left are taken into account.
+type DurationStats struct {
+	Samples int
+	Median  time.Duration
+	P90     time.Duration
+}
+
+type ClientCount struct {
+	UserId     string
+	TelegramUN string
+	Count      int
+}
+
+type SummaryQuery struct {
+	Period     Period
+	From       time.Time
+	To         time.Time
+	TopClients int
+}
+
+type Summary struct {
+	Query          SummaryQuery
+	CountsByState  map[billing.State]int
+	Created        []PeriodCount
+	Completed      []PeriodCount
+	StageDurations map[billing.State]DurationStats
+	TopClients     []ClientCount
+}
+
+// PeriodAmount is a sum of money in minor currency units per period
+// labelled the same way as PeriodCount.
+type PeriodAmount struct {
+	Period string
+	Amount int64
+}
+
+type RevenueQuery struct {
+	Period Period
+	From   time.Time
+	To     time.Time
+}
+
+type PeriodRevenue struct {
+	Period    string
+	Invoiced  int64
+	Collected int64
+}
+
+type Revenue struct {
+	Query   RevenueQuery
+	Periods []PeriodRevenue
+}
+
+// AgingBucket holds unpaid invoices whose age in days is in [MinDays, MaxDays],
+// negative MaxDays means the bucket has no upper bound.
+type AgingBucket struct {
+	Name        string
+	MinDays     int
+	MaxDays     int
+	Count       int
+	Outstanding int64
+}
+
+type Receivables struct {
+	AsOf    time.Time
+	Buckets []AgingBucket
+	Total   int64
+}
+
+type StatementLineKind string
+
+const (
+	StatementLineInvoice StatementLineKind = "invoice"
+	StatementLinePayment StatementLineKind = "payment"
+)
+
+// StatementLine is an invoice debiting or a payment crediting the client balance.
+type StatementLine struct {
+	Date        time.Time
+	BillingId   string
+	Kind        StatementLineKind
+	Description string
+	Debit       int64
+	Credit      int64
+	Balance     int64
+}
+
+type StatementQuery struct {
+	UserId string
+	From   time.Time
+	To     time.Time
+}
+
+type Statement struct {
+	Query          StatementQuery
+	User           user.User
+	OpeningBalance int64
+	Lines          []StatementLine
+	ClosingBalance int64
+}
+
+type ReportRepository interface {
+	CountByState(ctx context.Context) (map[billing.State]int, error)
+	CreatedPerPeriod(ctx context.Context, period Period, from time.Time, to time.Time) ([]PeriodCount, error)
+	CompletedPerPeriod(ctx context.Context, period Period, from time.Time, to time.Time) ([]PeriodCount, error)
+	StageDurations(ctx context.Context) (map[billing.State]DurationStats, error)
+	TopClients(ctx context.Context, limit int) ([]ClientCount, error)
+	InvoicedPerPeriod(ctx context.Context, period Period, from time.Time, to time.Time) ([]PeriodAmount, error)
+	CollectedPerPeriod(ctx context.Context, period Period, from time.Time, to time.Time) ([]PeriodAmount, error)
+	// ReceivablesByAge groups unpaid invoices by their age in days using
+	// bucket lower bounds, the last bound collects everything older.
+	ReceivablesByAge(ctx context.Context, asOf time.Time, lowerBounds []int) ([]AgingBucket, error)
+}
+
+type Reporting interface {
+	GetSummary(ctx context.Context, query SummaryQuery) (Summary, error)
+	GetRevenue(ctx context.Context, query RevenueQuery) (Revenue, error)
+	GetReceivables(ctx context.Context, asOf time.Time) (Receivables, error)
+	GetStatement(ctx context.Context, query StatementQuery) (Statement, error)
+}
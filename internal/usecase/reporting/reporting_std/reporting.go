@@ -0,0 +1,263 @@
+package reporting_std
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/reporting"
+)
+
+var _ reporting.Reporting = reportingStd{}
+
+const (
+	defaultRange      = time.Hour * 24 * 30
+	defaultTopClients = 10
+)
+
+// agingBuckets are the receivables aging buckets, only MaxDays of the last one is unbounded.
+var agingBuckets = []reporting.AgingBucket{
+	{Name: "0-30", MinDays: 0, MaxDays: 30},
+	{Name: "31-60", MinDays: 31, MaxDays: 60},
+	{Name: "61-90", MinDays: 61, MaxDays: 90},
+	{Name: "90+", MinDays: 91, MaxDays: -1},
+}
+
+type reportingStd struct {
+	reportRepo  reporting.ReportRepository
+	userRepo    usecase.UserRepository
+	billingRepo usecase.BillingRepository
+}
+
+// GetSummary fills missing query values with defaults: daily periods
+// over the last 30 days and the top 10 clients.
+func (r reportingStd) GetSummary(ctx context.Context, query reporting.SummaryQuery) (reporting.Summary, error) {
+	if query.Period == "" {
+		query.Period = reporting.PeriodDay
+	}
+	if _, err := reporting.ParsePeriod(string(query.Period)); err != nil {
+		return reporting.Summary{}, err
+	}
+	if query.To.IsZero() {
+		query.To = time.Now().UTC()
+	}
+	if query.From.IsZero() {
+		query.From = query.To.Add(-defaultRange)
+	}
+	if !query.From.Before(query.To) {
+		return reporting.Summary{}, reporting.ErrInvalidRange
+	}
+	if query.TopClients <= 0 {
+		query.TopClients = defaultTopClients
+	}
+
+	countsByState, err := r.reportRepo.CountByState(ctx)
+	if err != nil {
+		return reporting.Summary{}, fmt.Errorf("counting billings by state in repository: %w", err)
+	}
+
+	created, err := r.reportRepo.CreatedPerPeriod(ctx, query.Period, query.From, query.To)
+	if err != nil {
+		return reporting.Summary{}, fmt.Errorf("counting created billings in repository: %w", err)
+	}
+
+	completed, err := r.reportRepo.CompletedPerPeriod(ctx, query.Period, query.From, query.To)
+	if err != nil {
+		return reporting.Summary{}, fmt.Errorf("counting completed billings in repository: %w", err)
+	}
+
+	stageDurations, err := r.reportRepo.StageDurations(ctx)
+	if err != nil {
+		return reporting.Summary{}, fmt.Errorf("getting stage durations from repository: %w", err)
+	}
+
+	topClients, err := r.reportRepo.TopClients(ctx, query.TopClients)
+	if err != nil {
+		return reporting.Summary{}, fmt.Errorf("getting top clients from repository: %w", err)
+	}
+
+	return reporting.Summary{
+		Query:          query,
+		CountsByState:  countsByState,
+		Created:        created,
+		Completed:      completed,
+		StageDurations: stageDurations,
+		TopClients:     topClients,
+	}, nil
+}
+
+func (r reportingStd) GetRevenue(ctx context.Context, query reporting.RevenueQuery) (reporting.Revenue, error) {
+	if query.Period == "" {
+		query.Period = reporting.PeriodMonth
+	}
+	if _, err := reporting.ParsePeriod(string(query.Period)); err != nil {
+		return reporting.Revenue{}, err
+	}
+	if query.To.IsZero() {
+		query.To = time.Now().UTC()
+	}
+	if query.From.IsZero() {
+		query.From = query.To.AddDate(-1, 0, 0)
+	}
+	if !query.From.Before(query.To) {
+		return reporting.Revenue{}, reporting.ErrInvalidRange
+	}
+
+	invoiced, err := r.reportRepo.InvoicedPerPeriod(ctx, query.Period, query.From, query.To)
+	if err != nil {
+		return reporting.Revenue{}, fmt.Errorf("summing invoiced amounts in repository: %w", err)
+	}
+
+	collected, err := r.reportRepo.CollectedPerPeriod(ctx, query.Period, query.From, query.To)
+	if err != nil {
+		return reporting.Revenue{}, fmt.Errorf("summing collected amounts in repository: %w", err)
+	}
+
+	byPeriod := map[string]*reporting.PeriodRevenue{}
+	periodRevenue := func(period string) *reporting.PeriodRevenue {
+		revenue, ok := byPeriod[period]
+		if !ok {
+			revenue = &reporting.PeriodRevenue{Period: period}
+			byPeriod[period] = revenue
+		}
+		return revenue
+	}
+	for _, amount := range invoiced {
+		periodRevenue(amount.Period).Invoiced += amount.Amount
+	}
+	for _, amount := range collected {
+		periodRevenue(amount.Period).Collected += amount.Amount
+	}
+
+	periods := []reporting.PeriodRevenue{}
+	for _, revenue := range byPeriod {
+		periods = append(periods, *revenue)
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Period < periods[j].Period
+	})
+
+	return reporting.Revenue{
+		Query:   query,
+		Periods: periods,
+	}, nil
+}
+
+func (r reportingStd) GetReceivables(ctx context.Context, asOf time.Time) (reporting.Receivables, error) {
+	if asOf.IsZero() {
+		asOf = time.Now().UTC()
+	}
+
+	lowerBounds := []int{}
+	for _, bucket := range agingBuckets {
+		lowerBounds = append(lowerBounds, bucket.MinDays)
+	}
+
+	found, err := r.reportRepo.ReceivablesByAge(ctx, asOf, lowerBounds)
+	if err != nil {
+		return reporting.Receivables{}, fmt.Errorf("grouping receivables by age in repository: %w", err)
+	}
+
+	result := reporting.Receivables{AsOf: asOf}
+	for _, bucket := range agingBuckets {
+		for _, foundBucket := range found {
+			if foundBucket.MinDays == bucket.MinDays {
+				bucket.Count = foundBucket.Count
+				bucket.Outstanding = foundBucket.Outstanding
+			}
+		}
+		result.Buckets = append(result.Buckets, bucket)
+		result.Total += bucket.Outstanding
+	}
+
+	return result, nil
+}
+
+// GetStatement lists invoices and payments of the client in chronological
+// order with a running balance, everything before the range is folded
+// into the opening balance.
+func (r reportingStd) GetStatement(ctx context.Context, query reporting.StatementQuery) (reporting.Statement, error) {
+	if query.To.IsZero() {
+		query.To = time.Now().UTC()
+	}
+	if !query.From.IsZero() && !query.From.Before(query.To) {
+		return reporting.Statement{}, reporting.ErrInvalidRange
+	}
+
+	user, err := r.userRepo.Get(ctx, query.UserId)
+	if errors.Is(r.userRepo.GetNoDataError(), err) {
+		return reporting.Statement{}, reporting.ErrUserNotFound
+	}
+	if err != nil {
+		return reporting.Statement{}, fmt.Errorf("getting user by id from repository: %w", err)
+	}
+
+	billings, err := r.billingRepo.GetByUserId(ctx, user.Id)
+	if err != nil {
+		return reporting.Statement{}, fmt.Errorf("getting billings by user id from repository: %w", err)
+	}
+
+	var lines []reporting.StatementLine
+	for _, billing := range billings {
+		invoicedAt := billing.GetInvoicedAt()
+		if invoicedAt.IsZero() {
+			continue
+		}
+		lines = append(lines, reporting.StatementLine{
+			Date:        invoicedAt,
+			BillingId:   billing.Id,
+			Kind:        reporting.StatementLineInvoice,
+			Description: fmt.Sprintf("invoice for billing %s", billing.Id),
+			Debit:       billing.GetTotal(),
+		})
+		for _, payment := range billing.GetPayments() {
+			lines = append(lines, reporting.StatementLine{
+				Date:        payment.PaidAt,
+				BillingId:   billing.Id,
+				Kind:        reporting.StatementLinePayment,
+				Description: payment.Note,
+				Credit:      payment.Amount,
+			})
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Date.Before(lines[j].Date)
+	})
+
+	result := reporting.Statement{
+		Query: query,
+		User:  user,
+		Lines: []reporting.StatementLine{},
+	}
+	balance := int64(0)
+	for _, line := range lines {
+		if line.Date.After(query.To) {
+			break
+		}
+		balance += line.Debit - line.Credit
+		if line.Date.Before(query.From) {
+			result.OpeningBalance = balance
+			continue
+		}
+		line.Balance = balance
+		result.Lines = append(result.Lines, line)
+	}
+	result.ClosingBalance = balance
+
+	return result, nil
+}
+
+func New(
+	reportRepo reporting.ReportRepository,
+	userRepo usecase.UserRepository,
+	billingRepo usecase.BillingRepository,
+) reportingStd {
+	return reportingStd{
+		reportRepo:  reportRepo,
+		userRepo:    userRepo,
+		billingRepo: billingRepo,
+	}
+}
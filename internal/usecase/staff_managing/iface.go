@@ -0,0 +1,29 @@
+package staff_managing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ThePositree/billing_manager/internal/model/billing"
+	"github.com/ThePositree/billing_manager/internal/model/staff"
+)
+
+var (
+	ErrStaffNotFound    = errors.New("staff not found")
+	ErrStaffHasBillings = errors.New("staff has assigned billings")
+)
+
+// Workload counts actionable billings whose current stage is assigned to the staff member.
+type Workload struct {
+	Staff   staff.Staff
+	ByState map[billing.State]int
+	Total   int
+}
+
+type StaffManaging interface {
+	GetById(ctx context.Context, id string) (staff.Staff, error)
+	GetAll(ctx context.Context) ([]staff.Staff, error)
+	Create(ctx context.Context, name string) (staff.Staff, error)
+	Delete(ctx context.Context, id string) (staff.Staff, error)
+	GetWorkloads(ctx context.Context) ([]Workload, error)
+}
@@ -0,0 +1,190 @@
+package staff_managing_std
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ThePositree/billing_manager/internal/model/audit"
+	model_billing "github.com/ThePositree/billing_manager/internal/model/billing"
+	model_staff "github.com/ThePositree/billing_manager/internal/model/staff"
+	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
+	"github.com/ThePositree/billing_manager/internal/usecase/staff_managing"
+)
+
+var _ staff_managing.StaffManaging = staffManaging{}
+
+type staffManaging struct {
+	staffRepo   usecase.StaffRepository
+	billingRepo usecase.BillingRepository
+	transactor  usecase.Transactor
+	auditing    auditing.Auditing
+}
+
+func (s staffManaging) Create(ctx context.Context, name string) (model_staff.Staff, error) {
+	var result model_staff.Staff
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		staff, err := s.staffRepo.Create(ctx, model_staff.New(name))
+		if err != nil {
+			return fmt.Errorf("creating new staff from repository: %w", err)
+		}
+
+		result = staff
+		return s.record(ctx, audit.ActionStaffCreate, staff.Id, nil, auditing.StaffFields(staff))
+	})
+	if err != nil {
+		return model_staff.Staff{}, err
+	}
+	return result, nil
+}
+
+func (s staffManaging) GetAll(ctx context.Context) ([]model_staff.Staff, error) {
+	staffMembers, err := s.staffRepo.GetAll(ctx)
+	if err != nil {
+		return []model_staff.Staff{}, fmt.Errorf("getting all staff from repository: %w", err)
+	}
+
+	return staffMembers, nil
+}
+
+func (s staffManaging) GetById(ctx context.Context, id string) (model_staff.Staff, error) {
+	staff, err := s.staffRepo.Get(ctx, id)
+	if errors.Is(s.staffRepo.GetNoDataError(), err) {
+		return model_staff.Staff{}, staff_managing.ErrStaffNotFound
+	}
+	if err != nil {
+		return model_staff.Staff{}, fmt.Errorf("getting staff by id from repository: %w", err)
+	}
+	return staff, nil
+}
+
+func (s staffManaging) Delete(ctx context.Context, id string) (model_staff.Staff, error) {
+	var result model_staff.Staff
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		assigned, err := s.hasAssignedBillings(ctx, id)
+		if err != nil {
+			return err
+		}
+		if assigned {
+			return staff_managing.ErrStaffHasBillings
+		}
+
+		result, err = s.staffRepo.Delete(ctx, id)
+		if errors.Is(s.staffRepo.GetNoDataError(), err) {
+			return staff_managing.ErrStaffNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("deleting staff from repository: %w", err)
+		}
+		return s.record(ctx, audit.ActionStaffDelete, result.Id, auditing.StaffFields(result), nil)
+	})
+	if err != nil {
+		return model_staff.Staff{}, err
+	}
+	return result, nil
+}
+
+func (s staffManaging) GetWorkloads(ctx context.Context) ([]staff_managing.Workload, error) {
+	staffMembers, err := s.staffRepo.GetAll(ctx)
+	if err != nil {
+		return []staff_managing.Workload{}, fmt.Errorf("getting all staff from repository: %w", err)
+	}
+
+	workloads, err := s.getWorkloads(ctx)
+	if err != nil {
+		return []staff_managing.Workload{}, err
+	}
+
+	result := []staff_managing.Workload{}
+	for _, staff := range staffMembers {
+		workload := staff_managing.Workload{
+			Staff:   staff,
+			ByState: map[model_billing.State]int{},
+		}
+		if counted, ok := workloads[staff.Id]; ok {
+			workload.ByState = counted.ByState
+			workload.Total = counted.Total
+		}
+		result = append(result, workload)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Staff.Name < result[j].Staff.Name
+	})
+
+	return result, nil
+}
+
+func (s staffManaging) getWorkloads(ctx context.Context) (map[string]*staff_managing.Workload, error) {
+	billings, err := s.billingRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting all billings from repository: %w", err)
+	}
+
+	workloads := map[string]*staff_managing.Workload{}
+	for _, billing := range billings {
+		if !billing.IsActionable() {
+			continue
+		}
+		staffId, ok := billing.GetCurrentAssignee()
+		if !ok {
+			continue
+		}
+		workload, ok := workloads[staffId]
+		if !ok {
+			workload = &staff_managing.Workload{ByState: map[model_billing.State]int{}}
+			workloads[staffId] = workload
+		}
+		workload.ByState[billing.GetState()]++
+		workload.Total++
+	}
+
+	return workloads, nil
+}
+
+// hasAssignedBillings reports whether the staff member is assigned to any
+// stage, current or upcoming, of a billing that is not completed.
+func (s staffManaging) hasAssignedBillings(ctx context.Context, staffId string) (bool, error) {
+	billings, err := s.billingRepo.GetAll(ctx)
+	if err != nil {
+		return false, fmt.Errorf("getting all billings from repository: %w", err)
+	}
+
+	for _, billing := range billings {
+		if !billing.IsActionable() {
+			continue
+		}
+		for _, assignee := range billing.GetAssignees() {
+			if assignee == staffId {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (s staffManaging) record(ctx context.Context, action string, id string, before map[string]string, after map[string]string) error {
+	target := audit.Target{Type: audit.TargetStaff, Id: id}
+	if err := s.auditing.Record(ctx, action, target, before, after); err != nil {
+		return fmt.Errorf("recording audit entry: %w", err)
+	}
+	return nil
+}
+
+func New(
+	staffRepo usecase.StaffRepository,
+	billingRepo usecase.BillingRepository,
+	transactor usecase.Transactor,
+	auditing auditing.Auditing,
+) staffManaging {
+	return staffManaging{
+		staffRepo:   staffRepo,
+		billingRepo: billingRepo,
+		transactor:  transactor,
+		auditing:    auditing,
+	}
+}
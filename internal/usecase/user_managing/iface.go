@@ -8,8 +8,9 @@ import (
 )
 
 var (
-	ErrExistingUser = errors.New("user is existing")
-	ErrUserNotFound = errors.New("user not found")
+	ErrExistingUser    = errors.New("user is existing")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrUserHasBillings = errors.New("user has billings")
 )
 
 type UserManaging interface {
@@ -17,5 +18,8 @@ type UserManaging interface {
 	GetById(ctx context.Context, id string) (user.User, error)
 	GetAll(ctx context.Context) ([]user.User, error)
 	Create(ctx context.Context, telegramUN string) (user.User, error)
-	Delete(ctx context.Context, id string) (user.User, error)
+	// Delete soft deletes the user, it can be restored until it is purged.
+	Delete(ctx context.Context, id string, deletedBy string) (user.User, error)
+	Restore(ctx context.Context, id string) (user.User, error)
+	GetAllDeleted(ctx context.Context) ([]user.User, error)
 }
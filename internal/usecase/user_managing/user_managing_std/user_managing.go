@@ -5,15 +5,20 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/ThePositree/billing_manager/internal/model/audit"
 	model_user "github.com/ThePositree/billing_manager/internal/model/user"
 	"github.com/ThePositree/billing_manager/internal/usecase"
+	"github.com/ThePositree/billing_manager/internal/usecase/auditing"
 	"github.com/ThePositree/billing_manager/internal/usecase/user_managing"
 )
 
 var _ user_managing.UserManaging = userManaging{}
 
 type userManaging struct {
-	userRepo usecase.UserRepository
+	userRepo    usecase.UserRepository
+	billingRepo usecase.BillingRepository
+	transactor  usecase.Transactor
+	auditing    auditing.Auditing
 }
 
 func (u userManaging) Create(ctx context.Context, telegramUN string) (model_user.User, error) {
@@ -29,6 +34,9 @@ func (u userManaging) Create(ctx context.Context, telegramUN string) (model_user
 	}
 
 	user, err = u.userRepo.Create(ctx, user)
+	if errors.Is(u.userRepo.GetAlreadyExistsError(), err) {
+		return model_user.User{}, user_managing.ErrExistingUser
+	}
 	if err != nil {
 		return model_user.User{}, fmt.Errorf("creating new user from repository: %w", err)
 	}
@@ -67,19 +75,94 @@ func (u userManaging) GetById(ctx context.Context, id string) (model_user.User,
 	return user, nil
 }
 
-func (u userManaging) Delete(ctx context.Context, id string) (model_user.User, error) {
-	user, err := u.userRepo.Delete(ctx, id)
-	if errors.Is(u.userRepo.GetNoDataError(), err) {
-		return model_user.User{}, user_managing.ErrUserNotFound
+func (u userManaging) Delete(ctx context.Context, id string, deletedBy string) (model_user.User, error) {
+	var result model_user.User
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		billings, err := u.billingRepo.GetByUserId(ctx, id)
+		if err != nil {
+			return fmt.Errorf("getting billings by user id from repository: %w", err)
+		}
+		if len(billings) != 0 {
+			return user_managing.ErrUserHasBillings
+		}
+
+		user, err := u.userRepo.Get(ctx, id)
+		if errors.Is(u.userRepo.GetNoDataError(), err) {
+			return user_managing.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("getting user by id from repository: %w", err)
+		}
+
+		before := auditing.UserFields(user)
+		user.MarkDeleted(deletedBy)
+		result, err = u.userRepo.Update(ctx, user)
+		if err != nil {
+			return fmt.Errorf("updating user in repository: %w", err)
+		}
+		return u.record(ctx, audit.ActionUserDelete, result, before)
+	})
+	if err != nil {
+		return model_user.User{}, err
+	}
+	return result, nil
+}
+
+func (u userManaging) Restore(ctx context.Context, id string) (model_user.User, error) {
+	var result model_user.User
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := u.userRepo.GetDeleted(ctx, id)
+		if errors.Is(u.userRepo.GetNoDataError(), err) {
+			return user_managing.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("getting deleted user by id from repository: %w", err)
+		}
+
+		before := auditing.UserFields(user)
+		user.Restore()
+		result, err = u.userRepo.Update(ctx, user)
+		if errors.Is(u.userRepo.GetAlreadyExistsError(), err) {
+			return user_managing.ErrExistingUser
+		}
+		if err != nil {
+			return fmt.Errorf("updating user in repository: %w", err)
+		}
+		return u.record(ctx, audit.ActionUserRestore, result, before)
+	})
+	if err != nil {
+		return model_user.User{}, err
 	}
+	return result, nil
+}
+
+func (u userManaging) GetAllDeleted(ctx context.Context) ([]model_user.User, error) {
+	users, err := u.userRepo.GetAllDeleted(ctx)
 	if err != nil {
-		return model_user.User{}, fmt.Errorf("deleting user from repository: %w", err)
+		return []model_user.User{}, fmt.Errorf("getting deleted users from repository: %w", err)
 	}
-	return user, nil
+
+	return users, nil
+}
+
+func (u userManaging) record(ctx context.Context, action string, user model_user.User, before map[string]string) error {
+	target := audit.Target{Type: audit.TargetUser, Id: user.Id}
+	if err := u.auditing.Record(ctx, action, target, before, auditing.UserFields(user)); err != nil {
+		return fmt.Errorf("recording audit entry: %w", err)
+	}
+	return nil
 }
 
-func New(userRepo usecase.UserRepository) userManaging {
+func New(
+	userRepo usecase.UserRepository,
+	billingRepo usecase.BillingRepository,
+	transactor usecase.Transactor,
+	auditing auditing.Auditing,
+) userManaging {
 	return userManaging{
-		userRepo: userRepo,
+		userRepo:    userRepo,
+		billingRepo: billingRepo,
+		transactor:  transactor,
+		auditing:    auditing,
 	}
 }